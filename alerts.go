@@ -0,0 +1,132 @@
+package hue
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// Colors used by alert patterns.
+var (
+	alertRed  = XY{0.675, 0.322}
+	alertBlue = XY{0.167, 0.04}
+)
+
+// AlertPattern builds the sequence of steps which plays an attention pattern
+// on group g, whose lights are given sorted by ID.
+type AlertPattern func(g *Group, lights []*Light) Step
+
+// PoliceStrobe returns a pattern which alternates red and blue between the
+// two halves of the lights of a group, twice per second, for the duration d.
+// A group with a single light alternates its color. Since each light is sent
+// its own commands, large groups may exceed the rate at which the bridge
+// accepts commands.
+func PoliceStrobe(d time.Duration) AlertPattern {
+	const interval = 500 * time.Millisecond
+	return func(g *Group, lights []*Light) Step {
+		half := (len(lights) + 1) / 2
+		var steps []Step
+		for i := 0; time.Duration(i)*interval < d; i++ {
+			var set []Step
+			for j, l := range lights {
+				xy := alertRed
+				if (j < half) == (i%2 == 1) {
+					xy = alertBlue
+				}
+				set = append(set, SetLight(l, &State{On: true, Brightness: 254, XY: &xy, TransitionTime: 1}))
+			}
+			steps = append(steps, Sequence(set...), Wait(interval))
+		}
+		return Sequence(steps...)
+	}
+}
+
+// SlowRedPulse returns a pattern which slowly pulses the lights of a group
+// red the given number of times, taking four seconds per pulse.
+func SlowRedPulse(pulses int) AlertPattern {
+	return func(g *Group, lights []*Light) Step {
+		var steps []Step
+		for i := 0; i < pulses; i++ {
+			steps = append(steps,
+				SetGroup(g, &State{On: true, Brightness: 254, XY: &alertRed, TransitionTime: 20}),
+				Wait(2*time.Second),
+				SetGroup(g, &State{On: true, Brightness: 1, XY: &alertRed, TransitionTime: 20}),
+				Wait(2*time.Second),
+			)
+		}
+		return Sequence(steps...)
+	}
+}
+
+// DoorbellFlash returns a pattern which flashes the lights of a group bright
+// white three times.
+func DoorbellFlash() AlertPattern {
+	return func(g *Group, lights []*Light) Step {
+		var steps []Step
+		for i := 0; i < 3; i++ {
+			steps = append(steps,
+				SetGroup(g, &State{On: true, Brightness: 254, Ct: 233, TransitionTime: 1}),
+				Wait(500*time.Millisecond),
+				SetGroup(g, &State{On: true, Brightness: 1, TransitionTime: 1}),
+				Wait(500*time.Millisecond),
+			)
+		}
+		return Sequence(steps...)
+	}
+}
+
+// Notify plays the attention pattern p on the lights of the group, using the
+// clock of the bridge, and gives the lights back the state which they were in
+// before once it completes, fails or the context is done. Notifications are
+// automated changes: during the quiet hours of the group, Notify does nothing
+// and returns ErrQuietHours. Otherwise, it returns the error of the pattern,
+// if any, or a *LightsError identifying the lights which could not be
+// restored.
+func (g *Group) Notify(ctx context.Context, p AlertPattern) error {
+	if g.isQuiet(g.bridge.now()) {
+		return ErrQuietHours
+	}
+	lights, err := g.Members().List()
+	if err != nil {
+		return err
+	}
+	sort.Slice(lights, func(i, j int) bool { return lessID(lights[i].ID, lights[j].ID) })
+	saved := saveStates(lights)
+	err = p(g, lights).RunWithClock(ctx, clockOf(g.bridge.clock), nil)
+	if rerr := restoreStates(lights, saved); rerr != nil && err == nil {
+		err = rerr
+	}
+	return err
+}
+
+// saveStates returns the states of the given lights.
+func saveStates(lights []*Light) []LightState {
+	saved := make([]LightState, len(lights))
+	for i, l := range lights {
+		saved[i] = l.State
+	}
+	return saved
+}
+
+// restoreStates gives the lights the states saved using saveStates. Lights
+// which were off get their color back before being turned off, since the
+// bridge does not change lights which are off. It returns a *LightsError
+// identifying the lights which could not be restored.
+func restoreStates(lights []*Light, saved []LightState) error {
+	errs := make(map[string]error)
+	for i, l := range lights {
+		ls := saved[i]
+		ls.On = true
+		err := l.restore(ls)
+		if err == nil && !saved[i].On {
+			err = l.Off()
+		}
+		if err != nil {
+			errs[l.ID] = err
+		}
+	}
+	if len(errs) > 0 {
+		return &LightsError{Errors: errs}
+	}
+	return nil
+}
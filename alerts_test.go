@@ -0,0 +1,116 @@
+package hue_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gbbr.io/hue"
+	"gbbr.io/hue/huetest"
+)
+
+func TestNotify(t *testing.T) {
+	start := time.Date(2017, 1, 1, 20, 0, 0, 0, time.UTC)
+	for name, p := range map[string]hue.AlertPattern{
+		"strobe":   hue.PoliceStrobe(time.Second),
+		"pulse":    hue.SlowRedPulse(2),
+		"doorbell": hue.DoorbellFlash(),
+	} {
+		t.Run(name, func(t *testing.T) {
+			sim := huetest.NewSimulator(start, "a", "b")
+			defer sim.Close()
+			b := sim.Bridge()
+			g, err := b.Groups().GetByID(sim.AddGroup("Hall", "1", "2"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			before, err := hue.LightsByID(b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := g.Notify(context.Background(), p); err != nil {
+				t.Fatal(err)
+			}
+			if n := len(sim.Timeline()); n < 6 {
+				t.Fatalf("expected the pattern to be played, got %d frames", n)
+			}
+			after, err := hue.LightsByID(b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for id, l := range before {
+				if got := after[id].State; got.On != l.State.On || got.ColorMode != l.State.ColorMode {
+					t.Fatalf("light %s not restored: %+v", id, got)
+				}
+			}
+		})
+	}
+}
+
+func TestPoliceStrobeHalves(t *testing.T) {
+	sim := huetest.NewSimulator(time.Date(2017, 1, 1, 20, 0, 0, 0, time.UTC), "a", "b")
+	defer sim.Close()
+	b := sim.Bridge()
+	g, err := b.Groups().GetByID(sim.AddGroup("Hall", "1", "2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Notify(context.Background(), hue.PoliceStrobe(time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	colors := make(map[string][]hue.XY)
+	for _, f := range sim.Timeline()[2:] {
+		if f.State.ColorMode == "xy" {
+			colors[f.Light] = append(colors[f.Light], f.State.XY)
+		}
+	}
+	want := map[string][]hue.XY{"1": {hue.AlertRed, hue.AlertBlue}, "2": {hue.AlertBlue, hue.AlertRed}}
+	for id, w := range want {
+		if len(colors[id]) != 2 || colors[id][0] != w[0] || colors[id][1] != w[1] {
+			t.Fatalf("light %s: expected %v, got %v", id, w, colors[id])
+		}
+	}
+}
+
+func TestNotifyCancelled(t *testing.T) {
+	sim := huetest.NewSimulator(time.Date(2017, 1, 1, 20, 0, 0, 0, time.UTC), "a")
+	defer sim.Close()
+	b := sim.Bridge()
+	g, err := b.Groups().GetByID(sim.AddGroup("Hall", "1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := g.Notify(ctx, hue.SlowRedPulse(1)); err != context.Canceled {
+		t.Fatalf("expected cancellation, got %v", err)
+	}
+	l, err := b.Lights().GetByID("1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.State.On || l.State.ColorMode != "ct" {
+		t.Fatalf("expected light to be restored, got %+v", l.State)
+	}
+}
+
+func TestNotifyQuietHours(t *testing.T) {
+	sim := huetest.NewSimulator(time.Date(2017, 1, 1, 20, 0, 0, 0, time.UTC), "a")
+	defer sim.Close()
+	b := sim.Bridge()
+	g, err := b.Groups().GetByID(sim.AddGroup("Nursery", "1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.SetQuietHours(g.ID, hue.QuietHours{From: 19 * time.Hour, To: 7 * time.Hour})
+	if err := g.Notify(context.Background(), hue.DoorbellFlash()); err != hue.ErrQuietHours {
+		t.Fatalf("expected ErrQuietHours, got %v", err)
+	}
+	l, err := b.Lights().GetByID("1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.State.On {
+		t.Fatalf("expected light to be left alone, got %+v", l.State)
+	}
+}
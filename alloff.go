@@ -0,0 +1,67 @@
+package hue
+
+// AllOff turns all lights off using group 0, which takes a single command
+// regardless of the number of lights, except for the lights of the given
+// groups: these are given back the state which they were in before. This
+// allows for example turning everything off at night but the hallway
+// nightlight. The excluded lights briefly change while this is done. If
+// restoring some of them fails, a *LightsError identifying them is returned.
+func (b *Bridge) AllOff(except ...*Group) error {
+	return b.allExcept((*Group).Off, except)
+}
+
+// AllOn turns all lights on using group 0, except for the lights of the given
+// groups, in the same way as AllOff.
+func (b *Bridge) AllOn(except ...*Group) error {
+	return b.allExcept((*Group).On, except)
+}
+
+// allExcept calls fn with group 0 and restores the state of the lights of the
+// groups in except afterwards.
+func (b *Bridge) allExcept(fn func(*Group) error, except []*Group) error {
+	lights, err := b.Lights().idMap()
+	if err != nil {
+		return err
+	}
+	saved := make(map[string]*Light)
+	for _, g := range except {
+		for _, id := range g.Lights {
+			if l, ok := lights[id]; ok {
+				saved[id] = l
+			}
+		}
+	}
+	if err := fn(b.Groups().All()); err != nil {
+		return err
+	}
+	errs := make(map[string]error)
+	for id, l := range saved {
+		if err := l.restore(l.State); err != nil {
+			errs[id] = err
+		}
+	}
+	if len(errs) > 0 {
+		return &LightsError{Errors: errs}
+	}
+	return nil
+}
+
+// restore sets the light to state ls, using its color mode to pick the color
+// attributes to send. Since ls is a state which the light was in, it is not
+// adjusted by the color compensation of the bridge.
+func (l *Light) restore(ls LightState) error {
+	if !ls.On {
+		return l.Off()
+	}
+	s := &State{On: true, Brightness: ls.Brightness}
+	switch ls.ColorMode {
+	case "xy":
+		xy := ls.XY
+		s.XY = &xy
+	case "ct":
+		s.Ct = ls.ColorTemp
+	case "hs":
+		s.Hue, s.Saturation = ls.Hue, ls.Saturation
+	}
+	return l.setState(statePayload(s, l.defaultTransition()))
+}
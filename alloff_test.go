@@ -0,0 +1,43 @@
+package hue_test
+
+import (
+	"testing"
+	"time"
+
+	"gbbr.io/hue"
+	"gbbr.io/hue/huetest"
+)
+
+func TestAllOff(t *testing.T) {
+	sim := huetest.NewSimulator(time.Date(2017, 1, 1, 22, 0, 0, 0, time.UTC), "Hall", "Desk", "Bed")
+	defer sim.Close()
+	b := sim.Bridge()
+	if err := b.Groups().All().Set(&hue.State{On: true, Brightness: 200}); err != nil {
+		t.Fatal(err)
+	}
+	hall, err := b.Lights().GetByID("1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := hall.Set(&hue.State{On: true, Brightness: 20, Ct: 450}); err != nil {
+		t.Fatal(err)
+	}
+	hallway, err := b.Groups().GetByID(sim.AddGroup("Hallway", "1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.AllOff(hallway); err != nil {
+		t.Fatal(err)
+	}
+	lights, err := hue.LightsByID(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lights["2"].State.On || lights["3"].State.On {
+		t.Fatal("expected lights 2 and 3 to be off")
+	}
+	st := lights["1"].State
+	if !st.On || st.Brightness != 20 || st.ColorTemp != 450 || st.ColorMode != "ct" {
+		t.Fatalf("expected hallway light to be restored, got %+v", st)
+	}
+}
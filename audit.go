@@ -0,0 +1,122 @@
+package hue
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SourceBridge is the source of audit entries which record changes made by
+// anything other than this application, such as switches, sensors, schedules
+// or other applications.
+const SourceBridge = "bridge"
+
+// AuditEntry is an entry of the audit log set up using WithAuditLog. It
+// records either a command sent by the application or a change of the state of
+// a light observed when retrieving it.
+type AuditEntry struct {
+	// Time is the time of the command, or at which the change was observed.
+	Time time.Time `json:"time"`
+
+	// Source is the name of the application for commands, as set using
+	// WithAppName, or SourceBridge for observed changes.
+	Source string `json:"source"`
+
+	// Method is the HTTP method of a command.
+	Method string `json:"method,omitempty"`
+
+	// Resource is the path of the resource, relative to
+	// '<base>/api/<username>' (e.g. "lights/3/state").
+	Resource string `json:"resource"`
+
+	// Body is the body of a command, if any.
+	Body json.RawMessage `json:"body,omitempty"`
+
+	// Error is the error of a failed command.
+	Error string `json:"error,omitempty"`
+
+	// State is the state of a light after an observed change.
+	State *LightState `json:"state,omitempty"`
+}
+
+// auditLog appends commands and observed changes to a file, as JSON lines.
+type auditLog struct {
+	path string
+
+	mu sync.Mutex
+	// states holds the last observed state of each light, by ID.
+	states map[string]LightState
+}
+
+// command records a command sent by bridge b to the resource at tokens,
+// along with its error, if any. Requests which do not change anything are not
+// recorded. The lights which a successful command may have changed are
+// forgotten, so that the change is not recorded again once observed.
+func (a *auditLog) command(b Bridge, method string, body interface{}, tokens []string, err error) {
+	if method == http.MethodGet {
+		return
+	}
+	e := AuditEntry{
+		Time:     b.now(),
+		Source:   b.app,
+		Method:   method,
+		Resource: strings.Join(tokens, "/"),
+	}
+	if e.Source == "" {
+		e.Source = defaultApp
+	}
+	if body != nil {
+		e.Body, _ = json.Marshal(body)
+	}
+	if err != nil {
+		e.Error = err.Error()
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if err == nil && len(tokens) > 1 {
+		switch tokens[0] {
+		case "lights":
+			delete(a.states, tokens[1])
+		case "groups", "scenes":
+			a.states = make(map[string]LightState)
+		}
+	}
+	a.write(e)
+}
+
+// observe records the state of light l, retrieved from the bridge at time t,
+// if it changed since it was last observed. The first observation of a light
+// is not recorded, since what changed is unknown.
+func (a *auditLog) observe(l *Light, t time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	prev, ok := a.states[l.ID]
+	a.states[l.ID] = l.State
+	if !ok || prev == l.State {
+		return
+	}
+	st := l.State
+	a.write(AuditEntry{Time: t, Source: SourceBridge, Resource: "lights/" + l.ID, State: &st})
+}
+
+// write appends e to the log. a.mu must be held.
+func (a *auditLog) write(e AuditEntry) {
+	data, err := json.Marshal(e)
+	if err != nil {
+		log.Printf("could not write audit log: %v", err)
+		return
+	}
+	f, err := os.OpenFile(a.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0666)
+	if err != nil {
+		log.Printf("could not write audit log: %v", err)
+		return
+	}
+	defer f.Close()
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		log.Printf("could not write audit log: %v", err)
+	}
+}
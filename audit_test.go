@@ -0,0 +1,73 @@
+package hue_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gbbr.io/hue"
+	"gbbr.io/hue/huetest"
+)
+
+func TestAuditLog(t *testing.T) {
+	sim := huetest.NewSimulator(time.Date(2017, 1, 1, 3, 0, 0, 0, time.UTC), "a", "b")
+	defer sim.Close()
+	dir, err := ioutil.TempDir("", "hue")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "audit.jsonl")
+	b := sim.Bridge(hue.WithAuditLog(path))
+	other := sim.Bridge()
+
+	if _, err := b.Lights().List(); err != nil {
+		t.Fatal(err)
+	}
+	l, err := b.Lights().GetByID("1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := l.On(); err != nil {
+		t.Fatal(err)
+	}
+	// changed by another application
+	l2, err := other.Lights().GetByID("2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := l2.On(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.Lights().List(); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var entries []hue.AuditEntry
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var e hue.AuditEntry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, e)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %+v", entries)
+	}
+	if e := entries[0]; e.Source != hue.DefaultApp || e.Method != "PUT" || e.Resource != "lights/1/state" || string(e.Body) != `{"on":true}` {
+		t.Fatalf("unexpected command entry %+v", e)
+	}
+	if e := entries[1]; e.Source != hue.SourceBridge || e.Resource != "lights/2" || e.State == nil || !e.State.On {
+		t.Fatalf("unexpected change entry %+v", e)
+	}
+}
@@ -0,0 +1,81 @@
+package hue
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// GroupPlan is a group proposed by PlanGroups.
+type GroupPlan struct {
+	// Name is the name of the group: the prefix shared by the names of its
+	// lights.
+	Name string
+
+	// Lights holds the IDs of the lights of the group, sorted.
+	Lights []string
+}
+
+// namePrefix returns the name of a light without its trailing number, as in
+// "Kitchen 2" or "Hall-3", or "" if it has none.
+func namePrefix(name string) string {
+	trimmed := strings.TrimRightFunc(name, unicode.IsDigit)
+	if trimmed == name {
+		return ""
+	}
+	return strings.TrimRightFunc(trimmed, func(r rune) bool {
+		return unicode.IsSpace(r) || r == '-' || r == '_' || r == '#'
+	})
+}
+
+// PlanGroups proposes groups for the lights whose names share a prefix
+// followed by a number, such as "Kitchen 1" to "Kitchen 4", which results in
+// a group named "Kitchen". Prefixes shared by fewer than two lights are
+// ignored, as are those which are already the name of a group. The plan is
+// sorted by name and makes no changes; pass it to CreatePlanned to create the
+// groups, possibly after editing it.
+func (g *GroupsService) PlanGroups() ([]GroupPlan, error) {
+	lights, err := g.bridge.Lights().idMap()
+	if err != nil {
+		return nil, err
+	}
+	groups, err := g.idMap()
+	if err != nil {
+		return nil, err
+	}
+	exists := make(map[string]bool, len(groups))
+	for _, gg := range groups {
+		exists[gg.Name] = true
+	}
+	byPrefix := make(map[string][]string)
+	for id, l := range lights {
+		if p := namePrefix(l.Name); p != "" && !exists[p] {
+			byPrefix[p] = append(byPrefix[p], id)
+		}
+	}
+	var plan []GroupPlan
+	for p, ids := range byPrefix {
+		if len(ids) < 2 {
+			continue
+		}
+		sort.Slice(ids, func(i, j int) bool { return lessID(ids[i], ids[j]) })
+		plan = append(plan, GroupPlan{Name: p, Lights: ids})
+	}
+	sort.Slice(plan, func(i, j int) bool { return plan[i].Name < plan[j].Name })
+	return plan, nil
+}
+
+// CreatePlanned creates the groups of the plan, with the given type, in
+// order. It stops at the first failure, returning the groups created so far
+// along with the error.
+func (g *GroupsService) CreatePlanned(plan []GroupPlan, groupType string) ([]*Group, error) {
+	var created []*Group
+	for _, p := range plan {
+		grp, err := g.Create(p.Name, p.Lights, groupType)
+		if err != nil {
+			return created, err
+		}
+		created = append(created, grp)
+	}
+	return created, nil
+}
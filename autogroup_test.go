@@ -0,0 +1,66 @@
+package hue
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestNamePrefix(t *testing.T) {
+	for name, want := range map[string]string{
+		"Kitchen 1":  "Kitchen",
+		"Kitchen 12": "Kitchen",
+		"Hall-3":     "Hall",
+		"Spot #2":    "Spot",
+		"Lamp":       "",
+		"12":         "",
+	} {
+		if got := namePrefix(name); got != want {
+			t.Fatalf("%q: expected %q, got %q", name, want, got)
+		}
+	}
+}
+
+func TestPlanGroups(t *testing.T) {
+	mb := mockBridge(t)
+	defer mb.teardown()
+	mb.responses = map[string]interface{}{
+		"/api/bridge_username/lights": map[string]*Light{
+			"1":  &Light{Name: "Kitchen 1"},
+			"2":  &Light{Name: "Kitchen 2"},
+			"10": &Light{Name: "Kitchen 3"},
+			"4":  &Light{Name: "Hall 1"},
+			"5":  &Light{Name: "Hall 2"},
+			"6":  &Light{Name: "Desk 1"},
+			"7":  &Light{Name: "Bedroom 1"},
+			"8":  &Light{Name: "Bedroom 2"},
+			"9":  &Light{Name: "Couch"},
+		},
+	}
+	mb.nextResponse = map[string]*Group{"1": &Group{Name: "Hall"}}
+	plan, err := mb.b.Groups().PlanGroups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []GroupPlan{
+		{Name: "Bedroom", Lights: []string{"7", "8"}},
+		{Name: "Kitchen", Lights: []string{"1", "2", "10"}},
+	}
+	if !reflect.DeepEqual(plan, want) {
+		t.Fatalf("expected %v, got %v", want, plan)
+	}
+
+	mb.nextResponse = []interface{}{
+		map[string]interface{}{"success": map[string]string{"id": "3"}},
+	}
+	groups, err := mb.b.Groups().CreatePlanned(plan[1:], TypeRoom)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(groups) != 1 || groups[0].Name != "Kitchen" || groups[0].Type != TypeRoom {
+		t.Fatalf("unexpected groups %+v", groups)
+	}
+	if mb.lastMethod != http.MethodPost || mb.lastPath != "/api/bridge_username/groups" {
+		t.Fatalf("unexpected request %s %s", mb.lastMethod, mb.lastPath)
+	}
+}
@@ -2,12 +2,17 @@ package hue
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io/ioutil"
 	"net/http"
+	"net/url"
 	"os"
 	"runtime"
+	"strings"
+	"sync"
+	"time"
 )
 
 // http://www.developers.meethue.com/documentation/configuration-api#71_create_user
@@ -19,39 +24,247 @@ const (
 type Bridge struct {
 	bridgeID
 	username string
+
+	// cachePath is the path of the file where pairing data is cached. If
+	// empty, caching is disabled.
+	cachePath string
+
+	// strict, when true, causes responses which do not match the expected
+	// format to result in errors.
+	strict bool
+
+	// warnings collects problems encountered while decoding responses.
+	warnings *warnings
+
+	// verifyID, when true, causes the ID reported by the bridge to be checked
+	// before pairing.
+	verifyID bool
+
+	// observe, if non-nil, is called after every call to the API.
+	observe func(CallInfo)
+
+	// transition is the default transition time of state changes, if any.
+	transition *uint16
+
+	// transitions holds the default transition times of lights and groups.
+	transitions *transitions
+
+	// groupPrefs holds the quiet hours and time slots of groups.
+	groupPrefs *groupPrefs
+
+	// audit, if non-nil, records commands and observed changes.
+	audit *auditLog
+
+	// calibrate, if non-nil, adjusts the colors sent to lights by model.
+	calibrate Calibration
+
+	// readOnly, when true, causes all calls other than GET requests to fail
+	// with ErrReadOnly.
+	readOnly bool
+
+	// repair, if non-nil, is used to pair again when the credentials are
+	// rejected.
+	repair *repairFlow
+
+	// profile, if non-nil, holds the detected model of the bridge, which is
+	// used to reject calls to features that it does not support.
+	profile *profile
+
+	// reach, if non-nil, tracks the reachability of lights.
+	reach *reachability
+
+	// energy, if non-nil, tracks the estimated power draw of lights.
+	energy *energyTracker
+
+	// failover, if non-nil, routes reads to a secondary gateway while the
+	// bridge is unreachable.
+	failover *failover
+
+	// dangerous, when true, allows maintenance operations such as Reboot.
+	dangerous bool
+
+	// clock is the source of time of time-based features. If nil, the
+	// system clock is used.
+	clock Clock
+
+	// truncateNames, when true, causes names which are too long to be
+	// truncated instead of rejected.
+	truncateNames bool
+
+	// journal, if non-nil, records commands while the bridge is unreachable.
+	journal *journal
+
+	// limits holds the safeguards applied to responses.
+	limits limits
+
+	// app is the name of the application used when pairing, which also keys
+	// the cached credentials. If empty, defaultApp is used.
+	app string
+
+	// swr, if non-nil, keeps the responses of listing calls.
+	swr *swrCache
+
+	// detectStreaming, when true, causes commands to lights used by an
+	// entertainment stream to fail with ErrStreamingActive.
+	detectStreaming bool
+}
+
+// repairFlow pairs with a bridge again, after asking the user to press its link
+// button.
+type repairFlow struct {
+	// mu guards the username of the bridge, which pairing replaces.
+	mu     sync.Mutex
+	prompt func() error
+}
+
+// snapshot returns a copy of bridge b, taken while it is not being paired.
+func (r *repairFlow) snapshot(b *Bridge) Bridge {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return *b
+}
+
+// run pairs with bridge b again, unless this was done since a call using the
+// rejected username failed.
+func (r *repairFlow) run(b *Bridge, rejected string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if b.username != rejected {
+		// paired by a concurrent call
+		return nil
+	}
+	if err := r.prompt(); err != nil {
+		return err
+	}
+	return b.Pair()
+}
+
+// groupPrefs holds the preferences which are set per group on the client
+// side, rather than stored on the bridge.
+type groupPrefs struct {
+	mu    sync.Mutex
+	quiet map[string][]QuietHours
+	slots map[string][]TimeSlot
+}
+
+// newGroupPrefs returns empty group preferences.
+func newGroupPrefs() *groupPrefs {
+	return &groupPrefs{
+		quiet: make(map[string][]QuietHours),
+		slots: make(map[string][]TimeSlot),
+	}
+}
+
+// defaultApp is the name of the application used when pairing, unless
+// another is set using PairAs or WithAppName.
+const defaultApp = "gbbr/hue"
+
+// New returns the bridge at the given address (e.g. "192.168.1.2:80"), using
+// the given username, without discovery. The cache is neither read nor
+// written, so the ID and name of the bridge are not known. It is meant for
+// bridges at fixed addresses and for simulated bridges.
+func New(addr, username string, opts ...Option) *Bridge {
+	return newOptions(opts).apply(&Bridge{
+		bridgeID: bridgeID{IP: fmt.Sprintf("http://%s/", addr)},
+		username: username,
+	})
 }
 
 // Pair attempts to pair with the bridge. The link button on the bridge must be
 // pressed before calling this method.
-func (b *Bridge) Pair() error { return b.pairAs("gbbr/hue") }
+func (b *Bridge) Pair() error {
+	if b.app == "" {
+		return b.pairAs(defaultApp)
+	}
+	return b.pairAs(b.app)
+}
 
 // PairAs has the same outcome as Pair, except it allows setting how the program
-// identifies itself.
-func (b *Bridge) PairAs(appName string) error { return b.pairAs(appName) }
+// identifies itself. The credentials are cached for the given application, so
+// that programs using different names each keep their own.
+func (b *Bridge) PairAs(appName string) error {
+	if appName == defaultApp {
+		appName = ""
+	}
+	b.app = appName
+	return b.Pair()
+}
 
 // IsPaired will return true if the program has already paired with this bridge.
 func (b *Bridge) IsPaired() bool { return b.username != "" }
 
-// addr constructs the URL of the API using the passed tokens. Some examples:
+// addr constructs the URL of the API using the passed tokens, which are
+// escaped. Some examples:
 //
 // 	addr()              => '<base>/api'
 // 	addr("lights")      => '<base>/api/<username>/lights'
 // 	addr("lights", "1") => '<base>/api/<username>/lights/1'
 //
 func (b Bridge) addr(tokens ...string) string {
-	buf := bytes.NewBufferString(fmt.Sprintf("%sapi", b.IP))
+	var sb strings.Builder
 	if len(tokens) == 0 {
-		return buf.String()
+		sb.Grow(len(b.IP) + 3)
+		sb.WriteString(b.IP)
+		sb.WriteString("api")
+		return sb.String()
+	}
+	user := url.PathEscape(b.username)
+	escaped := make([]string, len(tokens))
+	n := len(b.IP) + 4 + len(user)
+	for i, t := range tokens {
+		escaped[i] = url.PathEscape(t)
+		n += 1 + len(escaped[i])
 	}
-	buf.WriteString("/")
-	buf.WriteString(b.username)
+	sb.Grow(n)
+	sb.WriteString(b.IP)
+	sb.WriteString("api/")
+	sb.WriteString(user)
+	for _, t := range escaped {
+		sb.WriteByte('/')
+		sb.WriteString(t)
+	}
+	return sb.String()
+}
+
+// path returns the path of the API at the resource specified by tokens, as it
+// is used in commands. For example:
+//
+// 	path("groups", "1", "action") => '/api/<username>/groups/1/action'
+//
+func (b Bridge) path(tokens ...string) string {
+	escaped := make([]string, len(tokens))
+	for i, t := range tokens {
+		escaped[i] = url.PathEscape(t)
+	}
+	return "/api/" + url.PathEscape(b.username) + "/" + strings.Join(escaped, "/")
+}
+
+// InvalidTokenError is returned when an ID or other element of a resource path
+// can not be used in an API call, because it is empty or would change the
+// resource being addressed.
+type InvalidTokenError struct {
+	// Token is the invalid path element.
+	Token string
+}
+
+func (e *InvalidTokenError) Error() string {
+	return fmt.Sprintf("invalid resource path element %q", e.Token)
+}
+
+// checkTokens returns an *InvalidTokenError if any of the tokens is invalid.
+func checkTokens(tokens []string) error {
 	for _, t := range tokens {
-		buf.WriteString("/")
-		buf.WriteString(t)
+		if t == "" || t == "." || t == ".." {
+			return &InvalidTokenError{Token: t}
+		}
 	}
-	return buf.String()
+	return nil
 }
 
+// ErrReadOnly is returned by methods which would change the bridge, when it
+// was obtained using the ReadOnly option.
+var ErrReadOnly = errors.New("bridge is read-only")
+
 // APIError holds detailed information about a failed API call.
 // For more information see: http://www.developers.meethue.com/documentation/error-messages
 type APIError struct {
@@ -62,30 +275,170 @@ type APIError struct {
 
 func (e APIError) Error() string { return e.Msg }
 
+// errResourceNotAvailable is the APIError code returned by the bridge when the
+// requested resource does not exist.
+const errResourceNotAvailable = 3
+
+// CallInfo describes a completed call to the bridge API. It is passed to the
+// function given to WithObserver.
+type CallInfo struct {
+	// Method is the HTTP method of the call.
+	Method string
+
+	// Resource is the path of the resource, relative to '<base>/api/<username>'
+	// (e.g. "lights/1/state"). It is empty when pairing.
+	Resource string
+
+	// Duration is the time it took for the call to complete.
+	Duration time.Duration
+
+	// Err is the error returned by the call, if any. Errors reported by the
+	// bridge are of type APIError.
+	Err error
+}
+
+// ErrUnauthorized is returned when the bridge rejects the credentials used to
+// access it, for example because they were removed from its whitelist.
+var ErrUnauthorized = errors.New("unauthorized user")
+
+// errUnauthorized is the APIError code returned by the bridge when the
+// username is not valid.
+const errUnauthorized = 1
+
 // call calls the API at the URL specified by tokens using the given method and
-// request body. If no request body is desired, body should be nil.
-func (b Bridge) call(method string, body interface{}, tokens ...string) ([]byte, error) {
-	bd := []byte{}
+// request body. If no request body is desired, body should be nil. Tokens are
+// escaped; empty tokens, "." and ".." result in an *InvalidTokenError. If the
+// credentials are rejected and a re-pair flow is set, the bridge is paired
+// with again and the call retried. Calls to resources which the bridge does
+// not implement result in an *UnsupportedError. With the DetectStreaming
+// option, commands to lights used by an entertainment stream result in
+// ErrStreamingActive.
+func (b *Bridge) call(method string, body interface{}, tokens ...string) ([]byte, error) {
+	if f := resourceFeature(tokens); f != "" {
+		if err := b.supports(f); err != nil {
+			return nil, err
+		}
+	}
+	if b.detectStreaming {
+		if err := b.checkStreaming(method, tokens); err != nil {
+			return nil, err
+		}
+	}
+	if b.repair == nil || len(tokens) == 0 {
+		// pairing itself is not retried, and is done while holding the lock
+		return b.callOnce(method, body, tokens...)
+	}
+	c := b.repair.snapshot(b)
+	msg, err := c.callOnce(method, body, tokens...)
+	if err != ErrUnauthorized {
+		return msg, err
+	}
+	if err := b.repair.run(b, c.username); err != nil {
+		return nil, err
+	}
+	c = b.repair.snapshot(b)
+	return c.callOnce(method, body, tokens...)
+}
+
+// callOnce is like call, without retrying.
+func (b Bridge) callOnce(method string, body interface{}, tokens ...string) ([]byte, error) {
+	if b.readOnly && method != http.MethodGet {
+		return nil, ErrReadOnly
+	}
+	if err := checkTokens(tokens); err != nil {
+		return nil, err
+	}
+	if b.observe == nil && b.audit == nil {
+		return b.roundTrip(method, body, tokens...)
+	}
+	start := time.Now()
+	msg, err := b.roundTrip(method, body, tokens...)
+	if b.audit != nil {
+		b.audit.command(b, method, body, tokens, err)
+	}
+	if b.observe == nil {
+		return msg, err
+	}
+	b.observe(CallInfo{
+		Method:   method,
+		Resource: strings.Join(tokens, "/"),
+		Duration: time.Since(start),
+		Err:      err,
+	})
+	return msg, err
+}
+
+// bufPool holds the buffers used to encode request bodies. Reusing them
+// reduces garbage when many commands are sent, such as in animations.
+var bufPool = sync.Pool{New: func() interface{} { return new(bytes.Buffer) }}
+
+// errorKey is present in every response which reports an error. Responses
+// without it need not be searched for errors.
+var errorKey = []byte(`"error"`)
+
+// roundTrip sends the request described by call and returns the response.
+// If a secondary gateway is set, reads are routed to it while the bridge is
+// unreachable. If a journal is set, commands are recorded in it while the
+// bridge is unreachable.
+func (b Bridge) roundTrip(method string, body interface{}, tokens ...string) ([]byte, error) {
+	if b.failover != nil && method == http.MethodGet {
+		return b.failover.read(b, tokens)
+	}
+	msg, err := b.request(method, body, tokens...)
+	if b.journal != nil {
+		return b.journal.after(b, method, body, tokens, msg, err)
+	}
+	return msg, err
+}
+
+// request sends the request described by call to the bridge and returns the
+// response. Concurrent GET requests for the same URL are coalesced into one,
+// except that GET requests made after a command never join one in progress
+// since before it.
+func (b Bridge) request(method string, body interface{}, tokens ...string) ([]byte, error) {
+	url := b.addr(tokens...)
+	if method == http.MethodGet && body == nil {
+		return gets.do(url, func() ([]byte, error) {
+			return send(method, url, nil, b.limits)
+		})
+	}
+	gets.forget(b.IP)
+	return send(method, url, body, b.limits)
+}
+
+// send sends a request with the given method and body to url and returns the
+// response, applying the given limits.
+func send(method, url string, body interface{}, lim limits) ([]byte, error) {
+	buf := bufPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	defer bufPool.Put(buf)
 	if body != nil {
-		var err error
-		bd, err = json.Marshal(body)
-		if err != nil {
+		if err := json.NewEncoder(buf).Encode(body); err != nil {
 			return nil, err
 		}
+		buf.Truncate(buf.Len() - 1) // trailing newline
 	}
-	req, err := http.NewRequest(method, b.addr(tokens...), bytes.NewReader(bd))
+	req, err := http.NewRequest(method, url, bytes.NewReader(buf.Bytes()))
 	if err != nil {
 		return nil, err
 	}
-	resp, err := http.DefaultClient.Do(req)
+	ctx, cancel := lim.context()
+	defer cancel()
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
 	if err != nil {
-		return nil, err
+		return nil, lim.check(ctx, url, err)
 	}
 	defer resp.Body.Close()
-	slurp, err := ioutil.ReadAll(resp.Body)
+	if err := busy(url, resp, time.Now()); err != nil {
+		return nil, err
+	}
+	slurp, err := lim.read(ctx, url, resp.Body)
 	if err != nil {
 		return nil, err
 	}
+	if !bytes.Contains(slurp, errorKey) && json.Valid(slurp) {
+		return slurp, nil
+	}
 	var errors []struct {
 		Err APIError `json:"error"`
 	}
@@ -95,13 +448,61 @@ func (b Bridge) call(method string, body interface{}, tokens ...string) ([]byte,
 		}
 	}
 	for _, e := range errors {
-		if e.Err.Code != 0 {
-			return nil, e.Err
+		switch e.Err.Code {
+		case 0:
+			continue
+		case errUnauthorized:
+			return nil, ErrUnauthorized
 		}
+		return nil, e.Err
 	}
 	return slurp, nil
 }
 
+// Do calls the API at the given path, relative to '<base>/api/<username>',
+// using the given method and JSON body, which may be nil. It returns the
+// response of the bridge. Do is intended for accessing parts of the API that
+// are not (yet) provided by this package. An empty path, such as "/",
+// addresses the full datastore at '<base>/api/<username>'.
+func (b *Bridge) Do(method, path string, body []byte) ([]byte, error) {
+	var tokens []string
+	for _, t := range strings.Split(path, "/") {
+		if t != "" {
+			tokens = append(tokens, t)
+		}
+	}
+	var payload interface{}
+	if len(body) > 0 {
+		payload = json.RawMessage(body)
+	}
+	if len(tokens) == 0 {
+		// call uses the root of the API for pairing, which is not what is
+		// asked for here
+		if b.readOnly && method != http.MethodGet {
+			return nil, ErrReadOnly
+		}
+		return send(method, b.addr()+"/"+url.PathEscape(b.username), payload, b.limits)
+	}
+	return b.call(method, payload, tokens...)
+}
+
+// createdID returns the ID of the resource created by a successful POST request,
+// given the response message.
+func createdID(msg []byte) (string, error) {
+	var resp []struct {
+		Success struct {
+			ID string `json:"id"`
+		} `json:"success"`
+	}
+	if err := json.Unmarshal(msg, &resp); err != nil {
+		return "", err
+	}
+	if len(resp) == 0 || resp[0].Success.ID == "" {
+		return "", fmt.Errorf("bad response: %s", msg)
+	}
+	return resp[0].Success.ID, nil
+}
+
 func (b *Bridge) pairAs(appName string) error {
 	host, err := os.Hostname()
 	if err != nil {
@@ -117,6 +518,12 @@ func (b *Bridge) pairAs(appName string) error {
 		deviceName = deviceName[:maxDeviceNameLength]
 	}
 
+	if b.verifyID {
+		if err := verify(context.Background(), &b.bridgeID); err != nil {
+			return err
+		}
+	}
+
 	msg, err := b.call(http.MethodPost, map[string]interface{}{
 		"devicetype": fmt.Sprintf("%s#%s", appName, deviceName),
 	})
@@ -2,9 +2,14 @@ package hue
 
 import (
 	"encoding/json"
+	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
 	"reflect"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 // addrTestsuite is a suite of tests for the internal addr function.
@@ -24,6 +29,10 @@ var addrTestsuite = map[string]struct {
 		In:  []string{"a", "b", "c"},
 		Out: "http://1.2.3.4/api/user/a/b/c",
 	},
+	"escaped-tokens": {
+		In:  []string{"a b", "c/d"},
+		Out: "http://1.2.3.4/api/user/a%20b/c%2Fd",
+	},
 }
 
 func TestAddr(t *testing.T) {
@@ -61,6 +70,11 @@ var callTestsuite = map[string]struct {
 		Response: []byte(`not json`),
 		Error:    &json.SyntaxError{Offset: 2},
 	},
+	// should return ErrUnauthorized for error 1
+	"unauthorized": {
+		Response: []byte(`[{"error": {"type":1,"address":"/lights","description":"unauthorized user"}}]`),
+		Error:    ErrUnauthorized,
+	},
 	// should return parsed error
 	"failure": {
 		Response: []byte(`[{"error": {"type":101,"address":"a/b/c","description":"blah"}}]`),
@@ -73,13 +87,16 @@ func TestCall(t *testing.T) {
 		t.Run(name, func(t *testing.T) {
 			srv := serverWithResponse(string(tt.Response))
 			defer srv.Close()
-			msg, err := (Bridge{
+			msg, err := (&Bridge{
 				bridgeID: bridgeID{IP: srv.URL + "/"},
 			}).call(http.MethodGet, "some body")
 			if tt.Error != nil {
 				if err == nil {
 					t.Fatalf("expected error")
 				}
+				if tt.Error == ErrUnauthorized && err != ErrUnauthorized {
+					t.Fatalf("expected ErrUnauthorized, got %v", err)
+				}
 				if _, ok := tt.Error.(APIError); ok {
 					if !reflect.DeepEqual(tt.Error, err) {
 						t.Fatalf("expected error %v, got %v", tt.Error, err)
@@ -97,3 +114,220 @@ func TestCall(t *testing.T) {
 		})
 	}
 }
+
+func TestDo(t *testing.T) {
+	mb := mockBridge(t)
+	defer mb.teardown()
+	mb.nextResponse = map[string]string{"name": "l1"}
+	msg, err := mb.b.Do(http.MethodPut, "/lights/1/", []byte(`{"name":"l1"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mb.lastMethod != http.MethodPut || mb.lastPath != "/api/bridge_username/lights/1" {
+		t.Fatalf("unexpected request %s %s", mb.lastMethod, mb.lastPath)
+	}
+	body, err := ioutil.ReadAll(mb.lastBody)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != `{"name":"l1"}` {
+		t.Fatalf("unexpected body %s", body)
+	}
+	if string(msg) != `{"name":"l1"}`+"\n" {
+		t.Fatalf("unexpected response %s", msg)
+	}
+	for _, path := range []string{"", "/"} {
+		if _, err := mb.b.Do(http.MethodGet, path, nil); err != nil {
+			t.Fatal(err)
+		}
+		if mb.lastMethod != http.MethodGet || mb.lastPath != "/api/bridge_username" {
+			t.Fatalf("%q: unexpected request %s %s", path, mb.lastMethod, mb.lastPath)
+		}
+	}
+}
+
+func TestPairVerifiesID(t *testing.T) {
+	var paired bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			paired = true
+		}
+		w.Write([]byte(`{"bridgeid": "001788FFFE000000"}`))
+	}))
+	defer srv.Close()
+	b := &Bridge{bridgeID: bridgeID{ID: "001788fffea1b2c3", IP: srv.URL + "/"}, verifyID: true}
+	if err := b.Pair(); err == nil {
+		t.Fatal("expected error")
+	}
+	if paired {
+		t.Fatal("expected no pairing request")
+	}
+}
+
+func TestObserver(t *testing.T) {
+	srv := serverWithResponse(`[{"error": {"type":901,"address":"lights/1/state","description":"busy"}}]`)
+	defer srv.Close()
+	var got []CallInfo
+	b := Bridge{
+		bridgeID: bridgeID{IP: srv.URL + "/"},
+		observe:  func(ci CallInfo) { got = append(got, ci) },
+	}
+	b.call(http.MethodPut, nil, "lights", "1", "state")
+	if len(got) != 1 {
+		t.Fatalf("expected 1 call, got %d", len(got))
+	}
+	if got[0].Method != http.MethodPut || got[0].Resource != "lights/1/state" {
+		t.Fatalf("unexpected call %+v", got[0])
+	}
+	if e, ok := got[0].Err.(APIError); !ok || e.Code != 901 {
+		t.Fatalf("unexpected error %v", got[0].Err)
+	}
+}
+
+func BenchmarkAddr(b *testing.B) {
+	br := Bridge{bridgeID: bridgeID{IP: "http://1.2.3.4/"}, username: "user"}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		br.addr("lights", "1", "state")
+	}
+}
+
+func TestCallInvalidToken(t *testing.T) {
+	b := Bridge{bridgeID: bridgeID{IP: "http://1.2.3.4/"}, username: "user"}
+	for _, tok := range []string{"", ".", ".."} {
+		_, err := b.call(http.MethodGet, nil, "lights", tok)
+		if e, ok := err.(*InvalidTokenError); !ok || e.Token != tok {
+			t.Fatalf("%q: expected *InvalidTokenError, got %v", tok, err)
+		}
+	}
+}
+
+func TestReadOnly(t *testing.T) {
+	mb := mockBridge(t)
+	defer mb.teardown()
+	mb.b.readOnly = true
+	mb.nextResponse = testLights
+	list, err := mb.b.Lights().List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mb.lastMethod = ""
+	if err := list[0].On(); err != ErrReadOnly {
+		t.Fatalf("expected ErrReadOnly, got %v", err)
+	}
+	if _, err := mb.b.Do(http.MethodDelete, "lights/1", nil); err != ErrReadOnly {
+		t.Fatalf("expected ErrReadOnly, got %v", err)
+	}
+	if mb.lastMethod != "" {
+		t.Fatalf("unexpected request %s %s", mb.lastMethod, mb.lastPath)
+	}
+}
+
+func TestRepair(t *testing.T) {
+	var paired bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/api":
+			paired = true
+			w.Write([]byte(`[{"success": {"username": "new"}}]`))
+		case r.URL.Path == "/api/new/lights":
+			w.Write([]byte(`{}`))
+		default:
+			w.Write([]byte(`[{"error": {"type": 1, "address": "/", "description": "unauthorized user"}}]`))
+		}
+	}))
+	defer srv.Close()
+	var prompted int
+	b := &Bridge{bridgeID: bridgeID{IP: srv.URL + "/"}, username: "old"}
+	if _, err := b.Lights().List(); err != ErrUnauthorized {
+		t.Fatalf("expected ErrUnauthorized, got %v", err)
+	}
+	b.repair = &repairFlow{prompt: func() error {
+		prompted++
+		return nil
+	}}
+	if _, err := b.Lights().List(); err != nil {
+		t.Fatal(err)
+	}
+	if !paired || prompted != 1 || b.username != "new" {
+		t.Fatalf("expected to pair again once, got paired=%v prompted=%d username=%q", paired, prompted, b.username)
+	}
+}
+
+func TestRepairConcurrent(t *testing.T) {
+	var mu sync.Mutex
+	var pairs int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/api":
+			mu.Lock()
+			pairs++
+			mu.Unlock()
+			w.Write([]byte(`[{"success": {"username": "new"}}]`))
+		case r.URL.Path == "/api/new/lights":
+			w.Write([]byte(`{}`))
+		default:
+			w.Write([]byte(`[{"error": {"type": 1, "address": "/", "description": "unauthorized user"}}]`))
+		}
+	}))
+	defer srv.Close()
+	var prompted int
+	b := &Bridge{bridgeID: bridgeID{IP: srv.URL + "/"}, username: "old"}
+	b.repair = &repairFlow{prompt: func() error {
+		prompted++
+		return nil
+	}}
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := b.Lights().List()
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if pairs != 1 || prompted != 1 {
+		t.Fatalf("expected to pair again once, got pairs=%d prompted=%d", pairs, prompted)
+	}
+}
+
+func TestLimits(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/u/slow":
+			<-release
+		case "/api/u/large":
+			w.Write([]byte(`{"name": "` + strings.Repeat("x", 100) + `"}`))
+		default:
+			w.Write([]byte(`{}`))
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+	var o options
+	MaxResponseSize(64)(&o)
+	WithTimeout(50 * time.Millisecond)(&o)
+	b := o.apply(&Bridge{bridgeID: bridgeID{IP: srv.URL + "/"}, username: "u"})
+	if _, err := b.call(http.MethodGet, nil, "small"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.call(http.MethodGet, nil, "large"); err == nil {
+		t.Fatal("expected error")
+	} else if e, ok := err.(*ResponseTooLargeError); !ok || e.Limit != 64 {
+		t.Fatalf("expected *ResponseTooLargeError, got %v", err)
+	}
+	if _, err := b.call(http.MethodGet, nil, "slow"); err == nil {
+		t.Fatal("expected error")
+	} else if _, ok := err.(*TimeoutError); !ok || !unreachable(err) {
+		t.Fatalf("expected *TimeoutError, got %v", err)
+	}
+}
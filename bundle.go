@@ -0,0 +1,224 @@
+package hue
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// Bundle is an automation exported from a bridge: the CLIP sensors, rules and
+// schedules grouped by a resource link, such as those created by the official
+// app for its formulas or by Bridge.Install. It is meant to be stored as JSON,
+// so that automations can be versioned and imported on other bridges. The
+// resources are keyed by their IDs on the bridge they were exported from.
+type Bundle struct {
+	// Name is the name of the resource link.
+	Name string `json:"name"`
+
+	// Description is the description of the resource link.
+	Description string `json:"description,omitempty"`
+
+	// ClassID is the class ID of the resource link, which is defined by the
+	// application that created it.
+	ClassID int `json:"classid"`
+
+	// Sensors holds the CLIP sensors of the bundle.
+	Sensors map[string]*Sensor `json:"sensors,omitempty"`
+
+	// Rules holds the rules of the bundle.
+	Rules map[string]*Rule `json:"rules,omitempty"`
+
+	// Schedules holds the schedules of the bundle.
+	Schedules map[string]*Schedule `json:"schedules,omitempty"`
+
+	// External holds the addresses of the other resources that the resource
+	// link refers to, such as physical sensors, groups and scenes. They must
+	// be mapped to resources of the bridge a bundle is imported on.
+	External []string `json:"external,omitempty"`
+}
+
+// ExportBundle returns the resources grouped by the resource link with the
+// given ID, as a bundle.
+func (b *Bridge) ExportBundle(linkID string) (*Bundle, error) {
+	msg, err := b.call(http.MethodGet, nil, "resourcelinks", linkID)
+	if err != nil {
+		return nil, err
+	}
+	var link struct {
+		Name        string   `json:"name"`
+		Description string   `json:"description"`
+		ClassID     int      `json:"classid"`
+		Links       []string `json:"links"`
+	}
+	if err := b.decode(msg, &link); err != nil {
+		return nil, err
+	}
+	bd := &Bundle{
+		Name:        link.Name,
+		Description: link.Description,
+		ClassID:     link.ClassID,
+		Sensors:     make(map[string]*Sensor),
+		Rules:       make(map[string]*Rule),
+		Schedules:   make(map[string]*Schedule),
+	}
+	var (
+		sensors   map[string]*Sensor
+		rules     map[string]*Rule
+		schedules map[string]*Schedule
+	)
+	for _, addr := range link.Links {
+		parts := strings.Split(strings.Trim(addr, "/"), "/")
+		if len(parts) != 2 {
+			bd.External = append(bd.External, addr)
+			continue
+		}
+		kind, id := parts[0], parts[1]
+		switch kind {
+		case "sensors":
+			if sensors == nil {
+				if sensors, err = b.Sensors().idMap(); err != nil {
+					return nil, err
+				}
+			}
+			if s, ok := sensors[id]; ok && strings.HasPrefix(s.Type, "CLIP") {
+				bd.Sensors[id] = s
+				continue
+			}
+		case "rules":
+			if rules == nil {
+				if rules, err = b.Rules().idMap(); err != nil {
+					return nil, err
+				}
+			}
+			if r, ok := rules[id]; ok {
+				bd.Rules[id] = r
+				continue
+			}
+		case "schedules":
+			if schedules == nil {
+				if schedules, err = b.Schedules().idMap(); err != nil {
+					return nil, err
+				}
+			}
+			if s, ok := schedules[id]; ok {
+				bd.Schedules[id] = s
+				continue
+			}
+		}
+		bd.External = append(bd.External, addr)
+	}
+	return bd, nil
+}
+
+// ImportBundle creates the resources of bundle bd on the bridge, along with a
+// resource link which groups them. The addresses of the resources that they
+// refer to are remapped: those of the bundle to the created resources, and
+// others according to ids, which maps addresses on the bridge that the bundle
+// was exported from to addresses on this one (e.g. "/sensors/5" to
+// "/sensors/12"). If import fails, the resources that were created are
+// removed. The returned installation may be removed as a unit.
+func (b *Bridge) ImportBundle(bd *Bundle, ids map[string]string) (*Installation, error) {
+	if err := b.supports(featureResourceLinks); err != nil {
+		return nil, err
+	}
+	remap := make(map[string]string, len(ids))
+	for k, v := range ids {
+		remap[k] = v
+	}
+	in := &Installation{bridge: b}
+	if err := in.importBundle(bd, remap); err != nil {
+		in.Remove()
+		return nil, err
+	}
+	links := append([]string(nil), in.Resources...)
+	for _, addr := range bd.External {
+		links = append(links, remapAddr(remap, addr, ""))
+	}
+	if err := in.link(bd.Name, bd.Description, bd.ClassID, links); err != nil {
+		in.Remove()
+		return nil, err
+	}
+	return in, nil
+}
+
+// importBundle creates the resources of bundle bd in order: sensors, which
+// rules and schedules may refer to, then schedules, then rules. remap is
+// extended with the address of each created resource.
+func (in *Installation) importBundle(bd *Bundle, remap map[string]string) error {
+	b := in.bridge
+	for _, id := range sortedIDs(bd.Sensors) {
+		s := *bd.Sensors[id]
+		if err := b.Sensors().Create(&s); err != nil {
+			return err
+		}
+		in.created(remap, "/sensors/"+id, "/sensors/"+s.ID)
+	}
+	for _, id := range sortedIDs(bd.Schedules) {
+		s := *bd.Schedules[id]
+		s.Command.Address = remapAddr(remap, s.Command.Address, b.username)
+		if err := b.Schedules().Create(&s); err != nil {
+			return err
+		}
+		in.created(remap, "/schedules/"+id, "/schedules/"+s.ID)
+	}
+	for _, id := range sortedIDs(bd.Rules) {
+		r := *bd.Rules[id]
+		r.Owner = ""
+		r.Conditions = append([]Condition(nil), r.Conditions...)
+		for i := range r.Conditions {
+			r.Conditions[i].Address = remapAddr(remap, r.Conditions[i].Address, b.username)
+		}
+		r.Actions = append([]Command(nil), r.Actions...)
+		for i := range r.Actions {
+			r.Actions[i].Address = remapAddr(remap, r.Actions[i].Address, b.username)
+		}
+		if err := b.Rules().Create(&r); err != nil {
+			return err
+		}
+		in.created(remap, "/rules/"+id, "/rules/"+r.ID)
+	}
+	return nil
+}
+
+// created records the creation of a resource at addr, which had the address
+// old in the bundle.
+func (in *Installation) created(remap map[string]string, old, addr string) {
+	remap[old] = addr
+	in.Resources = append(in.Resources, addr)
+}
+
+// remapAddr returns the API address addr with the resource that it refers to
+// replaced according to remap. An "/api/<username>" prefix is replaced by
+// one with the given username.
+func remapAddr(remap map[string]string, addr, username string) string {
+	parts := strings.Split(strings.Trim(addr, "/"), "/")
+	prefixed := len(parts) > 2 && parts[0] == "api"
+	if prefixed {
+		parts = parts[2:]
+	}
+	n := len(parts)
+	if n > 2 {
+		n = 2
+	}
+	res := "/" + strings.Join(parts[:n], "/")
+	if to, ok := remap[res]; ok {
+		res = to
+	}
+	for _, p := range parts[n:] {
+		res += "/" + p
+	}
+	if prefixed {
+		return "/api/" + username + res
+	}
+	return res
+}
+
+// sortedIDs returns the keys of m, which must be a map keyed by ID, sorted.
+func sortedIDs(m interface{}) []string {
+	var ids []string
+	for id := range names(m) {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return lessID(ids[i], ids[j]) })
+	return ids
+}
@@ -0,0 +1,83 @@
+package hue
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestRemapAddr(t *testing.T) {
+	remap := map[string]string{"/sensors/5": "/sensors/12"}
+	for in, want := range map[string]string{
+		"/sensors/5/state/presence": "/sensors/12/state/presence",
+		"/api/old/sensors/5/state":  "/api/new/sensors/12/state",
+		"/groups/1/action":          "/groups/1/action",
+		"/api/old/groups/1/action":  "/api/new/groups/1/action",
+	} {
+		if got := remapAddr(remap, in, "new"); got != want {
+			t.Errorf("%s: expected %s, got %s", in, want, got)
+		}
+	}
+}
+
+func TestBundleExportImport(t *testing.T) {
+	src := mockBridge(t)
+	defer src.teardown()
+	src.responses = map[string]interface{}{
+		"/api/bridge_username/resourcelinks/7": json.RawMessage(`{"name": "Hall", "classid": 1,
+			"links": ["/sensors/2", "/sensors/5", "/rules/3", "/groups/1"]}`),
+		"/api/bridge_username/sensors": json.RawMessage(`{
+			"2": {"name": "Hall status", "type": "CLIPGenericStatus"},
+			"5": {"name": "Hall sensor", "type": "ZLLPresence"}}`),
+		"/api/bridge_username/rules": json.RawMessage(`{"3": {"name": "Hall on", "owner": "x",
+			"conditions": [{"address": "/sensors/5/state/presence", "operator": "eq", "value": "true"}],
+			"actions": [{"address": "/sensors/2/state", "method": "PUT", "body": {"status": 1}}]}}`),
+	}
+	bd, err := src.b.ExportBundle("7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bd.Sensors) != 1 || len(bd.Rules) != 1 || !reflect.DeepEqual(bd.External, []string{"/sensors/5", "/groups/1"}) {
+		t.Fatalf("unexpected bundle %+v", bd)
+	}
+
+	var rule map[string]interface{}
+	var link struct {
+		Links []string `json:"links"`
+	}
+	var next int
+	dst := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		switch r.URL.Path {
+		case "/api/user/rules":
+			json.Unmarshal(body, &rule)
+		case "/api/user/resourcelinks":
+			json.Unmarshal(body, &link)
+		}
+		next++
+		fmt.Fprintf(w, `[{"success": {"id": "%d"}}]`, 20+next)
+	}))
+	defer dst.Close()
+	b := &Bridge{bridgeID: bridgeID{IP: dst.URL + "/"}, username: "user"}
+	in, err := b.ImportBundle(bd, map[string]string{"/sensors/5": "/sensors/9"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if in.ID != "23" || !reflect.DeepEqual(in.Resources, []string{"/sensors/21", "/rules/22"}) {
+		t.Fatalf("unexpected installation %+v", in)
+	}
+	conds := rule["conditions"].([]interface{})
+	acts := rule["actions"].([]interface{})
+	if conds[0].(map[string]interface{})["address"] != "/sensors/9/state/presence" ||
+		acts[0].(map[string]interface{})["address"] != "/sensors/21/state" || rule["owner"] != nil {
+		t.Fatalf("unexpected rule %v", rule)
+	}
+	want := []string{"/sensors/21", "/rules/22", "/sensors/9", "/groups/1"}
+	if !reflect.DeepEqual(link.Links, want) {
+		t.Fatalf("expected links %v, got %v", want, link.Links)
+	}
+}
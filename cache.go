@@ -10,39 +10,113 @@ import (
 	"github.com/mitchellh/go-homedir"
 )
 
-// cacheFile stores the name of the file where bridge cache will be stored.
+// cacheFile stores the name of the file in the home directory where the bridge
+// cache will be stored by default.
 var cacheFile = ".hue"
 
-// cacheBridge holds the format of the contents of the cache file.
-type cachedBridge struct{ ID, IP, Username string }
+// cacheBridge holds the format of an entry in the cache file.
+type cachedBridge struct {
+	ID, IP, Username string
+	Name             string `json:",omitempty"`
 
-// toCache writes bridge b to the cache file.
-func toCache(b *Bridge) {
+	// App is the name of the application which paired, if not the default.
+	App string `json:",omitempty"`
+
+	// Order holds the display order of lights and groups, if set.
+	Order *displayOrder `json:",omitempty"`
+}
+
+// defaultCachePath returns the default path of the cache file, or an empty
+// string if it can not be determined.
+func defaultCachePath() string {
 	homeDir, err := homedir.Dir()
 	if err != nil {
 		log.Printf("could not get homedir: %v", err)
-		return
+		return ""
 	}
-	data, err := json.Marshal(cachedBridge{ID: b.ID, IP: b.IP, Username: b.username})
-	if err != nil {
-		log.Printf("could not cache: %v", err)
+	return path.Join(homeDir, cacheFile)
+}
+
+// toCache writes bridge b to its cache file, as the first entry. Entries of
+// other bridges, or of other applications, are preserved. It does nothing if
+// caching is disabled for b.
+func toCache(b *Bridge) {
+	if b.cachePath == "" {
 		return
 	}
-	err = ioutil.WriteFile(path.Join(homeDir, cacheFile), data, 0666)
-	if err != nil {
+	entry := cachedBridge{ID: b.ID, IP: b.IP, Username: b.username, Name: b.Name, App: b.app}
+	list := []cachedBridge{entry}
+	for _, c := range readCache(b.cachePath) {
+		if !c.is(b) {
+			list = append(list, c)
+		} else {
+			list[0].Order = c.Order
+		}
+	}
+	if err := writeCache(b.cachePath, list); err != nil {
 		log.Printf("could not cache: %v", err)
-		return
 	}
 }
 
-// fromCache returns the cached bridge or nil otherwise.
-func fromCache() *Bridge {
-	homeDir, err := homedir.Dir()
+// writeCache replaces the entries of the cache file at path p with list.
+func writeCache(p string, list []cachedBridge) error {
+	data, err := json.Marshal(list)
 	if err != nil {
-		log.Printf("could not get homedir: %v", err)
+		return err
+	}
+	return ioutil.WriteFile(p, data, 0666)
+}
+
+// is reports whether the entry holds the credentials of the application of
+// bridge b for it.
+func (c *cachedBridge) is(b *Bridge) bool {
+	return sameID(c.ID, b.ID) && c.App == b.app
+}
+
+// fromCache returns the bridge most recently cached by the given application
+// in the file at path p. If the application has not paired with any bridge,
+// the most recently cached bridge is returned without credentials. It returns
+// nil if the cache is empty or p is empty.
+func fromCache(p, app string) *Bridge {
+	list := readCache(p)
+	if len(list) == 0 {
 		return nil
 	}
-	data, err := ioutil.ReadFile(path.Join(homeDir, cacheFile))
+	for _, c := range list {
+		if c.App == app {
+			return &Bridge{
+				bridgeID:  bridgeID{ID: c.ID, IP: c.IP, Name: c.Name},
+				username:  c.Username,
+				cachePath: p,
+				app:       app,
+			}
+		}
+	}
+	return &Bridge{
+		bridgeID:  bridgeID{ID: list[0].ID, IP: list[0].IP, Name: list[0].Name},
+		cachePath: p,
+		app:       app,
+	}
+}
+
+// cachedUsername returns the username cached by the application of bridge b
+// for it, if any.
+func cachedUsername(b *Bridge) string {
+	for _, c := range readCache(b.cachePath) {
+		if c.is(b) {
+			return c.Username
+		}
+	}
+	return ""
+}
+
+// readCache returns the entries of the cache file at path p, most recent first.
+// If p is empty, it returns nil.
+func readCache(p string) []cachedBridge {
+	if p == "" {
+		return nil
+	}
+	data, err := ioutil.ReadFile(p)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return nil
@@ -50,13 +124,15 @@ func fromCache() *Bridge {
 		log.Printf("could not retrieve cache: %v", err)
 		return nil
 	}
-	var b cachedBridge
-	if err := json.Unmarshal(data, &b); err != nil {
-		log.Printf("could not retrieve cache: %v", err)
-		return nil
-	}
-	return &Bridge{
-		bridgeID: bridgeID{ID: b.ID, IP: b.IP},
-		username: b.Username,
+	var list []cachedBridge
+	if err := json.Unmarshal(data, &list); err != nil {
+		// older versions stored a single bridge
+		var b cachedBridge
+		if err := json.Unmarshal(data, &b); err != nil {
+			log.Printf("could not retrieve cache: %v", err)
+			return nil
+		}
+		list = []cachedBridge{b}
 	}
+	return list
 }
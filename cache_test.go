@@ -1,33 +1,198 @@
 package hue
 
 import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path"
 	"reflect"
 	"testing"
-
-	"github.com/mitchellh/go-homedir"
 )
 
 func TestToCacheFromCache(t *testing.T) {
-	origCache := cacheFile
-	cacheFile = ".hue-test"
-	want := &Bridge{bridgeID: bridgeID{ID: "id", IP: "ip"}, username: "user"}
+	dir, err := ioutil.TempDir("", "hue")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	p := path.Join(dir, ".hue-test")
+	want := &Bridge{
+		bridgeID:  bridgeID{ID: "id", IP: "ip"},
+		username:  "user",
+		cachePath: p,
+	}
 	toCache(want)
-	b := fromCache()
+	b := fromCache(p, "")
 	if b == nil {
 		t.Fatal("expected non-nil response from cache")
 	}
 	if !reflect.DeepEqual(want, b) {
 		t.Fatalf("expected %v, got %v", want, b)
 	}
-	// clean-up
-	homeDir, err := homedir.Dir()
+}
+
+func TestDisabledCache(t *testing.T) {
+	toCache(&Bridge{bridgeID: bridgeID{ID: "id", IP: "ip"}})
+	if b := fromCache("", ""); b != nil {
+		t.Fatalf("expected nil, got %v", b)
+	}
+}
+
+func TestDiscoverWithCachePath(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hue")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	p := path.Join(dir, "bridge.json")
+	want := new(options).apply(&Bridge{
+		bridgeID:  bridgeID{ID: "id", IP: "ip"},
+		username:  "user",
+		cachePath: p,
+	})
+	toCache(want)
+	b, err := Discover(WithCachePath(p))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(want, b) {
+		t.Fatalf("expected %v, got %v", want, b)
+	}
+}
+
+func TestDiscoverCacheOnly(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hue")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	p := path.Join(dir, "bridge.json")
+	if _, err := Discover(WithCachePath(p), CacheOnly()); err != ErrNotFound {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	if _, err := Discover(WithCachePath(p), CacheOnly(), WithBridgeID("001788fffe4a5b6c")); err != ErrNotFound {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	toCache(&Bridge{bridgeID: bridgeID{ID: "id", IP: "ip"}, username: "user", cachePath: p})
+	if b, err := Discover(WithCachePath(p), CacheOnly()); err != nil || b.username != "user" {
+		t.Fatalf("expected the cached bridge, got %v, %v", b, err)
+	}
+}
+
+func TestCacheMultipleBridges(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hue")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	p := path.Join(dir, ".hue-test")
+	// older versions stored a single bridge
+	if err := ioutil.WriteFile(p, []byte(`{"ID":"a","IP":"ip-a","Username":"user-a"}`), 0666); err != nil {
+		t.Fatal(err)
+	}
+	toCache(&Bridge{bridgeID: bridgeID{ID: "b", IP: "ip-b"}, username: "user-b", cachePath: p})
+	want := []cachedBridge{
+		{ID: "b", IP: "ip-b", Username: "user-b"},
+		{ID: "a", IP: "ip-a", Username: "user-a"},
+	}
+	if got := readCache(p); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	// updating a bridge moves it to the front
+	toCache(&Bridge{bridgeID: bridgeID{ID: "a", IP: "ip-a2"}, username: "user-a", cachePath: p})
+	want = []cachedBridge{
+		{ID: "a", IP: "ip-a2", Username: "user-a"},
+		{ID: "b", IP: "ip-b", Username: "user-b"},
+	}
+	if got := readCache(p); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	if b := fromCache(p, ""); b.ID != "a" || b.IP != "ip-a2" {
+		t.Fatalf("expected most recent bridge, got %v", b)
+	}
+}
+
+func TestDiscoverWithBridgeID(t *testing.T) {
+	srv := serverWithResponse(`{"bridgeid": "001788FFFEA1B2C3"}`)
+	defer srv.Close()
+	dir, err := ioutil.TempDir("", "hue")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	p := path.Join(dir, "bridge.json")
+	toCache(&Bridge{
+		bridgeID:  bridgeID{ID: "001788fffea1b2c3", IP: srv.URL + "/"},
+		username:  "user",
+		cachePath: p,
+	})
+	b, err := Discover(WithCachePath(p), WithBridgeID("001788a1b2c3"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.username != "user" || !b.verifyID {
+		t.Fatalf("unexpected bridge: %+v", b)
+	}
+}
+
+func TestCachePerApp(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hue")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	p := path.Join(dir, ".hue-test")
+	toCache(&Bridge{bridgeID: bridgeID{ID: "a", IP: "ip-a"}, username: "user-default", cachePath: p})
+	toCache(&Bridge{bridgeID: bridgeID{ID: "a", IP: "ip-a"}, username: "user-lamps", cachePath: p, app: "lamps"})
+	if b := fromCache(p, ""); b.username != "user-default" {
+		t.Fatalf("expected user-default, got %q", b.username)
+	}
+	if b := fromCache(p, "lamps"); b.username != "user-lamps" || b.app != "lamps" {
+		t.Fatalf("expected user-lamps, got %q", b.username)
+	}
+	// an application which has not paired gets the bridge without credentials
+	b, err := Discover(WithCachePath(p), WithAppName("other"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.ID != "a" || b.IsPaired() {
+		t.Fatalf("expected unpaired bridge a, got %v (paired: %v)", b.ID, b.IsPaired())
+	}
+}
+
+func TestDiscoverPairPerApp(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			w.Write([]byte(`[{"success": {"username": "user-lamps"}}]`))
+			return
+		}
+		w.Write([]byte(`{"name": "Office bridge", "bridgeid": "001788FFFEA1B2C3"}`))
+	}))
+	defer srv.Close()
+	orig := mechanisms
+	defer func() { mechanisms = orig }()
+	mechanisms = map[string]func(context.Context, *options) (bridgeID, error){
+		"local": func(context.Context, *options) (bridgeID, error) {
+			return bridgeID{ID: "001788fffea1b2c3", IP: srv.URL + "/"}, nil
+		},
+	}
+	dir, err := ioutil.TempDir("", "hue")
 	if err != nil {
-		t.Fatalf("failed to clean up: %v", err)
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	p := path.Join(dir, "bridge.json")
+	b, err := Discover(WithCachePath(p), WithAppName("lamps"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Pair(); err != nil {
+		t.Fatal(err)
 	}
-	if err := os.Remove(path.Join(homeDir, cacheFile)); err != nil {
-		t.Fatalf("failed to clean up: %v", err)
+	list := readCache(p)
+	if len(list) != 1 || list[0].App != "lamps" || list[0].Username != "user-lamps" {
+		t.Fatalf("expected the credentials to be cached for the app, got %+v", list)
 	}
-	cacheFile = origCache
 }
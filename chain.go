@@ -0,0 +1,177 @@
+package hue
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Step is a step of a lighting sequence, such as setting the state of a group
+// or waiting. Steps are composed using Sequence and Parallel, and executed
+// using Run. For example:
+//
+//	movie := hue.Sequence(
+//		hue.SetGroup(hall, &hue.State{On: true, Brightness: 50}),
+//		hue.Parallel(hue.GroupOff(kitchen), hue.RecallScene(living, "ab12")),
+//		hue.Wait(5*time.Minute),
+//		hue.GroupOff(hall),
+//	)
+//	err := movie.Run(ctx, nil)
+type Step struct {
+	// name describes the step, for progress reports.
+	name string
+
+	// fn executes the step, if it is not composed of others and not a Wait
+	// step.
+	fn func(context.Context) error
+
+	// steps are the steps that this step is composed of. It is nil for
+	// steps which are not composed of others.
+	steps []Step
+
+	// parallel, when true, causes steps to be executed concurrently.
+	parallel bool
+
+	// wait is the duration of a Wait step.
+	wait time.Duration
+}
+
+// Sequence returns a step which executes the given steps one after the other,
+// stopping at the first which fails.
+func Sequence(steps ...Step) Step {
+	return Step{name: "sequence", steps: append([]Step{}, steps...)}
+}
+
+// Parallel returns a step which executes the given steps concurrently. When
+// one of them fails, the others are cancelled.
+func Parallel(steps ...Step) Step {
+	return Step{name: "parallel", steps: append([]Step{}, steps...), parallel: true}
+}
+
+// Wait returns a step which waits for the given duration.
+func Wait(d time.Duration) Step {
+	return Step{name: fmt.Sprintf("wait %v", d), wait: d}
+}
+
+// Func returns a step described by name, which calls fn.
+func Func(name string, fn func(context.Context) error) Step {
+	return Step{name: name, fn: fn}
+}
+
+// SetGroup returns a step which sets the state of group g.
+func SetGroup(g *Group, s *State) Step {
+	return Func(fmt.Sprintf("set group %s", g.Name), func(context.Context) error {
+		return g.Set(s)
+	})
+}
+
+// GroupOff returns a step which turns the lights of group g off.
+func GroupOff(g *Group) Step {
+	return Func(fmt.Sprintf("turn group %s off", g.Name), func(context.Context) error {
+		return g.Off()
+	})
+}
+
+// RecallScene returns a step which applies the scene with the given ID to the
+// lights of group g.
+func RecallScene(g *Group, id string) Step {
+	return Func(fmt.Sprintf("recall scene %s in group %s", id, g.Name), func(context.Context) error {
+		return g.RecallScene(id)
+	})
+}
+
+// SetLight returns a step which sets the state of light l.
+func SetLight(l *Light, s *State) Step {
+	return Func(fmt.Sprintf("set light %s", l.Name), func(context.Context) error {
+		return l.Set(s)
+	})
+}
+
+// Run executes the step until it completes, fails or the context is done. If
+// progress is not nil, it is called after each step that is not composed of
+// others, with the number of such steps done, the total, the name of the step
+// and its outcome. Calls to progress are not concurrent.
+func (s Step) Run(ctx context.Context, progress func(done, total int, step string, err error)) error {
+	return s.RunWithClock(ctx, SystemClock, progress)
+}
+
+// RunWithClock is like Run, but uses the given clock for Wait steps.
+func (s Step) RunWithClock(ctx context.Context, c Clock, progress func(done, total int, step string, err error)) error {
+	r := &stepRunner{clock: c, total: s.count(), progress: progress}
+	return r.run(ctx, s)
+}
+
+// count returns the number of steps which are not composed of others within s.
+func (s Step) count() int {
+	if s.steps == nil {
+		return 1
+	}
+	var n int
+	for _, st := range s.steps {
+		n += st.count()
+	}
+	return n
+}
+
+// stepRunner executes steps, reporting progress.
+type stepRunner struct {
+	clock    Clock
+	mu       sync.Mutex
+	done     int
+	total    int
+	progress func(done, total int, step string, err error)
+}
+
+// run executes step s.
+func (r *stepRunner) run(ctx context.Context, s Step) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	switch {
+	case s.steps == nil:
+		err := r.runLeaf(ctx, s)
+		r.mu.Lock()
+		r.done++
+		if r.progress != nil {
+			r.progress(r.done, r.total, s.name, err)
+		}
+		r.mu.Unlock()
+		return err
+	case s.parallel:
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		errc := make(chan error, len(s.steps))
+		for _, st := range s.steps {
+			go func(st Step) { errc <- r.run(ctx, st) }(st)
+		}
+		var first error
+		for range s.steps {
+			if err := <-errc; err != nil && first == nil {
+				first = err
+				cancel()
+			}
+		}
+		return first
+	default:
+		for _, st := range s.steps {
+			if err := r.run(ctx, st); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// runLeaf executes step s, which is not composed of others.
+func (r *stepRunner) runLeaf(ctx context.Context, s Step) error {
+	if s.fn != nil {
+		return s.fn(ctx)
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-r.clock.After(s.wait):
+		return nil
+	}
+}
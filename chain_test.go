@@ -0,0 +1,58 @@
+package hue
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestStepRun(t *testing.T) {
+	var order []string
+	record := func(name string) Step {
+		return Func(name, func(context.Context) error {
+			order = append(order, name)
+			return nil
+		})
+	}
+	var reports int
+	s := Sequence(record("a"), Sequence(record("b"), record("c")), Wait(time.Millisecond), record("d"))
+	err := s.Run(context.Background(), func(done, total int, step string, err error) {
+		reports++
+		if done != reports || total != 5 {
+			t.Fatalf("unexpected progress %d/%d", done, total)
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(order) != 4 || order[0] != "a" || order[3] != "d" || reports != 5 {
+		t.Fatalf("unexpected order %v (%d reports)", order, reports)
+	}
+}
+
+func TestParallelCancel(t *testing.T) {
+	errFail := errors.New("fail")
+	s := Parallel(
+		Wait(time.Minute),
+		Func("fail", func(context.Context) error { return errFail }),
+	)
+	start := time.Now()
+	if err := s.Run(context.Background(), nil); err != errFail {
+		t.Fatalf("expected errFail, got %v", err)
+	}
+	if time.Since(start) > 10*time.Second {
+		t.Fatal("expected wait to be cancelled")
+	}
+}
+
+func TestRunWithClock(t *testing.T) {
+	clock := &fakeClock{t: time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)}
+	s := Sequence(Wait(time.Hour), Wait(30*time.Minute))
+	if err := s.RunWithClock(context.Background(), clock, nil); err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2018, 1, 1, 1, 30, 0, 0, time.UTC); !clock.Now().Equal(want) {
+		t.Fatalf("expected %v, got %v", want, clock.Now())
+	}
+}
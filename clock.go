@@ -0,0 +1,38 @@
+package hue
+
+import "time"
+
+// Clock is a source of time. The time-based features of this package, such as
+// quiet hours, Follower and the Wait step, use it instead of the system clock
+// when one is given, so that applications can test their lighting logic
+// deterministically, or drive it using simulated time.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+
+	// After returns a channel on which the time is sent once the duration d
+	// has elapsed.
+	After(d time.Duration) <-chan time.Time
+}
+
+// SystemClock is the Clock of the system.
+var SystemClock Clock = systemClock{}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now() }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// clockOf returns c, or SystemClock if c is nil.
+func clockOf(c Clock) Clock {
+	if c == nil {
+		return SystemClock
+	}
+	return c
+}
+
+// now returns the current time, according to the clock of the bridge.
+func (b *Bridge) now() time.Time { return clockOf(b.clock).Now() }
+
+// sleep waits for the duration d, according to the clock of the bridge.
+func (b *Bridge) sleep(d time.Duration) { <-clockOf(b.clock).After(d) }
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+func init() {
+	commands["api"] = &command{
+		usage: "<method> <path> [body | -]\n" +
+			"\tcalls the bridge API at the path relative to /api/<username>, e.g.\n" +
+			"\t\"hue api GET /lights/1\", and prints the response. A body of \"-\"\n" +
+			"\tis read from standard input",
+		run: runAPI,
+	}
+}
+
+func runAPI(args []string) error {
+	if len(args) < 2 || len(args) > 3 {
+		return errUsage
+	}
+	var body []byte
+	if len(args) == 3 {
+		body = []byte(args[2])
+		if args[2] == "-" {
+			var err error
+			body, err = ioutil.ReadAll(os.Stdin)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	b, err := bridge()
+	if err != nil {
+		return err
+	}
+	msg, err := b.Do(strings.ToUpper(args[0]), args[1], body)
+	if err != nil {
+		return err
+	}
+	var out bytes.Buffer
+	format := func() error { return json.Indent(&out, msg, "", "  ") }
+	if *jsonFlag {
+		format = func() error { return json.Compact(&out, msg) }
+	}
+	if err := format(); err != nil {
+		// not JSON; print as is
+		os.Stdout.Write(msg)
+		return nil
+	}
+	fmt.Println(strings.TrimSpace(out.String()))
+	return nil
+}
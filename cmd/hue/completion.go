@@ -0,0 +1,193 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"gbbr.io/hue"
+)
+
+func init() {
+	commands["completion"] = &command{
+		usage: "bash | zsh | fish\n" +
+			"\tprints the shell completion script, e.g.: source <(hue completion bash)",
+		run:   runCompletion,
+		local: true,
+	}
+	// __complete is used by the completion scripts.
+	commands["__complete"] = &command{run: runComplete, local: true}
+}
+
+// completionScripts holds the completion script for each supported shell. They
+// call "hue __complete" with the words on the command line, the last one being
+// the word being completed.
+var completionScripts = map[string]string{
+	"bash": `_hue() {
+	local IFS=$'\n'
+	COMPREPLY=($(hue __complete "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null))
+}
+complete -F _hue hue
+`,
+	"zsh": `#compdef hue
+_hue() {
+	local -a opts
+	opts=("${(@f)$(hue __complete "${(@)words[2,CURRENT]}" 2>/dev/null)}")
+	compadd -a opts
+}
+compdef _hue hue
+`,
+	"fish": `complete -c hue -f -a '(hue __complete (commandline -opc)[2..-1] (commandline -ct) 2>/dev/null)'
+`,
+}
+
+func runCompletion(args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+	script, ok := completionScripts[args[0]]
+	if !ok {
+		return errUsage
+	}
+	fmt.Print(script)
+	return nil
+}
+
+// runComplete prints the candidates for the last of the given words, one per
+// line.
+func runComplete(args []string) error {
+	if len(args) == 0 {
+		args = []string{""}
+	}
+	cur := args[len(args)-1]
+	words := args[:len(args)-1]
+	// skip global flags
+	for len(words) > 0 && strings.HasPrefix(words[0], "-") {
+		if strings.TrimLeft(words[0], "-") == "bridge" && len(words) > 1 {
+			words = words[1:]
+		}
+		words = words[1:]
+	}
+	for _, c := range candidates(words) {
+		if strings.HasPrefix(c, cur) {
+			fmt.Println(c)
+		}
+	}
+	return nil
+}
+
+// candidates returns the possible words following the given ones.
+func candidates(words []string) []string {
+	if len(words) == 0 {
+		return append(commandNames(), pluginNames()...)
+	}
+	switch words[0] {
+	case "completion":
+		if len(words) == 1 {
+			return []string{"bash", "fish", "zsh"}
+		}
+	case "lights":
+		if len(words) == 1 {
+			return []string{"identify", "list", "off", "on", "rename", "startup", "toggle"}
+		}
+		if _, ok := lightActions[words[1]]; ok {
+			return lightNames()
+		}
+		if (words[1] == "identify" || words[1] == "rename") && len(words) == 2 {
+			return lightNames()
+		}
+		if words[1] == "startup" {
+			if len(words) == 2 {
+				return []string{hue.StartupLastOnState, hue.StartupPowerFail, hue.StartupSafety}
+			}
+			return lightNames()
+		}
+	case "api":
+		if len(words) == 1 {
+			return []string{"DELETE", "GET", "POST", "PUT"}
+		}
+	case "sensors":
+		if len(words) == 1 {
+			return []string{"list"}
+		}
+	case "schedule":
+		if len(words) == 1 {
+			return []string{"run"}
+		}
+	case "groups":
+		if len(words) == 1 {
+			return []string{"list", "off", "on", "scene"}
+		}
+		if len(words) == 2 && words[1] != "list" {
+			return groupNames()
+		}
+		if len(words) == 3 && words[1] == "scene" {
+			return sceneNames()
+		}
+	}
+	return nil
+}
+
+// pairedBridge returns the cached bridge, if it was paired with. Unlike bridge,
+// it never attempts to discover or pair, so that completion does not block.
+func pairedBridge() *hue.Bridge {
+	b, err := hue.Discover(hue.CacheOnly())
+	if err != nil || !b.IsPaired() {
+		return nil
+	}
+	return b
+}
+
+// lightNames returns the names of all lights on the cached bridge.
+func lightNames() []string {
+	b := pairedBridge()
+	if b == nil {
+		return nil
+	}
+	list, err := b.Lights().List()
+	if err != nil {
+		return nil
+	}
+	names := make([]string, 0, len(list))
+	for _, l := range list {
+		names = append(names, l.Name)
+	}
+	return names
+}
+
+// groupNames returns the names of all groups on the cached bridge.
+func groupNames() []string {
+	b := pairedBridge()
+	if b == nil {
+		return nil
+	}
+	list, err := b.Groups().List()
+	if err != nil {
+		return nil
+	}
+	names := make([]string, 0, len(list))
+	for _, g := range list {
+		names = append(names, g.Name)
+	}
+	return names
+}
+
+// sceneNames returns the names of all scenes on the cached bridge.
+func sceneNames() []string {
+	b := pairedBridge()
+	if b == nil {
+		return nil
+	}
+	list, err := b.Scenes().List()
+	if err != nil {
+		return nil
+	}
+	seen := make(map[string]bool, len(list))
+	names := make([]string, 0, len(list))
+	for _, sc := range list {
+		if !seen[sc.Name] {
+			seen[sc.Name] = true
+			names = append(names, sc.Name)
+		}
+	}
+	return names
+}
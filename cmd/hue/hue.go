@@ -1,32 +1,198 @@
+// Command hue controls the lights connected to a Philips Hue bridge. Run it
+// without arguments to see the list of commands.
+//
+// By default, commands operate on the cached bridge, or the first one that
+// is discovered. The -bridge flag selects a bridge by ID, name or IP address,
+// while -all-bridges runs the command on every bridge on the network.
+//
+// For scripting, the -json flag prints results as JSON, one object per line,
+// and -quiet suppresses human readable output. The exit status is 1 on
+// failure, 2 on bad usage, 3 when a light, group or sensor was not found and
+// 4 when the bridge could not be found or reached.
+//
+// Commands which are not built-in are looked up as plugins: running
+// "hue foo args..." executes the program "hue-foo args..." found in PATH.
 package main
 
 import (
+	"errors"
+	"flag"
+	"fmt"
 	"log"
+	"os"
+	"sort"
+	"strings"
 
 	"gbbr.io/hue"
 )
 
+// command is a subcommand of the CLI.
+type command struct {
+	// usage holds the arguments of the command, followed by a short
+	// description.
+	usage string
+
+	// run runs the command with the given arguments.
+	run func(args []string) error
+
+	// local is true for commands which do not operate on a bridge.
+	local bool
+}
+
+// commands holds all the built-in commands, by name.
+var commands = map[string]*command{}
+
+// errUsage is returned by commands when they are called with bad arguments.
+var errUsage = errors.New("bad usage")
+
+// exitStatus is returned by commands which completed, but need the CLI to exit
+// with the given status.
+type exitStatus int
+
+func (e exitStatus) Error() string { return fmt.Sprintf("exit status %d", int(e)) }
+
+var (
+	bridgeFlag     = flag.String("bridge", "", "")
+	allBridgesFlag = flag.Bool("all-bridges", false, "")
+)
+
+// target is the bridge that commands operate on. If nil, the cached or first
+// discovered bridge is used.
+var target *hue.Bridge
+
 func main() {
-	b, err := hue.Discover()
-	if err != nil {
-		log.Fatal(err)
+	log.SetFlags(0)
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() < 1 {
+		usage()
+		os.Exit(exitUsage)
 	}
-	if !b.IsPaired() {
-		// link button must be pressed for non-error response
-		if err := b.Pair(); err != nil {
+	name, args := flag.Arg(0), flag.Args()[1:]
+	cmd, ok := commands[name]
+	if !ok {
+		if err := runPlugin(name, args); err != nil {
+			if err == errNoPlugin {
+				usage()
+				os.Exit(exitUsage)
+			}
 			log.Fatal(err)
 		}
+		return
+	}
+	if cmd.local || (*bridgeFlag == "" && !*allBridgesFlag) {
+		exit(name, cmd, cmd.run(args))
+		return
 	}
-	l, err := b.Lights().Get("Couch")
+	bridges, err := selectBridges()
 	if err != nil {
-		log.Fatal(err)
+		log.Print(err)
+		os.Exit(exitUnreachable)
+	}
+	if len(bridges) == 1 {
+		target = bridges[0]
+		exit(name, cmd, cmd.run(args))
+		return
+	}
+	var failed error
+	for _, b := range bridges {
+		target = b
+		emit(map[string]string{"bridge": b.ID, "ip": b.IP, "name": b.Name}, "# bridge %s %q (%s)\n", b.ID, b.Name, b.IP)
+		if err := cmd.run(args); err != nil {
+			if err == errUsage {
+				exit(name, cmd, err)
+			}
+			failed = wrap("bridge "+b.ID, err)
+			log.Print(failed)
+		}
+	}
+	if failed != nil {
+		os.Exit(exitCode(failed))
+	}
+}
+
+// exit exits the program with the appropriate status if the given error,
+// returned by command cmd, is non-nil.
+func exit(name string, cmd *command, err error) {
+	if err == nil {
+		return
+	}
+	code := exitCode(err)
+	switch {
+	case code == exitUsage:
+		fmt.Fprintf(os.Stderr, "usage: hue %s %s\n", name, cmd.usage)
+	case err != exitStatus(code):
+		log.Print(err)
 	}
-	err = l.Set(&hue.State{
-		TransitionTime: 0,
-		Brightness:     255,
-		XY:             &[2]float64{1, 0.8},
-	})
+	os.Exit(code)
+}
+
+// usage prints the list of commands.
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: hue [-bridge <id|name|ip> | -all-bridges] [-json] [-quiet] <command> [arguments]\n\ncommands:")
+	for _, name := range commandNames() {
+		fmt.Fprintf(os.Stderr, "  %s %s\n", name, commands[name].usage)
+	}
+}
+
+// commandNames returns the sorted names of the built-in commands. Commands
+// starting with "__" are for internal use and are omitted.
+func commandNames() []string {
+	var names []string
+	for name := range commands {
+		if strings.HasPrefix(name, "__") {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// selectBridges returns the bridges chosen using the -bridge or -all-bridges
+// flags.
+func selectBridges() ([]*hue.Bridge, error) {
+	all, err := hue.DiscoverAll()
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
+	}
+	if *allBridgesFlag {
+		return all, nil
+	}
+	want := strings.ToLower(*bridgeFlag)
+	if len(want) == 12 {
+		// the MAC address form of the ID
+		want = want[:6] + "fffe" + want[6:]
+	}
+	for _, b := range all {
+		if strings.ToLower(b.ID) == want ||
+			strings.Trim(strings.TrimPrefix(b.IP, "http://"), "/") == *bridgeFlag {
+			return []*hue.Bridge{b}, nil
+		}
+	}
+	for _, b := range all {
+		if b.Name == *bridgeFlag {
+			return []*hue.Bridge{b}, nil
+		}
+	}
+	return nil, fmt.Errorf("bridge %q not found", *bridgeFlag)
+}
+
+// bridge returns the bridge to operate on, pairing with it if needed. The link
+// button must be pressed for pairing to succeed.
+func bridge() (*hue.Bridge, error) {
+	b := target
+	if b == nil {
+		var err error
+		b, err = hue.Discover()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !b.IsPaired() {
+		if err := b.Pair(); err != nil {
+			return nil, err
+		}
 	}
+	return b, nil
 }
@@ -0,0 +1,275 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gbbr.io/hue"
+)
+
+func init() {
+	commands["lights"] = &command{
+		usage: "list | on [light...] | off [light...] | toggle [light...] |\n" +
+			"\tidentify <light> | rename [all | <light> <name>] |\n" +
+			"\tstartup <safety|powerfail|lastonstate> [light...]\n" +
+			"\tlists or switches the given lights, or all lights if none are given.\n" +
+			"\tidentify blinks a light. rename without a new name blinks each new\n" +
+			"\tlight (or all lights) in turn, prompting for its name. startup sets\n" +
+			"\tthe power-on behavior of the given lights, or all lights.\n" +
+			"\tlights are given by ID or name",
+		run: runLights,
+	}
+	commands["groups"] = &command{
+		usage: "list | on <name> | off <name> | scene <name> <scene>\n" +
+			"\tlists or switches groups of lights, or recalls a scene, given by\n" +
+			"\tname or ID, in a group",
+		run: runGroups,
+	}
+}
+
+// lightActions maps actions that can be applied to lights by name.
+var lightActions = map[string]func(*hue.Light) error{
+	"on":     (*hue.Light).On,
+	"off":    (*hue.Light).Off,
+	"toggle": (*hue.Light).Toggle,
+}
+
+func runLights(args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+	b, err := bridge()
+	if err != nil {
+		return err
+	}
+	switch args[0] {
+	case "list":
+		list, err := b.Lights().List()
+		if err != nil {
+			return err
+		}
+		sort.Sort(lightsByName(list))
+		for _, l := range list {
+			state := "off"
+			if l.State.On {
+				state = "on"
+			}
+			emit(map[string]interface{}{
+				"id":         l.ID,
+				"name":       l.Name,
+				"on":         l.State.On,
+				"brightness": l.State.Brightness,
+				"reachable":  l.State.Reachable,
+			}, "%-4s %-32s %s\n", l.ID, l.Name, state)
+		}
+		return nil
+	case "identify":
+		if len(args) != 2 {
+			return errUsage
+		}
+		l, err := findLight(b, args[1])
+		if err != nil {
+			return err
+		}
+		return l.Breathe()
+	case "startup":
+		if len(args) < 2 {
+			return errUsage
+		}
+		names := make(map[string]bool)
+		for _, n := range args[2:] {
+			names[n] = true
+		}
+		filter := func(l *hue.Light) bool { return names[l.ID] || names[l.Name] }
+		if len(names) == 0 {
+			filter = nil
+		}
+		return b.Lights().SetStartupBehavior(args[1], filter, func(done, total int, l *hue.Light, err error) {
+			status := "ok"
+			if err != nil {
+				status = err.Error()
+			}
+			emit(map[string]interface{}{
+				"id":     l.ID,
+				"name":   l.Name,
+				"mode":   args[1],
+				"ok":     err == nil,
+				"status": status,
+			}, "[%d/%d] %s: %s\n", done, total, l.Name, status)
+		})
+	case "rename":
+		switch len(args) {
+		case 1:
+			return renameInteractive(b, false)
+		case 2:
+			if args[1] != "all" {
+				return errUsage
+			}
+			return renameInteractive(b, true)
+		case 3:
+			l, err := findLight(b, args[1])
+			if err != nil {
+				return err
+			}
+			return l.Rename(args[2])
+		}
+		return errUsage
+	}
+	fn, ok := lightActions[args[0]]
+	if !ok {
+		return errUsage
+	}
+	if len(args) == 1 {
+		return b.Lights().ForEachE(fn)
+	}
+	for _, name := range args[1:] {
+		l, err := findLight(b, name)
+		if err != nil {
+			return err
+		}
+		if err := fn(l); err != nil {
+			return wrap(name, err)
+		}
+	}
+	return nil
+}
+
+// findLight returns the light with the given ID, name or unique ID.
+func findLight(b *hue.Bridge, s string) (*hue.Light, error) {
+	l, err := b.Lights().GetByID(s)
+	if err == hue.ErrNotExist {
+		l, err = b.Lights().Get(s)
+	}
+	if err == hue.ErrNotExist {
+		l, err = b.Lights().GetByUID(s)
+	}
+	if err != nil {
+		return nil, wrap(s, err)
+	}
+	return l, nil
+}
+
+// renameInteractive blinks each light found by the last scan, or each light
+// if all is true, and prompts for its new name.
+func renameInteractive(b *hue.Bridge, all bool) error {
+	var (
+		list []*hue.Light
+		err  error
+	)
+	if all {
+		list, err = b.Lights().List()
+	} else {
+		list, err = b.Lights().New()
+	}
+	if err != nil {
+		return err
+	}
+	if len(list) == 0 {
+		say("No new lights. Use \"hue lights rename all\" to rename all lights.\n")
+		return nil
+	}
+	sort.Sort(lightsByID(list))
+	in := bufio.NewScanner(os.Stdin)
+	for _, l := range list {
+		if err := l.Set(&hue.State{On: true, Alert: hue.AlertLongSelect}); err != nil {
+			return wrap(l.Name, err)
+		}
+		fmt.Printf("Light %s (%q) is blinking. New name (empty to skip): ", l.ID, l.Name)
+		if !in.Scan() {
+			return in.Err()
+		}
+		if err := l.Set(&hue.State{Alert: hue.AlertNone}); err != nil {
+			return wrap(l.Name, err)
+		}
+		name := strings.TrimSpace(in.Text())
+		if name == "" {
+			continue
+		}
+		if err := l.Rename(name); err != nil {
+			return wrap(l.Name, err)
+		}
+	}
+	return nil
+}
+
+func runGroups(args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+	b, err := bridge()
+	if err != nil {
+		return err
+	}
+	switch args[0] {
+	case "list":
+		list, err := b.Groups().List()
+		if err != nil {
+			return err
+		}
+		sort.Sort(groupsByName(list))
+		for _, g := range list {
+			emit(map[string]string{
+				"id":    g.ID,
+				"name":  g.Name,
+				"type":  g.Type,
+				"class": g.Class,
+			}, "%-4s %-32s %s\n", g.ID, g.Name, g.Type)
+		}
+		return nil
+	case "on", "off":
+		if len(args) != 2 {
+			return errUsage
+		}
+		g, err := b.Groups().Get(args[1])
+		if err != nil {
+			return wrap(args[1], err)
+		}
+		if args[0] == "on" {
+			return g.On()
+		}
+		return g.Off()
+	case "scene":
+		if len(args) != 3 {
+			return errUsage
+		}
+		g, err := b.Groups().Get(args[1])
+		if err != nil {
+			return wrap(args[1], err)
+		}
+		sc, err := b.Scenes().Get(args[2])
+		if err == hue.ErrSceneNotExist {
+			sc, err = b.Scenes().GetByID(args[2])
+		}
+		if err != nil {
+			return wrap(args[2], err)
+		}
+		return g.RecallScene(sc.ID)
+	}
+	return errUsage
+}
+
+type lightsByName []*hue.Light
+
+func (l lightsByName) Len() int           { return len(l) }
+func (l lightsByName) Swap(i, j int)      { l[i], l[j] = l[j], l[i] }
+func (l lightsByName) Less(i, j int) bool { return l[i].Name < l[j].Name }
+
+type groupsByName []*hue.Group
+
+func (g groupsByName) Len() int           { return len(g) }
+func (g groupsByName) Swap(i, j int)      { g[i], g[j] = g[j], g[i] }
+func (g groupsByName) Less(i, j int) bool { return g[i].Name < g[j].Name }
+
+type lightsByID []*hue.Light
+
+func (l lightsByID) Len() int      { return len(l) }
+func (l lightsByID) Swap(i, j int) { l[i], l[j] = l[j], l[i] }
+func (l lightsByID) Less(i, j int) bool {
+	if len(l[i].ID) != len(l[j].ID) {
+		return len(l[i].ID) < len(l[j].ID)
+	}
+	return l[i].ID < l[j].ID
+}
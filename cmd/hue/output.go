@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+
+	"gbbr.io/hue"
+)
+
+var (
+	jsonFlag  = flag.Bool("json", false, "")
+	quietFlag = flag.Bool("quiet", false, "")
+)
+
+// Exit statuses of the CLI.
+const (
+	exitFailure     = 1 // any other error
+	exitUsage       = 2 // bad usage
+	exitNotFound    = 3 // a light, group or sensor was not found
+	exitUnreachable = 4 // no bridge was found or it could not be reached
+)
+
+// emit prints v as a single line of JSON when -json is set. Otherwise, it
+// prints the human readable text given by format and args, unless -quiet is
+// set.
+func emit(v interface{}, format string, args ...interface{}) {
+	if *jsonFlag {
+		json.NewEncoder(os.Stdout).Encode(v)
+		return
+	}
+	say(format, args...)
+}
+
+// say prints human readable text, unless -quiet or -json is set.
+func say(format string, args ...interface{}) {
+	if *quietFlag || *jsonFlag {
+		return
+	}
+	fmt.Printf(format, args...)
+}
+
+// subjectError is an error concerning a specific subject, such as a light.
+type subjectError struct {
+	subject string
+	err     error
+}
+
+func (e *subjectError) Error() string { return fmt.Sprintf("%s: %v", e.subject, e.err) }
+
+// wrap returns err annotated with the subject it concerns.
+func wrap(subject string, err error) error { return &subjectError{subject, err} }
+
+// exitCode returns the exit status corresponding to err.
+func exitCode(err error) int {
+	if e, ok := err.(*subjectError); ok {
+		err = e.err
+	}
+	switch err {
+	case errUsage:
+		return exitUsage
+	case hue.ErrNotExist, hue.ErrGroupNotExist, hue.ErrSensorNotExist, hue.ErrSceneNotExist:
+		return exitNotFound
+	case hue.ErrNotFound:
+		return exitUnreachable
+	}
+	if code, ok := err.(exitStatus); ok {
+		return int(code)
+	}
+	if _, ok := err.(*hue.DiscoveryError); ok {
+		return exitUnreachable
+	}
+	if _, ok := err.(net.Error); ok {
+		return exitUnreachable
+	}
+	return exitFailure
+}
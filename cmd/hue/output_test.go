@@ -0,0 +1,28 @@
+package main
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"gbbr.io/hue"
+)
+
+func TestExitCode(t *testing.T) {
+	for _, tt := range []struct {
+		err  error
+		want int
+	}{
+		{errors.New("boom"), exitFailure},
+		{errUsage, exitUsage},
+		{hue.ErrNotExist, exitNotFound},
+		{wrap("Desk", hue.ErrGroupNotExist), exitNotFound},
+		{hue.ErrNotFound, exitUnreachable},
+		{&net.OpError{Op: "dial", Err: errors.New("refused")}, exitUnreachable},
+		{exitStatus(5), 5},
+	} {
+		if got := exitCode(tt.err); got != tt.want {
+			t.Errorf("%v: expected %d, got %d", tt.err, tt.want, got)
+		}
+	}
+}
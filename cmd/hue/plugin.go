@@ -0,0 +1,59 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// pluginPrefix is the prefix of the name of programs which extend the CLI.
+const pluginPrefix = "hue-"
+
+// errNoPlugin is returned when no plugin was found for a command.
+var errNoPlugin = errors.New("no such command")
+
+// runPlugin runs the plugin for the given command with the given arguments.
+// If the plugin exits with a non-zero status, so does the CLI.
+func runPlugin(name string, args []string) error {
+	path, err := exec.LookPath(pluginPrefix + name)
+	if err != nil {
+		return errNoPlugin
+	}
+	cmd := exec.Command(path, args...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	err = cmd.Run()
+	if e, ok := err.(*exec.ExitError); ok {
+		if ws, ok := e.Sys().(syscall.WaitStatus); ok {
+			os.Exit(ws.ExitStatus())
+		}
+	}
+	return err
+}
+
+// pluginNames returns the names of the commands provided by plugins found in
+// PATH.
+func pluginNames() []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		matches, err := filepath.Glob(filepath.Join(dir, pluginPrefix+"*"))
+		if err != nil {
+			continue
+		}
+		for _, m := range matches {
+			name := strings.TrimPrefix(filepath.Base(m), pluginPrefix)
+			if fi, err := os.Stat(m); err != nil || fi.IsDir() || fi.Mode()&0111 == 0 {
+				continue
+			}
+			if _, builtin := commands[name]; builtin || seen[name] {
+				continue
+			}
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names
+}
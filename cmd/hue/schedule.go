@@ -0,0 +1,236 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gbbr.io/hue"
+)
+
+func init() {
+	commands["schedule"] = &command{
+		usage: "run <file>\n" +
+			"\truns the actions in a cron-style file, one per line, as in:\n" +
+			"\t  # minute hour day-of-month month day-of-week action [arguments]\n" +
+			"\t  30 7 * * 1-5 scene \"Living room\" <scene-id>\n" +
+			"\t  0 23 * * * off Bedroom\n" +
+			"\tas in cron, Sunday is day 0 or 7, and when both the day of month and\n" +
+			"\tthe day of week are restricted, either one may match\n" +
+			"\tsupported actions: on <group>, off <group>, scene <group> <scene-id>",
+		run: runSchedule,
+	}
+}
+
+func runSchedule(args []string) error {
+	if len(args) != 2 || args[0] != "run" {
+		return errUsage
+	}
+	f, err := os.Open(args[1])
+	if err != nil {
+		return err
+	}
+	entries, err := parseSchedule(f)
+	f.Close()
+	if err != nil {
+		return err
+	}
+	b, err := bridge()
+	if err != nil {
+		return err
+	}
+	for {
+		now := time.Now()
+		next := now.Truncate(time.Minute).Add(time.Minute)
+		time.Sleep(next.Sub(now))
+		for _, e := range entries {
+			if !e.matches(next) {
+				continue
+			}
+			if err := e.run(b); err != nil {
+				log.Printf("line %d: %v", e.line, err)
+			}
+		}
+	}
+}
+
+// cronEntry is a line of a schedule file.
+type cronEntry struct {
+	// line is the line number in the file.
+	line int
+
+	// fields holds the allowed values of the minute, hour, day of month,
+	// month and day of week, in this order.
+	fields [5]map[int]bool
+
+	// eitherDay is true if both the day of month and the day of week are
+	// restricted, in which case matching either one is enough, as in cron.
+	eitherDay bool
+
+	// action is the name of the action, followed by its arguments.
+	action []string
+}
+
+// cronRanges holds the bounds of each field of a cronEntry. Both 0 and 7 are
+// Sunday in the day of week.
+var cronRanges = [5][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 7}}
+
+// scheduleActions maps actions to the number of arguments that they take.
+var scheduleActions = map[string]int{"on": 1, "off": 1, "scene": 2}
+
+// matches reports whether the entry should run at time t.
+func (e *cronEntry) matches(t time.Time) bool {
+	if !e.fields[0][t.Minute()] || !e.fields[1][t.Hour()] || !e.fields[3][int(t.Month())] {
+		return false
+	}
+	dom, dow := e.fields[2][t.Day()], e.fields[4][int(t.Weekday())]
+	if e.eitherDay {
+		return dom || dow
+	}
+	return dom && dow
+}
+
+// run runs the action of the entry on bridge b.
+func (e *cronEntry) run(b *hue.Bridge) error {
+	g, err := b.Groups().Get(e.action[1])
+	if err != nil {
+		return wrap(e.action[1], err)
+	}
+	switch e.action[0] {
+	case "on":
+		return g.On()
+	case "off":
+		return g.Off()
+	default:
+		return g.RecallScene(e.action[2])
+	}
+}
+
+// parseSchedule parses the entries of a schedule file. Empty lines and lines
+// starting with '#' are ignored.
+func parseSchedule(r io.Reader) ([]*cronEntry, error) {
+	var entries []*cronEntry
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		e, err := parseEntry(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", n, err)
+		}
+		e.line = n
+		entries = append(entries, e)
+	}
+	return entries, sc.Err()
+}
+
+// parseEntry parses a single line of a schedule file.
+func parseEntry(line string) (*cronEntry, error) {
+	words, err := splitWords(line)
+	if err != nil {
+		return nil, err
+	}
+	if len(words) < 6 {
+		return nil, errors.New("expected 5 time fields followed by an action")
+	}
+	e := &cronEntry{action: words[5:]}
+	for i := range e.fields {
+		e.fields[i], err = parseField(words[i], cronRanges[i][0], cronRanges[i][1])
+		if err != nil {
+			return nil, fmt.Errorf("field %q: %v", words[i], err)
+		}
+	}
+	if e.fields[4][7] {
+		e.fields[4][0] = true
+	}
+	e.eitherDay = !strings.HasPrefix(words[2], "*") && !strings.HasPrefix(words[4], "*")
+	n, ok := scheduleActions[e.action[0]]
+	if !ok {
+		return nil, fmt.Errorf("unknown action %q", e.action[0])
+	}
+	if len(e.action)-1 != n {
+		return nil, fmt.Errorf("action %q takes %d argument(s)", e.action[0], n)
+	}
+	return e, nil
+}
+
+// parseField parses a comma separated list of values, ranges ("1-5") and
+// steps ("*/15" or "0-30/10") between min and max.
+func parseField(s string, min, max int) (map[int]bool, error) {
+	set := make(map[int]bool)
+	for _, part := range strings.Split(s, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			var err error
+			step, err = strconv.Atoi(part[i+1:])
+			if err != nil || step < 1 {
+				return nil, errors.New("bad step")
+			}
+			part = part[:i]
+		}
+		lo, hi := min, max
+		if part != "*" {
+			bounds := strings.SplitN(part, "-", 2)
+			var err error
+			lo, err = strconv.Atoi(bounds[0])
+			if err != nil {
+				return nil, errors.New("bad value")
+			}
+			hi = lo
+			if len(bounds) == 2 {
+				hi, err = strconv.Atoi(bounds[1])
+				if err != nil {
+					return nil, errors.New("bad value")
+				}
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return nil, fmt.Errorf("out of range %d-%d", min, max)
+		}
+		for v := lo; v <= hi; v += step {
+			set[v] = true
+		}
+	}
+	return set, nil
+}
+
+// splitWords splits s into words separated by spaces. Words containing spaces
+// may be enclosed in double quotes.
+func splitWords(s string) ([]string, error) {
+	var (
+		words  []string
+		word   []rune
+		quoted bool
+		inWord bool
+	)
+	for _, r := range s {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			inWord = true
+		case (r == ' ' || r == '\t') && !quoted:
+			if inWord {
+				words = append(words, string(word))
+				word, inWord = word[:0], false
+			}
+		default:
+			word = append(word, r)
+			inWord = true
+		}
+	}
+	if quoted {
+		return nil, errors.New("unterminated quote")
+	}
+	if inWord {
+		words = append(words, string(word))
+	}
+	return words, nil
+}
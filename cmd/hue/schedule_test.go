@@ -0,0 +1,60 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseSchedule(t *testing.T) {
+	entries, err := parseSchedule(strings.NewReader(`
+# comment
+30 7 * * 1-5 scene "Living room" abc
+*/15 22-23 1,15 * * off Bedroom
+0 7 1 * 7 on Kitchen
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(entries))
+	}
+	if want := []string{"scene", "Living room", "abc"}; !reflect.DeepEqual(entries[0].action, want) {
+		t.Fatalf("expected %v, got %v", want, entries[0].action)
+	}
+	for _, tt := range []struct {
+		entry int
+		time  time.Time
+		want  bool
+	}{
+		{0, time.Date(2016, 10, 17, 7, 30, 0, 0, time.Local), true},  // Monday
+		{0, time.Date(2016, 10, 16, 7, 30, 0, 0, time.Local), false}, // Sunday
+		{0, time.Date(2016, 10, 17, 7, 31, 0, 0, time.Local), false},
+		{1, time.Date(2016, 10, 15, 22, 45, 0, 0, time.Local), true},
+		{1, time.Date(2016, 10, 15, 22, 40, 0, 0, time.Local), false},
+		{1, time.Date(2016, 10, 16, 22, 45, 0, 0, time.Local), false},
+		{2, time.Date(2016, 10, 16, 7, 0, 0, 0, time.Local), true}, // Sunday
+		{2, time.Date(2016, 11, 1, 7, 0, 0, 0, time.Local), true},  // Tuesday the 1st
+		{2, time.Date(2016, 10, 17, 7, 0, 0, 0, time.Local), false},
+	} {
+		if got := entries[tt.entry].matches(tt.time); got != tt.want {
+			t.Errorf("entry %d at %v: expected %v, got %v", tt.entry, tt.time, tt.want, got)
+		}
+	}
+}
+
+func TestParseScheduleErrors(t *testing.T) {
+	for _, line := range []string{
+		"30 7 * * off Bedroom",
+		"60 7 * * * off Bedroom",
+		"0 7 * * 8 off Bedroom",
+		"30 7 * * * dance Bedroom",
+		"30 7 * * * scene Bedroom",
+		`30 7 * * * off "Bedroom`,
+	} {
+		if _, err := parseSchedule(strings.NewReader(line)); err == nil {
+			t.Errorf("%q: expected error", line)
+		}
+	}
+}
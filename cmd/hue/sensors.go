@@ -0,0 +1,122 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"sort"
+
+	"gbbr.io/hue"
+)
+
+func init() {
+	commands["sensors"] = &command{
+		usage: "list\n" +
+			"\tlists all sensors along with their readings",
+		run: runSensors,
+	}
+	commands["batteries"] = &command{
+		usage: "[-warn-below <percent>]\n" +
+			"\tlists the battery levels of all battery powered devices. If any is\n" +
+			"\tbelow the given percentage, the exit status is 1",
+		run: runBatteries,
+	}
+}
+
+func runSensors(args []string) error {
+	if len(args) != 1 || args[0] != "list" {
+		return errUsage
+	}
+	b, err := bridge()
+	if err != nil {
+		return err
+	}
+	list, err := b.Sensors().List()
+	if err != nil {
+		return err
+	}
+	sort.Sort(sensorsByID(list))
+	for _, s := range list {
+		emit(map[string]interface{}{
+			"id":      s.ID,
+			"name":    s.Name,
+			"type":    s.Type,
+			"reading": reading(s),
+			"state":   s.State,
+		}, "%-4s %-32s %-16s %s\n", s.ID, s.Name, s.Type, reading(s))
+	}
+	return nil
+}
+
+// reading returns a description of the current reading of sensor s.
+func reading(s *hue.Sensor) string {
+	switch s.Type {
+	case "ZLLPresence", "CLIPPresence":
+		if s.State.Presence {
+			return "motion"
+		}
+		return "no motion"
+	case "ZLLTemperature", "CLIPTemperature":
+		return fmt.Sprintf("%.2f°C", s.State.Celsius())
+	case "ZLLLightLevel", "CLIPLightLevel":
+		r := fmt.Sprintf("%.0f lux", s.State.Lux())
+		if s.State.Dark {
+			r += " (dark)"
+		}
+		return r
+	case "ZLLSwitch", "ZGPSwitch", "CLIPSwitch":
+		return fmt.Sprintf("button event %d", s.State.ButtonEvent)
+	}
+	return ""
+}
+
+func runBatteries(args []string) error {
+	fs := flag.NewFlagSet("batteries", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	warnBelow := fs.Int("warn-below", 0, "")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 0 {
+		return errUsage
+	}
+	b, err := bridge()
+	if err != nil {
+		return err
+	}
+	list, err := b.Sensors().List()
+	if err != nil {
+		return err
+	}
+	sort.Sort(sensorsByID(list))
+	var low bool
+	for _, s := range list {
+		if s.Config.Battery == nil {
+			continue
+		}
+		level := int(*s.Config.Battery)
+		warn := ""
+		if level < *warnBelow {
+			warn = " LOW"
+			low = true
+		}
+		emit(map[string]interface{}{
+			"id":      s.ID,
+			"name":    s.Name,
+			"battery": level,
+			"low":     warn != "",
+		}, "%-4s %-32s %3d%%%s\n", s.ID, s.Name, level, warn)
+	}
+	if low {
+		return exitStatus(1)
+	}
+	return nil
+}
+
+type sensorsByID []*hue.Sensor
+
+func (s sensorsByID) Len() int      { return len(s) }
+func (s sensorsByID) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s sensorsByID) Less(i, j int) bool {
+	if len(s[i].ID) != len(s[j].ID) {
+		return len(s[i].ID) < len(s[j].ID)
+	}
+	return s[i].ID < s[j].ID
+}
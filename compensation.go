@@ -0,0 +1,84 @@
+package hue
+
+import "math"
+
+// Calibration adjusts a color and brightness requested for a light of the
+// given model, so that lights of different models render them alike. It is
+// set using WithColorCompensation.
+type Calibration func(modelID string, xy XY, bri uint8) (XY, uint8)
+
+// Compensation is the adjustment applied to the colors sent to a model of
+// light.
+type Compensation struct {
+	// Offset is added to the requested coordinates.
+	Offset XY
+
+	// Brightness scales the requested brightness. Zero is taken as 1.
+	Brightness float64
+}
+
+// CompensationTable maps model IDs to the compensation applied to the lights
+// of that model. Models which are not listed are not adjusted.
+type CompensationTable map[string]Compensation
+
+// DefaultCompensation holds rough adjustments which bring older lights closer
+// to the rendering of current bulbs with gamut C: first generation bulbs render
+// colors greener, lightstrips and LivingColors brighter and redder. For
+// accurate results, measure the lights and supply a table of your own.
+var DefaultCompensation = CompensationTable{
+	"LCT001": {Offset: XY{0.005, -0.01}},
+	"LCT002": {Offset: XY{0.005, -0.01}},
+	"LCT003": {Offset: XY{0.005, -0.01}},
+	"LST001": {Offset: XY{-0.01, 0}, Brightness: 0.85},
+	"LLC010": {Offset: XY{-0.01, 0}, Brightness: 0.9},
+	"LLC011": {Offset: XY{-0.01, 0}, Brightness: 0.9},
+	"LLC012": {Offset: XY{-0.01, 0}, Brightness: 0.9},
+}
+
+// Calibrate applies the compensation of the model to the color xy and the
+// brightness bri, which is left as is if it is zero (unset). It is a
+// Calibration.
+func (t CompensationTable) Calibrate(modelID string, xy XY, bri uint8) (XY, uint8) {
+	c, ok := t[modelID]
+	if !ok {
+		return xy, bri
+	}
+	xy = XY{clamp01(xy[0] + c.Offset[0]), clamp01(xy[1] + c.Offset[1])}
+	if bri != 0 && c.Brightness != 0 {
+		bri = uint8(math.Max(1, math.Min(254, math.Round(float64(bri)*c.Brightness))))
+	}
+	return xy, bri
+}
+
+// clamp01 returns v limited to [0, 1].
+func clamp01(v float64) float64 { return math.Max(0, math.Min(1, v)) }
+
+// compensate returns the state to send to light l in place of s, adjusted by
+// the calibration of the bridge, if any. Only states setting a color using
+// xy coordinates or a brightness are adjusted; s itself is not modified.
+func (l *Light) compensate(s *State) *State {
+	cal := l.bridge.calibrate
+	if cal == nil || (s.XY == nil && s.Brightness == 0) {
+		return s
+	}
+	adjusted := *s
+	var xy XY
+	if s.XY != nil {
+		xy = *s.XY
+	}
+	xy, adjusted.Brightness = cal(l.ModelID, xy, s.Brightness)
+	if s.XY != nil {
+		adjusted.XY = &xy
+	}
+	return &adjusted
+}
+
+// SetUniform is like Set, but sends the state to each light of the group
+// separately, so that the colors of lights of different models are adjusted
+// by the calibration set using WithColorCompensation and the group looks
+// uniform. It takes one command per light instead of one for the group. All
+// lights are attempted; if some fail, a *LightsError identifying them is
+// returned.
+func (g *Group) SetUniform(s *State) error {
+	return g.Members().forAll(func(l *Light) error { return l.Set(s) })
+}
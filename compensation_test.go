@@ -0,0 +1,78 @@
+package hue_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"gbbr.io/hue"
+	"gbbr.io/hue/huetest"
+)
+
+func TestCalibrate(t *testing.T) {
+	xy, bri := hue.DefaultCompensation.Calibrate("LST001", hue.XY{0.3, 0.3}, 200)
+	if math.Abs(xy[0]-0.29) > 1e-9 || xy[1] != 0.3 || bri != 170 {
+		t.Fatalf("unexpected compensation %v, %d", xy, bri)
+	}
+	if xy, bri := hue.DefaultCompensation.Calibrate("LCT015", hue.XY{0.3, 0.3}, 200); xy != (hue.XY{0.3, 0.3}) || bri != 200 {
+		t.Fatalf("expected no compensation, got %v, %d", xy, bri)
+	}
+	if xy, bri := hue.DefaultCompensation.Calibrate("LST001", hue.XY{0.005, 0.3}, 0); xy[0] != 0 || bri != 0 {
+		t.Fatalf("expected clamped coordinates and no brightness, got %v, %d", xy, bri)
+	}
+}
+
+func TestSetUniform(t *testing.T) {
+	sim := huetest.NewSimulator(time.Date(2017, 1, 1, 20, 0, 0, 0, time.UTC), "a", "b")
+	defer sim.Close()
+	table := hue.CompensationTable{"LCT015": {Offset: hue.XY{0.1, 0}, Brightness: 0.5}}
+	b := sim.Bridge(hue.WithColorCompensation(table.Calibrate))
+	g, err := b.Groups().GetByID(sim.AddGroup("Living", "1", "2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &hue.State{On: true, Brightness: 200, XY: hue.XYPtr(0.3, 0.3)}
+	if err := g.SetUniform(s); err != nil {
+		t.Fatal(err)
+	}
+	if *s.XY != (hue.XY{0.3, 0.3}) || s.Brightness != 200 {
+		t.Fatalf("expected the state to be left as is, got %+v", s)
+	}
+	lights, err := hue.LightsByID(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for id, l := range lights {
+		if st := l.State; math.Abs(st.XY[0]-0.4) > 1e-9 || st.Brightness != 100 {
+			t.Fatalf("light %s: unexpected state %+v", id, st)
+		}
+	}
+}
+
+func TestSetVerifiedCompensated(t *testing.T) {
+	defer hue.SetVerifyDelay(time.Millisecond)()
+	sim := huetest.NewSimulator(time.Date(2017, 1, 1, 20, 0, 0, 0, time.UTC), "a", "b")
+	defer sim.Close()
+	table := hue.CompensationTable{"LCT015": {Brightness: 0.85}}
+	b := sim.Bridge(hue.WithColorCompensation(table.Calibrate))
+	l, err := b.Lights().Get("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &hue.State{On: true, Brightness: 200}
+	if err := l.SetVerified(s, 0); err != nil {
+		t.Fatal(err)
+	}
+	if l, err = b.Lights().Get("a"); err != nil {
+		t.Fatal(err)
+	} else if l.State.Brightness != 170 {
+		t.Fatalf("expected the compensated brightness, got %d", l.State.Brightness)
+	}
+	g, err := b.Groups().GetByID(sim.AddGroup("Living", "1", "2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := g.SetVerified(s, 0); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,112 @@
+package hue
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// ErrInvalidTimeZone is returned when attempting to set a timezone which is
+// not supported by the bridge.
+var ErrInvalidTimeZone = errors.New("timezone is not supported by the bridge")
+
+// Config holds the configuration of the bridge. For more information see:
+// http://www.developers.meethue.com/documentation/configuration-api#72_get_configuration
+type Config struct {
+	// Name is the name of the bridge.
+	Name string `json:"name"`
+
+	// BridgeID is the unique identifier of the bridge.
+	BridgeID string `json:"bridgeid"`
+
+	// ModelID is the hardware model of the bridge (e.g. "BSB002").
+	ModelID string `json:"modelid"`
+
+	// SWVersion is the software version of the bridge.
+	SWVersion string `json:"swversion"`
+
+	// APIVersion is the version of the API that the bridge implements.
+	APIVersion string `json:"apiversion"`
+
+	// UTC is the current time of the bridge, in UTC. Use the Time method to
+	// obtain it as a time.Time.
+	UTC string `json:"UTC"`
+
+	// LocalTime is the current time of the bridge, in its timezone.
+	LocalTime string `json:"localtime"`
+
+	// TimeZone is the timezone of the bridge, as an IANA name (e.g.
+	// "Europe/Amsterdam").
+	TimeZone string `json:"timezone"`
+}
+
+// Time returns the time of the bridge at the moment the configuration was
+// retrieved.
+func (c *Config) Time() (time.Time, error) {
+	return time.ParseInLocation(timeLayout, c.UTC, time.UTC)
+}
+
+// Config returns the configuration of the bridge.
+func (b *Bridge) Config() (*Config, error) {
+	msg, err := b.call(http.MethodGet, nil, "config")
+	if err != nil {
+		return nil, err
+	}
+	var c Config
+	if err := b.decode(msg, &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// ClockSkew returns the difference between the clock of the bridge and the
+// clock of the host. A positive value means that the bridge is ahead. Since
+// the bridge reports time in seconds, the result is accurate to about a
+// second.
+func (b *Bridge) ClockSkew() (time.Duration, error) {
+	before := time.Now()
+	c, err := b.Config()
+	if err != nil {
+		return 0, err
+	}
+	after := time.Now()
+	t, err := c.Time()
+	if err != nil {
+		return 0, err
+	}
+	return t.Sub(before.Add(after.Sub(before) / 2)), nil
+}
+
+// TimeZones returns the list of timezones supported by the bridge.
+func (b *Bridge) TimeZones() ([]string, error) {
+	msg, err := b.call(http.MethodGet, nil, "capabilities", "timezones")
+	if err != nil {
+		return nil, err
+	}
+	var tz struct {
+		Values []string `json:"values"`
+	}
+	if err := json.Unmarshal(msg, &tz); err != nil {
+		return nil, err
+	}
+	return tz.Values, nil
+}
+
+// SetTimeZone sets the timezone of the bridge. The timezone must be one of the
+// values returned by TimeZones, otherwise ErrInvalidTimeZone is returned.
+func (b *Bridge) SetTimeZone(tz string) error {
+	all, err := b.TimeZones()
+	if err != nil {
+		return err
+	}
+	for _, v := range all {
+		if v == tz {
+			_, err := b.call(http.MethodPut, map[string]string{
+				"timezone": tz,
+			}, "config")
+			return err
+		}
+	}
+	return ErrInvalidTimeZone
+}
@@ -0,0 +1,49 @@
+package hue
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestConfig(t *testing.T) {
+	mb := mockBridge(t)
+	defer mb.teardown()
+
+	t.Run("ClockSkew", func(t *testing.T) {
+		mb.nextResponse = Config{
+			UTC: time.Now().UTC().Add(time.Hour).Format(timeLayout),
+		}
+		skew, err := mb.b.ClockSkew()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if skew < 59*time.Minute || skew > 61*time.Minute {
+			t.Fatalf("expected a skew of about an hour, got %v", skew)
+		}
+	})
+
+	t.Run("SetTimeZone", func(t *testing.T) {
+		mb.nextResponse = map[string][]string{
+			"values": {"Europe/Amsterdam", "Europe/London"},
+		}
+		if err := mb.b.SetTimeZone("Mars/Olympus"); err != ErrInvalidTimeZone {
+			t.Fatalf("expected ErrInvalidTimeZone, got %v", err)
+		}
+		if err := mb.b.SetTimeZone("Europe/London"); err != nil {
+			t.Fatal(err)
+		}
+		if mb.lastMethod != http.MethodPut || mb.lastPath != "/api/bridge_username/config" {
+			t.Fatalf("unexpected request %s %s", mb.lastMethod, mb.lastPath)
+		}
+		var got map[string]string
+		if err := json.NewDecoder(mb.lastBody).Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+		if want := map[string]string{"timezone": "Europe/London"}; !reflect.DeepEqual(got, want) {
+			t.Fatalf("expected %v, got %v", want, got)
+		}
+	})
+}
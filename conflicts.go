@@ -0,0 +1,136 @@
+package hue
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Automation describes something outside the bridge which controls lights,
+// such as a Follower or a program reacting to sensors, for the purpose of
+// finding conflicts with the rules and schedules on the bridge.
+type Automation struct {
+	// Name identifies the automation in reported conflicts.
+	Name string
+
+	// Targets holds the resources that the automation changes, as
+	// addresses such as "/groups/1" or "/lights/3".
+	Targets []string
+
+	// Triggers holds what the automation reacts to: the addresses of sensor
+	// attributes (e.g. "/sensors/2/state/presence"), or times in the format
+	// of Schedule.LocalTime, prefixed with "localtime:" (e.g.
+	// "localtime:W124/T07:30:00").
+	Triggers []string
+}
+
+// Conflict is an automation which controls the same resource as a rule or
+// schedule on the bridge, in response to the same trigger.
+type Conflict struct {
+	// Automation is the name of the automation.
+	Automation string
+
+	// Rule is the conflicting rule, if any.
+	Rule *Rule
+
+	// Schedule is the conflicting schedule, if any.
+	Schedule *Schedule
+
+	// Target is the address of the resource controlled by both.
+	Target string
+
+	// Trigger is the trigger shared by both.
+	Trigger string
+}
+
+func (c Conflict) String() string {
+	other := ""
+	switch {
+	case c.Rule != nil:
+		other = fmt.Sprintf("rule %s (%q)", c.Rule.ID, c.Rule.Name)
+	case c.Schedule != nil:
+		other = fmt.Sprintf("schedule %s (%q)", c.Schedule.ID, c.Schedule.Name)
+	}
+	return fmt.Sprintf("%s and %s both control %s on %s", c.Automation, other, c.Target, c.Trigger)
+}
+
+// Conflicts cross-references the given automations with the rules and
+// schedules on the bridge and returns those which control the same resource
+// in response to the same trigger. Conditions on the time of day are not
+// evaluated, so some of the conflicts may never occur in practice.
+func (b *Bridge) Conflicts(automations ...Automation) ([]Conflict, error) {
+	rules, err := b.Rules().List()
+	if err != nil {
+		return nil, err
+	}
+	schedules, err := b.Schedules().List()
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(rules, func(i, j int) bool { return lessID(rules[i].ID, rules[j].ID) })
+	sort.Slice(schedules, func(i, j int) bool { return lessID(schedules[i].ID, schedules[j].ID) })
+	var list []Conflict
+	for _, a := range automations {
+		for _, r := range rules {
+			var targets, triggers []string
+			for _, act := range r.Actions {
+				targets = append(targets, resourceAddr(act.Address))
+			}
+			for _, c := range r.Conditions {
+				triggers = append(triggers, c.Address)
+			}
+			target, trigger := overlap(a, targets, triggers)
+			if target != "" {
+				list = append(list, Conflict{Automation: a.Name, Rule: r, Target: target, Trigger: trigger})
+			}
+		}
+		for _, s := range schedules {
+			targets := []string{resourceAddr(s.Command.Address)}
+			triggers := []string{"localtime:" + s.LocalTime}
+			target, trigger := overlap(a, targets, triggers)
+			if target != "" {
+				list = append(list, Conflict{Automation: a.Name, Schedule: s, Target: target, Trigger: trigger})
+			}
+		}
+	}
+	return list, nil
+}
+
+// overlap returns a target and trigger shared by automation a and the given
+// targets and triggers, or empty strings if there are none.
+func overlap(a Automation, targets, triggers []string) (target, trigger string) {
+	for _, t := range a.Targets {
+		if !contains(targets, resourceAddr(t)) {
+			continue
+		}
+		for _, tr := range a.Triggers {
+			if contains(triggers, tr) {
+				return resourceAddr(t), tr
+			}
+		}
+	}
+	return "", ""
+}
+
+// resourceAddr returns the address of the resource that the API address addr
+// refers to, without the "/api/<username>" prefix. For example, both
+// "/api/user/groups/1/action" and "/groups/1/action" result in "/groups/1".
+func resourceAddr(addr string) string {
+	parts := strings.Split(strings.Trim(addr, "/"), "/")
+	if len(parts) > 2 && parts[0] == "api" {
+		parts = parts[2:]
+	}
+	if len(parts) > 2 {
+		parts = parts[:2]
+	}
+	return "/" + strings.Join(parts, "/")
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,45 @@
+package hue
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestConflicts(t *testing.T) {
+	mb := mockBridge(t)
+	defer mb.teardown()
+	mb.responses = map[string]interface{}{
+		"/api/bridge_username/rules": json.RawMessage(`{
+			"1": {"name": "hall motion", "conditions": [{"address": "/sensors/2/state/presence", "operator": "dx"}],
+				"actions": [{"address": "/groups/1/action", "method": "PUT", "body": {"on": true}}]},
+			"2": {"name": "kitchen motion", "conditions": [{"address": "/sensors/2/state/presence", "operator": "dx"}],
+				"actions": [{"address": "/groups/2/action", "method": "PUT", "body": {"on": true}}]}}`),
+		"/api/bridge_username/schedules": json.RawMessage(`{
+			"5": {"name": "wake", "localtime": "W124/T07:00:00",
+				"command": {"address": "/api/bridge_username/groups/1/action", "method": "PUT", "body": {"on": true}}}}`),
+	}
+	list, err := mb.b.Conflicts(
+		Automation{
+			Name:     "presence",
+			Targets:  []string{"/groups/1"},
+			Triggers: []string{"/sensors/2/state/presence", "localtime:W124/T07:00:00"},
+		},
+		Automation{
+			Name:     "unrelated",
+			Targets:  []string{"/groups/3"},
+			Triggers: []string{"/sensors/2/state/presence"},
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 {
+		t.Fatalf("expected 2 conflicts, got %v", list)
+	}
+	if list[0].Rule == nil || list[0].Rule.ID != "1" || list[0].Target != "/groups/1" {
+		t.Fatalf("unexpected conflict %v", list[0])
+	}
+	if want := `presence and schedule 5 ("wake") both control /groups/1 on localtime:W124/T07:00:00`; list[1].String() != want {
+		t.Fatalf("expected %q, got %q", want, list[1].String())
+	}
+}
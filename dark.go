@@ -0,0 +1,48 @@
+package hue
+
+import "net/http"
+
+// IsDark reports whether the light level measured by the given light level
+// sensor is below its dark threshold. The state of the sensor is refreshed
+// beforehand.
+func IsDark(lightLevel *Sensor) (bool, error) {
+	if err := lightLevel.refresh(); err != nil {
+		return false, err
+	}
+	return lightLevel.State.Dark, nil
+}
+
+// SetIfDark sets the state s on group g only if the given light level sensor
+// reports that it is dark. It reports whether the state was set. It is an
+// automated change, so it returns ErrQuietHours during the quiet hours of the
+// group.
+func (g *Group) SetIfDark(lightLevel *Sensor, s *State) (bool, error) {
+	dark, err := IsDark(lightLevel)
+	if err != nil || !dark {
+		return false, err
+	}
+	if err := g.SetAutomated(s); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// MotionWhenDarkRule returns a rule which sets state s on group g when motion
+// is detected by the given presence sensor, but only if the given light level
+// sensor reports that it is dark. The rule is not created on the bridge; use
+// RulesService.Create for that.
+func MotionWhenDarkRule(name string, presence, lightLevel *Sensor, g *Group, s *State) *Rule {
+	return &Rule{
+		Name: name,
+		Conditions: []Condition{
+			{Address: "/sensors/" + presence.ID + "/state/presence", Operator: OpEq, Value: "true"},
+			{Address: "/sensors/" + presence.ID + "/state/presence", Operator: OpDx},
+			{Address: "/sensors/" + lightLevel.ID + "/state/dark", Operator: OpEq, Value: "true"},
+		},
+		Actions: []Command{{
+			Address: "/groups/" + g.ID + "/action",
+			Method:  http.MethodPut,
+			Body:    s,
+		}},
+	}
+}
@@ -0,0 +1,49 @@
+package hue
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestSetIfDark(t *testing.T) {
+	mb := mockBridge(t)
+	defer mb.teardown()
+	g := &Group{bridge: mb.b, ID: "1"}
+	ll := &Sensor{bridge: mb.b, ID: "3"}
+	for _, dark := range []bool{false, true} {
+		mb.lastMethod = ""
+		mb.responses = map[string]interface{}{
+			"/api/bridge_username/sensors/3": &Sensor{State: SensorState{Dark: dark}},
+		}
+		ok, err := g.SetIfDark(ll, &State{On: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok != dark {
+			t.Fatalf("dark=%v: expected %v, got %v", dark, dark, ok)
+		}
+		if dark && mb.lastPath != "/api/bridge_username/groups/1" {
+			t.Fatalf("expected group to be set, last path was %s", mb.lastPath)
+		}
+		if !dark && mb.lastPath != "/api/bridge_username/sensors/3" {
+			t.Fatalf("expected group not to be set, last path was %s", mb.lastPath)
+		}
+	}
+}
+
+func TestMotionWhenDarkRule(t *testing.T) {
+	r := MotionWhenDarkRule("hall", &Sensor{ID: "4"}, &Sensor{ID: "5"}, &Group{ID: "2"}, &State{On: true})
+	want := &Rule{
+		Name: "hall",
+		Conditions: []Condition{
+			{Address: "/sensors/4/state/presence", Operator: OpEq, Value: "true"},
+			{Address: "/sensors/4/state/presence", Operator: OpDx},
+			{Address: "/sensors/5/state/dark", Operator: OpEq, Value: "true"},
+		},
+		Actions: []Command{{Address: "/groups/2/action", Method: http.MethodPut, Body: &State{On: true}}},
+	}
+	if !reflect.DeepEqual(r, want) {
+		t.Fatalf("expected %+v, got %+v", want, r)
+	}
+}
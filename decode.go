@@ -0,0 +1,71 @@
+package hue
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"sync"
+)
+
+// maxWarnings is the maximum number of warnings kept by a bridge.
+const maxWarnings = 100
+
+// warnings collects the problems encountered while decoding responses in
+// lenient mode.
+type warnings struct {
+	mu   sync.Mutex
+	list []error
+}
+
+// add records err, unless an identical warning was already recorded. Only the
+// most recent maxWarnings warnings are kept.
+func (w *warnings) add(err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, e := range w.list {
+		if e.Error() == err.Error() {
+			return
+		}
+	}
+	w.list = append(w.list, err)
+	if len(w.list) > maxWarnings {
+		w.list = w.list[len(w.list)-maxWarnings:]
+	}
+}
+
+// Warnings returns the problems encountered while decoding responses from the
+// bridge, such as unknown fields or fields with an unexpected type. These
+// typically occur after the bridge firmware is updated and are ignored,
+// unless the Strict option is used.
+func (b *Bridge) Warnings() []error {
+	if b.warnings == nil {
+		return nil
+	}
+	b.warnings.mu.Lock()
+	defer b.warnings.mu.Unlock()
+	return append([]error(nil), b.warnings.list...)
+}
+
+// decode decodes the response data into v. In strict mode, unknown fields and
+// fields of an unexpected type result in an error. Otherwise, they are
+// recorded as warnings and the remaining fields are decoded.
+func (b Bridge) decode(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	err := dec.Decode(v)
+	if err == nil || b.strict {
+		return err
+	}
+	_, typeErr := err.(*json.UnmarshalTypeError)
+	if !typeErr && !strings.HasPrefix(err.Error(), "json: unknown field") {
+		return err
+	}
+	if b.warnings != nil {
+		b.warnings.add(err)
+	}
+	err = json.Unmarshal(data, v)
+	if _, ok := err.(*json.UnmarshalTypeError); ok {
+		return nil
+	}
+	return err
+}
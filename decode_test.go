@@ -0,0 +1,47 @@
+package hue
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestDecode(t *testing.T) {
+	// a newer firmware added a field and changed the type of another
+	data := []byte(`{"name": "l1", "brandnew": true, "modelid": 7, "type": "Extended color light"}`)
+
+	t.Run("lenient", func(t *testing.T) {
+		b := &Bridge{warnings: new(warnings)}
+		var l Light
+		if err := b.decode(data, &l); err != nil {
+			t.Fatal(err)
+		}
+		if l.Name != "l1" || l.Type != "Extended color light" {
+			t.Fatalf("expected known fields to be decoded, got %+v", l)
+		}
+		if err := b.decode(data, &l); err != nil {
+			t.Fatal(err)
+		}
+		if w := b.Warnings(); len(w) != 1 {
+			t.Fatalf("expected a single warning, got %v", w)
+		}
+	})
+
+	t.Run("strict", func(t *testing.T) {
+		b := &Bridge{strict: true, warnings: new(warnings)}
+		var l Light
+		if err := b.decode(data, &l); err == nil {
+			t.Fatal("expected error")
+		}
+		if err := b.decode([]byte(`{"name": "l1"}`), &l); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("syntax", func(t *testing.T) {
+		b := &Bridge{}
+		var l Light
+		if _, ok := b.decode([]byte(`{"name" 1}`), &l).(*json.SyntaxError); !ok {
+			t.Fatal("expected syntax error")
+		}
+	})
+}
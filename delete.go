@@ -0,0 +1,162 @@
+package hue
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// References holds the resources on the bridge which refer to another
+// resource, such as the rules that are triggered by a sensor or the schedules
+// that recall a scene.
+type References struct {
+	// Rules holds the rules which refer to the resource in a condition or
+	// action.
+	Rules []*Rule
+
+	// Schedules holds the schedules whose command refers to the resource.
+	Schedules []*Schedule
+
+	// ResourceLinks holds the IDs of the resource links which include the
+	// resource.
+	ResourceLinks []string
+
+	bridge *Bridge
+
+	// link is the address of the resource, as used in resource links (e.g.
+	// "/scenes/ab12").
+	link string
+
+	// links maps the IDs in ResourceLinks to the links they contain.
+	links map[string][]string
+}
+
+// References returns the rules, schedules and resource links which refer to
+// the resource with the given kind and ID, for example ("sensors", "2") or
+// ("scenes", "ab12"). Rules and schedules refer to a resource when it is part
+// of the address of one of their conditions or commands, or in the case of
+// scenes, when a command recalls it. Resource links are not searched on
+// bridges which do not support them.
+func (b *Bridge) References(kind, id string) (*References, error) {
+	refs := &References{bridge: b, link: "/" + kind + "/" + id}
+	rules, err := b.Rules().List()
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range rules {
+		var match bool
+		for _, c := range r.Conditions {
+			match = match || refersTo(c.Address, nil, kind, id)
+		}
+		for _, a := range r.Actions {
+			match = match || refersTo(a.Address, a.Body, kind, id)
+		}
+		if match {
+			refs.Rules = append(refs.Rules, r)
+		}
+	}
+	schedules, err := b.Schedules().List()
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range schedules {
+		if refersTo(s.Command.Address, s.Command.Body, kind, id) {
+			refs.Schedules = append(refs.Schedules, s)
+		}
+	}
+	msg, err := b.call(http.MethodGet, nil, "resourcelinks")
+	if _, ok := err.(*UnsupportedError); ok {
+		return refs, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var links map[string]struct {
+		Links []string `json:"links"`
+	}
+	if err := b.decode(msg, &links); err != nil {
+		return nil, err
+	}
+	refs.links = make(map[string][]string)
+	for lid, l := range links {
+		for _, addr := range l.Links {
+			if addr == refs.link {
+				refs.ResourceLinks = append(refs.ResourceLinks, lid)
+				refs.links[lid] = l.Links
+				break
+			}
+		}
+	}
+	return refs, nil
+}
+
+// Delete deletes the referring rules and schedules, and removes the resource
+// from the referring resource links. Resource links which are left empty are
+// deleted.
+func (r *References) Delete() error {
+	for _, rule := range r.Rules {
+		if err := rule.Delete(false); err != nil {
+			return err
+		}
+	}
+	for _, s := range r.Schedules {
+		if err := s.Delete(false); err != nil {
+			return err
+		}
+	}
+	for _, lid := range r.ResourceLinks {
+		var rest []string
+		for _, addr := range r.links[lid] {
+			if addr != r.link {
+				rest = append(rest, addr)
+			}
+		}
+		var err error
+		if len(rest) == 0 {
+			_, err = r.bridge.call(http.MethodDelete, nil, "resourcelinks", lid)
+		} else {
+			_, err = r.bridge.call(http.MethodPut, map[string][]string{
+				"links": rest,
+			}, "resourcelinks", lid)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteResource deletes the resource with the given kind and ID. If cascade
+// is true, the resources which refer to it are deleted first.
+func (b *Bridge) deleteResource(cascade bool, kind, id string) error {
+	if cascade {
+		refs, err := b.References(kind, id)
+		if err != nil {
+			return err
+		}
+		if err := refs.Delete(); err != nil {
+			return err
+		}
+	}
+	_, err := b.call(http.MethodDelete, nil, kind, id)
+	return err
+}
+
+// refersTo reports whether the API address addr, or the command body sent to
+// it, refers to the resource with the given kind and ID. Addresses may be
+// given with or without the "/api/<username>" prefix.
+func refersTo(addr string, body interface{}, kind, id string) bool {
+	if resourceAddr(addr) == "/"+kind+"/"+id {
+		return true
+	}
+	if kind != "scenes" || body == nil {
+		return false
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return false
+	}
+	var v struct {
+		Scene string `json:"scene"`
+	}
+	return json.Unmarshal(data, &v) == nil && v.Scene == id
+}
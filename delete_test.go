@@ -0,0 +1,90 @@
+package hue
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestDeleteCascade(t *testing.T) {
+	responses := map[string]string{
+		"GET /api/user/rules": `{
+			"1": {"name": "motion", "conditions": [{"address": "/sensors/2/state/presence", "operator": "dx"}],
+				"actions": [{"address": "/groups/1/action", "method": "PUT", "body": {"scene": "ab12"}}]},
+			"2": {"name": "other", "conditions": [{"address": "/sensors/3/state/presence", "operator": "dx"}],
+				"actions": [{"address": "/groups/1/action", "method": "PUT", "body": {"on": true}}]}}`,
+		"GET /api/user/schedules": `{
+			"5": {"name": "wake", "localtime": "W124/T07:00:00",
+				"command": {"address": "/api/user/groups/0/action", "method": "PUT", "body": {"scene": "ab12"}}},
+			"6": {"name": "sleep", "localtime": "W124/T23:00:00",
+				"command": {"address": "/api/user/groups/0/action", "method": "PUT", "body": {"on": false}}}}`,
+		"GET /api/user/resourcelinks": `{
+			"7": {"links": ["/scenes/ab12", "/rules/1"]},
+			"8": {"links": ["/scenes/ab12"]},
+			"9": {"links": ["/scenes/cd34"]}}`,
+	}
+	var (
+		requests []string
+		bodies   = make(map[string]string)
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := r.Method + " " + r.URL.Path
+		if resp, ok := responses[req]; ok {
+			w.Write([]byte(resp))
+			return
+		}
+		requests = append(requests, req)
+		body, _ := ioutil.ReadAll(r.Body)
+		bodies[req] = string(body)
+		w.Write([]byte(`[{"success": "ok"}]`))
+	}))
+	defer srv.Close()
+	b := &Bridge{bridgeID: bridgeID{IP: srv.URL + "/"}, username: "user"}
+
+	refs, err := b.References("scenes", "ab12")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(refs.Rules) != 1 || refs.Rules[0].ID != "1" || len(refs.Schedules) != 1 || refs.Schedules[0].ID != "5" {
+		t.Fatalf("unexpected references %+v", refs)
+	}
+
+	sc := &Scene{bridge: b, ID: "ab12"}
+	if err := sc.Delete(true); err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(requests)
+	want := []string{
+		"DELETE /api/user/resourcelinks/8",
+		"DELETE /api/user/rules/1",
+		"DELETE /api/user/scenes/ab12",
+		"DELETE /api/user/schedules/5",
+		"PUT /api/user/resourcelinks/7",
+	}
+	if !reflect.DeepEqual(requests, want) {
+		t.Fatalf("expected %v, got %v", want, requests)
+	}
+	var links map[string][]string
+	if err := json.Unmarshal([]byte(bodies["PUT /api/user/resourcelinks/7"]), &links); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"/rules/1"}; !reflect.DeepEqual(links["links"], want) {
+		t.Fatalf("expected links %v, got %v", want, links["links"])
+	}
+}
+
+func TestDelete(t *testing.T) {
+	mb := mockBridge(t)
+	defer mb.teardown()
+	mb.nextResponse = []interface{}{}
+	if err := (&Sensor{bridge: mb.b, ID: "2"}).Delete(false); err != nil {
+		t.Fatal(err)
+	}
+	if mb.lastMethod != http.MethodDelete || mb.lastPath != "/api/bridge_username/sensors/2" {
+		t.Fatalf("unexpected request %s %s", mb.lastMethod, mb.lastPath)
+	}
+}
@@ -2,96 +2,378 @@ package hue
 
 import (
 	"bufio"
+	"bytes"
+	"context"
 	"encoding/json"
 	"encoding/xml"
 	"errors"
 	"fmt"
-	"log"
 	"net"
 	"net/http"
 	"net/textproto"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
 // ErrNotFound is returned when no bridge was discovered.
 var ErrNotFound = errors.New("no bridge was found")
 
-// Discover returns the (first) bridge that it finds on the local network.
-func Discover() (*Bridge, error) {
-	if b := fromCache(); b != nil {
-		return b, nil
+// Discover returns the (first) bridge that it finds on the local network. The
+// result is read from the cache, if available.
+func Discover(opts ...Option) (*Bridge, error) {
+	o := newOptions(opts)
+	cachePath := o.cacheFile()
+	if o.wantID != "" {
+		return discoverID(o, cachePath)
 	}
-	bid, err := discover()
+	if b := fromCache(cachePath, o.app); b != nil {
+		return o.apply(b), nil
+	}
+	if o.cacheOnly {
+		return nil, ErrNotFound
+	}
+	bid, err := discover(o)
 	if err != nil {
 		return nil, err
 	}
-	return &Bridge{bridgeID: bid}, err
+	lookupName(context.Background(), &bid)
+	return o.apply(&Bridge{bridgeID: bid, cachePath: cachePath, app: o.app}), err
+}
+
+// discoverID returns the bridge with the ID requested by o, verifying that the
+// device found at its address reports the same ID. The cached address is tried
+// first.
+func discoverID(o *options, cachePath string) (*Bridge, error) {
+	ctx := context.Background()
+	for _, c := range readCache(cachePath) {
+		if !sameID(c.ID, o.wantID) {
+			continue
+		}
+		bid := bridgeID{ID: c.ID, IP: c.IP, Name: c.Name}
+		if verify(ctx, &bid) == nil {
+			b := &Bridge{bridgeID: bid, cachePath: cachePath, app: o.app}
+			b.username = cachedUsername(b)
+			return o.apply(b), nil
+		}
+		break
+	}
+	if o.cacheOnly {
+		return nil, ErrNotFound
+	}
+	for _, bid := range discoverAll(ctx, o) {
+		if !sameID(bid.ID, o.wantID) {
+			continue
+		}
+		if verify(ctx, &bid) != nil {
+			continue
+		}
+		b := &Bridge{bridgeID: bid, cachePath: cachePath, app: o.app}
+		b.username = cachedUsername(b)
+		return o.apply(b), nil
+	}
+	return nil, ErrNotFound
+}
+
+// DiscoverAll returns all the bridges that it finds on the local network, using
+// both UPNP and the remote API, sorted by ID. IDs are returned in their 16
+// character form, as reported by the bridge itself, along with the name of
+// each bridge. Bridges which have been paired with before have their
+// credentials restored from the cache.
+func DiscoverAll(opts ...Option) ([]*Bridge, error) {
+	o := newOptions(opts)
+	cachePath := o.cacheFile()
+	cached := readCache(cachePath)
+	ctx := context.Background()
+	var list []*Bridge
+	for _, bid := range discoverAll(ctx, o) {
+		var dup bool
+		for _, b := range list {
+			if sameID(b.ID, bid.ID) {
+				dup = true
+				break
+			}
+		}
+		if dup {
+			continue
+		}
+		bid.ID = strings.ToLower(normalizeID(bid.ID))
+		b := &Bridge{bridgeID: bid, cachePath: cachePath, app: o.app}
+		for _, c := range cached {
+			if c.is(b) {
+				b.username = c.Username
+				break
+			}
+		}
+		list = append(list, o.apply(b))
+	}
+	if len(list) == 0 {
+		return nil, ErrNotFound
+	}
+	var wg sync.WaitGroup
+	for _, b := range list {
+		wg.Add(1)
+		go func(b *Bridge) {
+			defer wg.Done()
+			lookupName(ctx, &b.bridgeID)
+		}(b)
+	}
+	wg.Wait()
+	sort.Sort(bridgesByID(list))
+	return list, nil
+}
+
+// discoverAll returns the bridges found using both UPNP and the remote API,
+// unless the LocalOnly option is set. Bridges found by both may be listed
+// twice.
+func discoverAll(ctx context.Context, o *options) []bridgeID {
+	var (
+		local, remote []bridgeID
+		wg            sync.WaitGroup
+	)
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		local, _ = discoverLocalAll(ctx, o)
+	}()
+	go func() {
+		defer wg.Done()
+		if !o.localOnly {
+			remote, _ = discoverRemoteAll(ctx)
+		}
+	}()
+	wg.Wait()
+	return append(local, remote...)
+}
+
+// bridgesByID sorts bridges by their ID.
+type bridgesByID []*Bridge
+
+func (b bridgesByID) Len() int           { return len(b) }
+func (b bridgesByID) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+func (b bridgesByID) Less(i, j int) bool { return b[i].ID < b[j].ID }
+
+// sameID reports whether a and b identify the same bridge. UPNP reports the
+// MAC address of the bridge (e.g. "001788a1b2c3") as its ID, while the bridge
+// and the remote API report an ID derived from it by inserting "fffe" in the
+// middle (e.g. "001788fffea1b2c3").
+func sameID(a, b string) bool {
+	return strings.EqualFold(normalizeID(a), normalizeID(b))
+}
+
+// normalizeID returns the 16 character form of a bridge ID.
+func normalizeID(id string) string {
+	if len(id) == 12 {
+		return id[:6] + "fffe" + id[6:]
+	}
+	return id
 }
 
 // bridgeID stores discovered bridges.
 type bridgeID struct {
 	ID string `json:"id"`
 	IP string `json:"internalipaddress"`
+
+	// Name is the name given to the bridge by its user (e.g. "Office
+	// bridge"). It is empty if it could not be determined.
+	Name string `json:"name,omitempty"`
 }
 
-// discover runs UPNP discovery and falls back to the remote API on failure.
-func discover() (bridgeID, error) {
-	var (
-		b   bridgeID
-		err error
-	)
-	b, err = discoverLocal()
-	if err != nil {
-		log.Println("Didn't find any bridges via UPNP, attempting remote API...")
-		b, err = discoverRemote()
-		if err != nil {
-			return b, ErrNotFound
+// DiscoveryError is returned by Discover when no bridge was found. It holds
+// the reason for which each discovery mechanism failed.
+type DiscoveryError struct {
+	// Errors maps the name of each discovery mechanism (e.g. "upnp" or
+	// "cloud") to the error it encountered.
+	Errors map[string]error
+}
+
+func (e *DiscoveryError) Error() string {
+	names := make([]string, 0, len(e.Errors))
+	for name := range e.Errors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var buf bytes.Buffer
+	buf.WriteString(ErrNotFound.Error())
+	for i, name := range names {
+		if i == 0 {
+			buf.WriteString(": ")
+		} else {
+			buf.WriteString("; ")
 		}
+		fmt.Fprintf(&buf, "%s: %v", name, e.Errors[name])
 	}
-	return b, err
+	return buf.String()
+}
+
+// Is reports whether target is ErrNotFound, so that errors.Is(err, ErrNotFound)
+// holds for a *DiscoveryError.
+func (e *DiscoveryError) Is(target error) bool { return target == ErrNotFound }
+
+// ErrLocalOnly is reported by a *DiscoveryError for the "cloud" mechanism when
+// it was not used because of the LocalOnly option. Its presence means that
+// Discover might have found a bridge, had LocalOnly not been given.
+var ErrLocalOnly = errors.New("disabled by LocalOnly option")
+
+// mechanisms holds the ways in which a bridge may be discovered, by name.
+var mechanisms = map[string]func(context.Context, *options) (bridgeID, error){
+	"upnp":  discoverLocal,
+	"cloud": discoverRemoteVerified,
+}
+
+// discover runs all discovery mechanisms concurrently, configured by o, and
+// returns the first bridge found, cancelling the other mechanisms. If none
+// succeeds, the returned error is a *DiscoveryError.
+func discover(o *options) (bridgeID, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	type result struct {
+		name string
+		bid  bridgeID
+		err  error
+	}
+	derr := &DiscoveryError{Errors: make(map[string]error)}
+	results := make(chan result, len(mechanisms))
+	var n int
+	for name, fn := range mechanisms {
+		if o.localOnly && name == "cloud" {
+			derr.Errors[name] = ErrLocalOnly
+			continue
+		}
+		n++
+		go func(name string, fn func(context.Context, *options) (bridgeID, error)) {
+			bid, err := fn(ctx, o)
+			results <- result{name, bid, err}
+		}(name, fn)
+	}
+	for i := 0; i < n; i++ {
+		r := <-results
+		if r.err == nil {
+			return r.bid, nil
+		}
+		derr.Errors[r.name] = r.err
+	}
+	return bridgeID{}, derr
 }
 
 var (
-	mcastAddr    = &net.UDPAddr{IP: []byte{239, 255, 255, 250}, Port: 1900}
+	mcastAddr = &net.UDPAddr{IP: []byte{239, 255, 255, 250}, Port: 1900}
+
+	// connDeadline is the default time during which UPNP responses are
+	// collected.
 	connDeadline = 5 * time.Second
 )
 
-// discoverLocal attempts to discover any Hue bridges available via UPNP.
-func discoverLocal() (bridgeID, error) {
-	conn, err := net.ListenUDP("udp", &net.UDPAddr{})
+// defaultMX is the default MX value of UPNP search requests: the maximum
+// number of seconds that devices wait before responding.
+const defaultMX = 10
+
+// discoverLocal attempts to discover any Hue bridges available via UPNP. It
+// returns the first one found.
+func discoverLocal(ctx context.Context, o *options) (bridgeID, error) {
+	var found []bridgeID
+	err := ssdpSearch(ctx, o, func(bid bridgeID) bool {
+		found = append(found, bid)
+		return false
+	})
 	if err != nil {
 		return bridgeID{}, err
 	}
+	if len(found) == 0 {
+		return bridgeID{}, ErrNotFound
+	}
+	return found[0], nil
+}
+
+// discoverLocalAll returns all the Hue bridges that respond via UPNP within
+// the response collection window.
+func discoverLocalAll(ctx context.Context, o *options) ([]bridgeID, error) {
+	var found []bridgeID
+	err := ssdpSearch(ctx, o, func(bid bridgeID) bool {
+		for _, f := range found {
+			if sameID(f.ID, bid.ID) {
+				return true
+			}
+		}
+		found = append(found, bid)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(found) == 0 {
+		return nil, ErrNotFound
+	}
+	return found, nil
+}
+
+// ssdpSearch sends an UPNP search request and calls fn for each Hue bridge that
+// responds, until fn returns false, the response collection window ends or the
+// context is done. Bridges answer a search several times; repeated responses
+// are skipped without fetching their description again.
+func ssdpSearch(ctx context.Context, o *options, fn func(bridgeID) bool) error {
+	mx, window := o.ssdpMX, o.ssdpWindow
+	if mx <= 0 {
+		mx = defaultMX
+	}
+	if window <= 0 {
+		window = connDeadline
+	}
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{})
+	if err != nil {
+		return err
+	}
 	defer conn.Close()
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
 	conn.WriteToUDP([]byte("M-SEARCH * HTTP/1.1\r\n"+
 		"HOST: 239.255.255.250:1900\r\n"+
 		"MAN: ssdp:discover\r\n"+
-		"MX: 10\r\n"+
+		"MX: "+strconv.Itoa(mx)+"\r\n"+
 		"ST: ssdp:all\r\n"), mcastAddr)
-	conn.SetDeadline(time.Now().Add(connDeadline))
+	conn.SetDeadline(time.Now().Add(window))
 	r := bufio.NewReader(conn)
+	seen := make(map[string]bool)
 	for {
 		_, err := r.ReadString('\n') // HTTP/1.1 200 OK\r\n
 		if err != nil {
-			break
+			return ctx.Err()
 		}
 		tp := textproto.NewReader(r)
 		h, err := tp.ReadMIMEHeader()
 		if err != nil {
 			continue
 		}
-		v, ok := h["Location"]
-		if !ok || len(v) == 0 {
+		loc := h.Get("Location")
+		if loc == "" {
 			continue
 		}
-		bid, err := tryLocation(v[0])
+		// bridges include their ID in the hue-bridgeid header
+		key := "id:" + strings.ToLower(normalizeID(h.Get("Hue-Bridgeid")))
+		if key == "id:" {
+			key = loc
+		}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		bid, err := tryLocation(loc)
 		if err != nil {
 			continue
 		}
-		return bid, err
+		if !fn(bid) {
+			return nil
+		}
 	}
-	return bridgeID{}, ErrNotFound
 }
 
 // tryLocation queries the passed url to check if it is the description of a Hue
@@ -126,23 +408,135 @@ func tryLocation(url string) (bridgeID, error) {
 	}, nil
 }
 
-var remoteAddr = "https://www.meethue.com/api/nupnp"
+var (
+	remoteAddr = "https://www.meethue.com/api/nupnp"
 
-// discoverRemote uses the meethue.com API to discover local bridges.
-func discoverRemote() (bridgeID, error) {
-	resp, err := http.Get(remoteAddr)
-	defer resp.Body.Close()
+	// remoteClient is the client used to query the remote discovery service.
+	remoteClient = &http.Client{Timeout: 10 * time.Second}
+)
+
+// ErrNoRegisteredBridges is returned by remote discovery when the discovery
+// service was reached, but knows of no bridges on the local network.
+var ErrNoRegisteredBridges = errors.New("no bridges registered with the discovery service")
+
+// RemoteError is returned by remote discovery when the discovery service could
+// not be reached, or replied with something other than a list of bridges. The
+// most common cause is a missing internet connection.
+type RemoteError struct {
+	// Err is the underlying error.
+	Err error
+}
+
+func (e *RemoteError) Error() string {
+	return "could not query discovery service: " + e.Err.Error()
+}
+
+// discoverRemote uses the meethue.com API to discover local bridges. It returns
+// the first one found.
+func discoverRemote(ctx context.Context) (bridgeID, error) {
+	b, err := discoverRemoteAll(ctx)
 	if err != nil {
 		return bridgeID{}, err
 	}
-	var b []bridgeID
-	err = json.NewDecoder(resp.Body).Decode(&b)
+	return b[0], nil
+}
+
+// discoverRemoteVerified is like discoverRemote, except that it returns the
+// first bridge which can be reached on the local network and reports the
+// expected ID.
+func discoverRemoteVerified(ctx context.Context, _ *options) (bridgeID, error) {
+	list, err := discoverRemoteAll(ctx)
 	if err != nil {
 		return bridgeID{}, err
 	}
+	for _, bid := range list {
+		if err = verify(ctx, &bid); err == nil {
+			return bid, nil
+		}
+	}
+	return bridgeID{}, err
+}
+
+// verify checks that the bridge bid is reachable and reports the expected ID.
+// On success, the name of bid is set to the one reported by the bridge.
+func verify(ctx context.Context, bid *bridgeID) error {
+	id, name, err := publicConfig(ctx, bid.IP)
+	if err != nil {
+		return err
+	}
+	if !sameID(id, bid.ID) {
+		return fmt.Errorf("bridge at %s reported ID %q, expected %q", bid.IP, id, bid.ID)
+	}
+	bid.Name = name
+	return nil
+}
+
+// lookupName sets the name of bid to the one reported by the bridge, unless it
+// is already known. Errors are ignored, leaving the name empty.
+func lookupName(ctx context.Context, bid *bridgeID) {
+	if bid.Name != "" {
+		return
+	}
+	if _, name, err := publicConfig(ctx, bid.IP); err == nil {
+		bid.Name = name
+	}
+}
+
+// publicConfigTimeout is the maximum time that reading the public
+// configuration of a bridge may take, so that an address which went stale does
+// not hang discovery.
+const publicConfigTimeout = 3 * time.Second
+
+// publicConfig returns the ID and name of the bridge at the given address, as
+// found in the part of its configuration which is available without pairing.
+// It fails if the bridge does not respond within publicConfigTimeout.
+func publicConfig(ctx context.Context, ip string) (id, name string, err error) {
+	ctx, cancel := context.WithTimeout(ctx, publicConfigTimeout)
+	defer cancel()
+	req, err := http.NewRequest(http.MethodGet, ip+"api/config", nil)
+	if err != nil {
+		return "", "", err
+	}
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return "", "", err
+	}
+	defer resp.Body.Close()
+	var c struct {
+		ID   string `json:"bridgeid"`
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&c); err != nil {
+		return "", "", err
+	}
+	return c.ID, c.Name, nil
+}
+
+// discoverRemoteAll uses the meethue.com API to discover all local bridges. It
+// returns a *RemoteError if the service could not be queried, or
+// ErrNoRegisteredBridges if it reported no bridges.
+func discoverRemoteAll(ctx context.Context) ([]bridgeID, error) {
+	req, err := http.NewRequest(http.MethodGet, remoteAddr, nil)
+	if err != nil {
+		return nil, &RemoteError{Err: err}
+	}
+	resp, err := remoteClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, &RemoteError{Err: err}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, &RemoteError{Err: fmt.Errorf("unexpected status %q", resp.Status)}
+	}
+	var b []bridgeID
+	if err := json.NewDecoder(resp.Body).Decode(&b); err != nil {
+		return nil, &RemoteError{Err: err}
+	}
 	if len(b) == 0 {
-		return bridgeID{}, ErrNotFound
+		return nil, ErrNoRegisteredBridges
 	}
-	b[0].IP = fmt.Sprintf("http://%s/", b[0].IP) // sanitize
-	return b[0], nil
+	for i := range b {
+		b[i].IP = fmt.Sprintf("http://%s/", b[i].IP) // sanitize
+	}
+	return b, nil
 }
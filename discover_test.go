@@ -2,7 +2,9 @@ package hue
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net"
 	"net/http"
@@ -119,8 +121,14 @@ var discoverRemoteTestsuite = map[string]struct {
 	// the response.
 	Result bridgeID
 
+	// Status is the HTTP status of the response. It defaults to 200.
+	Status int
+
 	// Error, when true, signals that the response should trigger an error.
 	Error bool
+
+	// Remote, when true, signals that the error should be a *RemoteError.
+	Remote bool
 }{
 	// single bridge
 	"single": {
@@ -137,6 +145,8 @@ var discoverRemoteTestsuite = map[string]struct {
 	},
 	// no bridges
 	"not-found": {Response: []bridgeID{}, Error: true},
+	// service failure
+	"server-error": {Status: http.StatusInternalServerError, Error: true, Remote: true},
 }
 
 func TestDiscoverRemote(t *testing.T) {
@@ -154,16 +164,25 @@ func TestDiscoverRemote(t *testing.T) {
 	for name, tt := range discoverRemoteTestsuite {
 		t.Run(name, func(t *testing.T) {
 			srv := setup(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				if tt.Status != 0 {
+					w.WriteHeader(tt.Status)
+				}
 				err := json.NewEncoder(w).Encode(tt.Response)
 				if err != nil {
 					t.Fatal(err)
 				}
 			}))
 			defer teardown(srv)
-			bid, err := discoverRemote()
+			bid, err := discoverRemote(context.Background())
 			if tt.Error {
-				if err == nil {
+				_, remote := err.(*RemoteError)
+				switch {
+				case err == nil:
 					t.Fatal("expected error")
+				case tt.Remote && !remote:
+					t.Fatalf("expected *RemoteError, got %v", err)
+				case !tt.Remote && err != ErrNoRegisteredBridges:
+					t.Fatalf("expected ErrNoRegisteredBridges, got %v", err)
 				}
 				return
 			}
@@ -177,6 +196,18 @@ func TestDiscoverRemote(t *testing.T) {
 	}
 }
 
+func TestDiscoverRemoteUnreachable(t *testing.T) {
+	orig := remoteAddr
+	defer func() { remoteAddr = orig }()
+	srv := httptest.NewServer(http.NotFoundHandler())
+	remoteAddr = srv.URL
+	srv.Close()
+	_, err := discoverRemote(context.Background())
+	if _, ok := err.(*RemoteError); !ok {
+		t.Fatalf("expected *RemoteError, got %v", err)
+	}
+}
+
 var discoverLocalTestsuite = map[string]struct {
 	Reply       string
 	Result      bridgeID
@@ -218,13 +249,13 @@ func TestDiscoverLocal(t *testing.T) {
 	origAddr := mcastAddr
 	origDeadline := connDeadline
 	setup := func() *net.UDPConn {
-		mcastAddr = &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: 9999}
 		// shorten deadline
 		connDeadline = time.Second
-		conn, err := net.ListenUDP("udp", mcastAddr)
+		conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.ParseIP("127.0.0.1")})
 		if err != nil {
 			t.Fatal(err)
 		}
+		mcastAddr = conn.LocalAddr().(*net.UDPAddr)
 		conn.SetDeadline(time.Now().Add(time.Second))
 		return conn
 	}
@@ -241,18 +272,19 @@ func TestDiscoverLocal(t *testing.T) {
 			wg.Add(1)
 			go func() {
 				defer wg.Done()
-				bid, err := discoverLocal()
+				bid, err := discoverLocal(context.Background(), new(options))
 				if tt.Error {
 					if err == nil {
-						t.Fatal("expected error")
+						t.Error("expected error")
 					}
 					return
 				}
 				if err != nil {
-					t.Fatalf("got unexpected error: %v", err)
+					t.Errorf("got unexpected error: %v", err)
+					return
 				}
 				if !reflect.DeepEqual(tt.Result, bid) {
-					t.Fatalf("expected %v, got %v", tt.Result, bid)
+					t.Errorf("expected %v, got %v", tt.Result, bid)
 				}
 			}()
 			b := make([]byte, 128)
@@ -272,3 +304,150 @@ func TestDiscoverLocal(t *testing.T) {
 		})
 	}
 }
+
+func TestSameID(t *testing.T) {
+	for _, tt := range []struct {
+		a, b string
+		want bool
+	}{
+		{"001788a1b2c3", "001788FFFEA1B2C3", true},
+		{"001788fffea1b2c3", "001788fffea1b2c3", true},
+		{"001788a1b2c3", "001788fffea1b2c4", false},
+	} {
+		if got := sameID(tt.a, tt.b); got != tt.want {
+			t.Errorf("sameID(%q, %q): expected %v, got %v", tt.a, tt.b, tt.want, got)
+		}
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	orig := mechanisms
+	defer func() { mechanisms = orig }()
+	bid := bridgeID{ID: "id", IP: "http://1.2.3.4/"}
+	cancelled := make(chan struct{})
+
+	t.Run("first", func(t *testing.T) {
+		mechanisms = map[string]func(context.Context, *options) (bridgeID, error){
+			"fast": func(context.Context, *options) (bridgeID, error) { return bid, nil },
+			"slow": func(ctx context.Context, _ *options) (bridgeID, error) {
+				<-ctx.Done()
+				close(cancelled)
+				return bridgeID{}, ctx.Err()
+			},
+		}
+		got, err := discover(new(options))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != bid {
+			t.Fatalf("expected %v, got %v", bid, got)
+		}
+		select {
+		case <-cancelled:
+		case <-time.After(time.Second):
+			t.Fatal("expected slow mechanism to be cancelled")
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		mechanisms = map[string]func(context.Context, *options) (bridgeID, error){
+			"upnp":  func(context.Context, *options) (bridgeID, error) { return bridgeID{}, ErrNotFound },
+			"cloud": func(context.Context, *options) (bridgeID, error) { return bridgeID{}, errors.New("offline") },
+		}
+		_, err := discover(new(options))
+		derr, ok := err.(*DiscoveryError)
+		if !ok {
+			t.Fatalf("expected *DiscoveryError, got %v", err)
+		}
+		if want := "no bridge was found: cloud: offline; upnp: no bridge was found"; derr.Error() != want {
+			t.Fatalf("expected %q, got %q", want, derr.Error())
+		}
+		if !derr.Is(ErrNotFound) {
+			t.Fatal("expected error to be ErrNotFound")
+		}
+	})
+}
+
+func TestDiscoverLocalOnly(t *testing.T) {
+	orig := mechanisms
+	defer func() { mechanisms = orig }()
+	mechanisms = map[string]func(context.Context, *options) (bridgeID, error){
+		"upnp": func(context.Context, *options) (bridgeID, error) { return bridgeID{}, ErrNotFound },
+		"cloud": func(context.Context, *options) (bridgeID, error) {
+			t.Error("cloud mechanism used")
+			return bridgeID{}, nil
+		},
+	}
+	_, err := discover(&options{localOnly: true})
+	derr, ok := err.(*DiscoveryError)
+	if !ok {
+		t.Fatalf("expected *DiscoveryError, got %v", err)
+	}
+	if derr.Errors["cloud"] != ErrLocalOnly {
+		t.Fatalf("expected ErrLocalOnly, got %v", derr.Errors["cloud"])
+	}
+}
+
+func TestVerify(t *testing.T) {
+	srv := serverWithResponse(`{"bridgeid": "001788FFFEA1B2C3", "name": "Office bridge"}`)
+	defer srv.Close()
+	bid := bridgeID{ID: "001788a1b2c3", IP: srv.URL + "/"}
+	if err := verify(context.Background(), &bid); err != nil {
+		t.Fatal(err)
+	}
+	if bid.Name != "Office bridge" {
+		t.Fatalf("expected name to be set, got %q", bid.Name)
+	}
+	if err := verify(context.Background(), &bridgeID{ID: "001788a1b2c4", IP: srv.URL + "/"}); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestSSDPDeduplicate(t *testing.T) {
+	origAddr := mcastAddr
+	defer func() { mcastAddr = origAddr }()
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.ParseIP("127.0.0.1")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mcastAddr = conn.LocalAddr().(*net.UDPAddr)
+	defer conn.Close()
+	var fetches int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fetches++
+		w.Write([]byte(xmlTestsuite["good"].Response))
+	}))
+	defer srv.Close()
+	var (
+		wg    sync.WaitGroup
+		found []bridgeID
+		lerr  error
+	)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		found, lerr = discoverLocalAll(context.Background(), &options{ssdpMX: 1, ssdpWindow: time.Second})
+	}()
+	b := make([]byte, 128)
+	_, raddr, err := conn.ReadFromUDP(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(b, []byte("MX: 1\r\n")) {
+		t.Fatalf("expected MX of 1, got %s", b)
+	}
+	reply := "HTTP/1.1 200 OK\r\nHue-Bridgeid: 00178829DA0D\r\nLocation: %s\r\n\r\n"
+	for _, st := range []string{"a", "b", "c"} {
+		r := fmt.Sprintf(reply, srv.URL+"/"+st)
+		if _, err := conn.WriteToUDP([]byte(r), raddr); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wg.Wait()
+	if lerr != nil {
+		t.Fatal(lerr)
+	}
+	if len(found) != 1 || fetches != 1 {
+		t.Fatalf("expected 1 bridge and 1 fetch, got %v and %d", found, fetches)
+	}
+}
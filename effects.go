@@ -0,0 +1,117 @@
+package hue
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+// Effect computes the state of light i of n lights, at the given time since
+// the effect started. A nil state leaves the light unchanged. The random
+// source is that of the running effect.
+type Effect func(i, n int, elapsed time.Duration, rnd *rand.Rand) *State
+
+const (
+	// minEffectFrame is the shortest time between two frames of an effect.
+	minEffectFrame = 400 * time.Millisecond
+
+	// lightCommandInterval is the time to allow for each light command. The
+	// bridge can not handle more than about ten of them per second.
+	lightCommandInterval = 100 * time.Millisecond
+)
+
+// Candle is an effect which flickers warm white like a candle.
+func Candle(i, n int, elapsed time.Duration, rnd *rand.Rand) *State {
+	return &State{On: true, Brightness: uint8(100 + rnd.Intn(80)), Ct: 500}
+}
+
+// Fireplace is an effect which flickers orange and red like a fireplace.
+func Fireplace(i, n int, elapsed time.Duration, rnd *rand.Rand) *State {
+	xy := XY{0.58 + rnd.Float64()*0.06, 0.38 + rnd.Float64()*0.02}
+	return &State{On: true, Brightness: uint8(60 + rnd.Intn(160)), XY: &xy}
+}
+
+// Thunderstorm is an effect which keeps the lights a dim blue, with occasional
+// flashes of lightning.
+func Thunderstorm(i, n int, elapsed time.Duration, rnd *rand.Rand) *State {
+	if rnd.Intn(20) == 0 {
+		return &State{On: true, Brightness: 254, Ct: 153, TransitionTime: 1}
+	}
+	xy := XY{0.17, 0.1}
+	return &State{On: true, Brightness: uint8(10 + rnd.Intn(30)), XY: &xy}
+}
+
+// Rainbow returns an effect which slowly cycles through the colors of the
+// rainbow, spreading them across the lights, and taking the given period to
+// complete a cycle.
+func Rainbow(period time.Duration) Effect {
+	return func(i, n int, elapsed time.Duration, rnd *rand.Rand) *State {
+		h := 360 * (float64(elapsed)/float64(period) + float64(i)/float64(n))
+		xy := xyFromHSV(math.Mod(h, 360), 1, 1)
+		return &State{On: true, Brightness: 254, XY: &xy}
+	}
+}
+
+// RunEffect runs effect e on the lights of the group, computing their states
+// on the client, until the context is done, and then gives the lights back the
+// state which they were in before. The lights are sent frames of the effect
+// at a pace which keeps within the rate of commands that the bridge accepts:
+// the more lights, the longer between frames. This allows effects on lights
+// which have none built in. To run an effect in the background, call RunEffect
+// in a goroutine and cancel the context to stop it. Frames are sent as by
+// Light.Set, so color compensation and the default transition time of the
+// lights apply. Effects are automated changes: once the quiet hours of the
+// group start, the effect ends and RunEffect returns ErrQuietHours. Otherwise,
+// it returns the error of the context, or the first error encountered while
+// updating a light.
+func (g *Group) RunEffect(ctx context.Context, e Effect) error {
+	lights, err := g.Members().List()
+	if err != nil {
+		return err
+	}
+	if len(lights) == 0 {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	sort.Slice(lights, func(i, j int) bool { return lessID(lights[i].ID, lights[j].ID) })
+	saved := saveStates(lights)
+	frame := time.Duration(len(lights)) * lightCommandInterval
+	if frame < minEffectFrame {
+		frame = minEffectFrame
+	}
+	clock := clockOf(g.bridge.clock)
+	rnd := rand.New(rand.NewSource(clock.Now().UnixNano()))
+	start := clock.Now()
+	err = func() error {
+		for {
+			now := clock.Now()
+			if g.isQuiet(now) {
+				return ErrQuietHours
+			}
+			elapsed := now.Sub(start)
+			for i, l := range lights {
+				s := e(i, len(lights), elapsed, rnd)
+				if s == nil {
+					continue
+				}
+				if s.TransitionTime == 0 && l.defaultTransition() == nil {
+					s.TransitionTime = uint16(frame / (100 * time.Millisecond))
+				}
+				if err := l.send(s); err != nil {
+					return err
+				}
+			}
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-clock.After(frame):
+			}
+		}
+	}()
+	if rerr := restoreStates(lights, saved); rerr != nil && err == nil {
+		err = rerr
+	}
+	return err
+}
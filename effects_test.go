@@ -0,0 +1,100 @@
+package hue_test
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+	"time"
+
+	"gbbr.io/hue"
+	"gbbr.io/hue/huetest"
+)
+
+func TestRunEffect(t *testing.T) {
+	start := time.Date(2017, 1, 1, 20, 0, 0, 0, time.UTC)
+	for name, e := range map[string]hue.Effect{
+		"candle":       hue.Candle,
+		"fireplace":    hue.Fireplace,
+		"thunderstorm": hue.Thunderstorm,
+		"rainbow":      hue.Rainbow(time.Minute),
+	} {
+		t.Run(name, func(t *testing.T) {
+			sim := huetest.NewSimulator(start, "a", "b", "c", "d", "e")
+			defer sim.Close()
+			b := sim.Bridge()
+			g, err := b.Groups().GetByID(sim.AddGroup("Living", "1", "2", "3", "4", "5"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			var frames []time.Duration
+			stop := func(i, n int, elapsed time.Duration, rnd *rand.Rand) *hue.State {
+				if i == 0 {
+					frames = append(frames, elapsed)
+					if len(frames) == 3 {
+						cancel()
+					}
+				}
+				return e(i, n, elapsed, rnd)
+			}
+			if err := g.RunEffect(ctx, stop); err != context.Canceled {
+				t.Fatalf("expected cancellation, got %v", err)
+			}
+			// five lights take 500ms per frame
+			if frames[1]-frames[0] != 500*time.Millisecond {
+				t.Fatalf("expected frames 500ms apart, got %v", frames)
+			}
+			for _, l := range []string{"1", "5"} {
+				l, err := b.Lights().GetByID(l)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if l.State.On || l.State.ColorMode != "ct" || l.State.ColorTemp != 366 {
+					t.Fatalf("expected light %s to be restored, got %+v", l.ID, l.State)
+				}
+			}
+		})
+	}
+}
+
+func TestRunEffectQuietHours(t *testing.T) {
+	start := time.Date(2017, 1, 1, 20, 0, 0, 0, time.UTC)
+	sim := huetest.NewSimulator(start, "a", "b")
+	defer sim.Close()
+	b := sim.Bridge()
+	g, err := b.Groups().GetByID(sim.AddGroup("Nursery", "1", "2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// quiet hours start one second into the effect
+	b.SetQuietHours(g.ID, hue.QuietHours{From: 20*time.Hour + time.Second, To: 23 * time.Hour})
+	var frames int
+	count := func(i, n int, elapsed time.Duration, rnd *rand.Rand) *hue.State {
+		if i == 0 {
+			frames++
+		}
+		return hue.Candle(i, n, elapsed, rnd)
+	}
+	if err := g.RunEffect(context.Background(), count); err != hue.ErrQuietHours {
+		t.Fatalf("expected ErrQuietHours, got %v", err)
+	}
+	if frames != 3 {
+		t.Fatalf("expected the effect to end after 3 frames, got %d", frames)
+	}
+	l, err := b.Lights().GetByID("1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.State.On {
+		t.Fatalf("expected light to be restored, got %+v", l.State)
+	}
+}
+
+func TestRainbowSpread(t *testing.T) {
+	e := hue.Rainbow(time.Minute)
+	a := e(0, 2, 0, nil)
+	b := e(1, 2, 0, nil)
+	if c := hue.Complementary(*a.XY)[1]; c.Distance(*b.XY) > 0.001 {
+		t.Fatalf("expected opposite colors, got %v and %v", *a.XY, *b.XY)
+	}
+}
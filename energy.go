@@ -0,0 +1,137 @@
+package hue
+
+import (
+	"sync"
+	"time"
+)
+
+// modelWatts holds the maximum power draw, in watts, of known light models.
+// The numbers are approximate and only meant for rough estimates.
+var modelWatts = map[string]float64{
+	"LCT001": 8.5,  // Hue bulb A19
+	"LCT007": 9,    // Hue bulb A19
+	"LCT010": 10,   // Hue bulb A19
+	"LCT014": 9.5,  // Hue bulb A19
+	"LCT015": 9.5,  // Hue color lamp
+	"LCT016": 9.5,  // Hue color lamp
+	"LCT002": 8,    // Hue spot BR30
+	"LCT003": 6.5,  // Hue spot GU10
+	"LCT011": 8,    // Hue BR30
+	"LCT012": 6,    // Hue color candle
+	"LTW001": 9.5,  // Hue white ambiance A19
+	"LTW004": 9.5,  // Hue white ambiance A19
+	"LTW010": 9.5,  // Hue white ambiance A19
+	"LTW012": 5.5,  // Hue white ambiance candle
+	"LTW013": 5.5,  // Hue white ambiance GU10
+	"LWB004": 9,    // Hue white A19
+	"LWB006": 9,    // Hue white A19
+	"LWB010": 9,    // Hue white A19
+	"LWB014": 9,    // Hue white A19
+	"LST001": 12,   // Hue lightstrip
+	"LST002": 20,   // Hue lightstrip plus
+	"LLC010": 8,    // Hue living colors iris
+	"LLC011": 8,    // Hue bloom
+	"LLC012": 8,    // Hue bloom
+	"LLC020": 6,    // Hue go
+	"HML004": 22.5, // Hue Phoenix
+}
+
+// Power draw estimates for lights whose model is unknown, and for lights which
+// are off.
+const (
+	defaultWatts = 9
+	standbyWatts = 0.4
+)
+
+// estimateWatts returns the estimated power draw of light l, in watts. The
+// draw is assumed to scale linearly with brightness, from a tenth of the
+// maximum draw at the lowest brightness.
+func estimateWatts(l *Light) float64 {
+	if !l.State.On || !l.State.Reachable {
+		return standbyWatts
+	}
+	max, ok := modelWatts[l.ModelID]
+	if !ok {
+		max = defaultWatts
+	}
+	return standbyWatts + (max-standbyWatts)*(0.1+0.9*float64(l.State.Brightness)/254)
+}
+
+// maxPowerSamples is the number of power samples kept per light.
+const maxPowerSamples = 1000
+
+// powerSample records the estimated power draw of a light from a point in time
+// on.
+type powerSample struct {
+	t     time.Time
+	watts float64
+}
+
+// energyTracker tracks the estimated power draw of lights across calls.
+type energyTracker struct {
+	mu      sync.Mutex
+	samples map[string][]powerSample
+}
+
+// observe records the estimated power draw of light l at time t.
+func (e *energyTracker) observe(l *Light, t time.Time) {
+	w := estimateWatts(l)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.samples == nil {
+		e.samples = make(map[string][]powerSample)
+	}
+	s := e.samples[l.ID]
+	if len(s) > 0 && s[len(s)-1].watts == w {
+		return
+	}
+	if len(s) == maxPowerSamples {
+		s = append(s[:0], s[1:]...)
+	}
+	e.samples[l.ID] = append(s, powerSample{t: t, watts: w})
+}
+
+// estimate returns the estimated energy used by the light with the given ID
+// between the given times, in watt-hours.
+func (e *energyTracker) estimate(id string, since, end time.Time) float64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	s := e.samples[id]
+	var wh float64
+	for i := len(s) - 1; i >= 0 && end.After(since); i-- {
+		start := s[i].t
+		if start.Before(since) {
+			start = since
+		}
+		if end.After(start) {
+			wh += s[i].watts * end.Sub(start).Hours()
+		}
+		end = s[i].t
+	}
+	return wh
+}
+
+// EnergyEstimate returns a rough estimate of the energy used by the light
+// since the given time, in watt-hours. It is based on the model of the light
+// and its state each time it was retrieved, and it requires the TrackEnergy
+// option. Time before the light was first retrieved is not accounted for.
+func (l *Light) EnergyEstimate(since time.Time) float64 {
+	if l.bridge.energy == nil {
+		return 0
+	}
+	return l.bridge.energy.estimate(l.ID, since, l.bridge.now())
+}
+
+// EnergyEstimate returns a rough estimate of the energy used by the lights of
+// the room since the given time, in watt-hours. See Light.EnergyEstimate.
+func (r *Room) EnergyEstimate(since time.Time) float64 {
+	b := r.Group.bridge
+	if b.energy == nil {
+		return 0
+	}
+	var wh float64
+	for _, id := range r.Group.Lights {
+		wh += b.energy.estimate(id, since, b.now())
+	}
+	return wh
+}
@@ -0,0 +1,29 @@
+package hue
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestEnergyEstimate(t *testing.T) {
+	var bodies []string
+	for _, on := range []bool{true, true, false} {
+		bodies = append(bodies, fmt.Sprintf(`{"1": {"modelid": "LCT001", "state": {"on": %v, "bri": 254, "reachable": true}}}`, on))
+	}
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	b := &Bridge{energy: new(energyTracker)}
+	_, clock := pollLights(t, b, start, time.Hour, bodies...)
+	clock.set(start.Add(4 * time.Hour))
+	r := &Room{Group: &Group{bridge: b, Lights: []string{"1"}}}
+	// two hours on at full brightness, two hours in standby
+	want := 2*8.5 + 2*standbyWatts
+	if got := r.EnergyEstimate(start); math.Abs(got-want) > 1e-9 {
+		t.Fatalf("expected %v Wh, got %v Wh", want, got)
+	}
+	want = 8.5 + 2*standbyWatts
+	if got := r.EnergyEstimate(start.Add(time.Hour)); math.Abs(got-want) > 1e-9 {
+		t.Fatalf("expected %v Wh, got %v Wh", want, got)
+	}
+}
@@ -0,0 +1,149 @@
+package hue
+
+import (
+	"context"
+	"time"
+)
+
+// Event reports that the state of a light changed.
+type Event struct {
+	// Time is the time at which the change was detected.
+	Time time.Time
+
+	// Resource is the address of the light (e.g. "/lights/3").
+	Resource string
+
+	// State is the new state of the light.
+	State LightState
+}
+
+// Overflow is the policy applied to events when the buffer of a subscription
+// is full, because the consumer is slower than the changes.
+type Overflow int
+
+const (
+	// DropOldest discards the oldest buffered event to make room for a new
+	// one.
+	DropOldest Overflow = iota
+
+	// Block stops polling the bridge until the consumer catches up, so that
+	// no event is lost, though changes which are undone in the meantime are
+	// not seen.
+	Block
+
+	// Coalesce replaces the buffered event of the same light, if any, with
+	// the new one, so that each light has at most one pending event with its
+	// latest state. If the buffer holds events of more lights than it fits,
+	// the oldest is discarded.
+	Coalesce
+)
+
+// defaultEventBuffer is the default number of events buffered by a
+// subscription.
+const defaultEventBuffer = 64
+
+// EventOptions configures a subscription to the events of a bridge.
+type EventOptions struct {
+	// Interval is the time between polls of the bridge. It defaults to one
+	// second.
+	Interval time.Duration
+
+	// Buffer is the number of events held for the consumer. It defaults to
+	// 64.
+	Buffer int
+
+	// Overflow is the policy applied when the buffer is full.
+	Overflow Overflow
+
+	// OnError, if not nil, is called with the errors encountered while
+	// polling the bridge, which do not end the subscription.
+	OnError func(error)
+}
+
+// Subscribe polls the lights of the bridge and sends an event on the returned
+// channel for each light whose state changed, until the context is done, at
+// which point the channel is closed. Since the bridge does not push changes,
+// transitions which are undone between two polls are not seen. Events are
+// buffered so that a slow consumer does not delay polling; when the buffer is
+// full, the overflow policy of the options applies.
+func (b *Bridge) Subscribe(ctx context.Context, opts EventOptions) <-chan Event {
+	if opts.Interval <= 0 {
+		opts.Interval = defaultFollowInterval
+	}
+	if opts.Buffer <= 0 {
+		opts.Buffer = defaultEventBuffer
+	}
+	ch := make(chan Event)
+	go b.poll(ctx, opts, ch)
+	return ch
+}
+
+// poll implements Subscribe, sending events on ch.
+func (b *Bridge) poll(ctx context.Context, opts EventOptions, ch chan<- Event) {
+	defer close(ch)
+	q := &eventQueue{max: opts.Buffer, policy: opts.Overflow}
+	clock := clockOf(b.clock)
+	var last map[string]LightState
+	tick := clock.After(0)
+	for {
+		var out chan<- Event
+		var next Event
+		if len(q.list) > 0 {
+			out, next = ch, q.list[0]
+		}
+		wait := tick
+		if opts.Overflow == Block && q.full() {
+			wait = nil
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case out <- next:
+			q.list = q.list[1:]
+		case <-wait:
+			tick = clock.After(opts.Interval)
+			lights, err := b.Lights().idMap()
+			if err != nil {
+				if opts.OnError != nil {
+					opts.OnError(err)
+				}
+				continue
+			}
+			now := clock.Now()
+			cur := make(map[string]LightState, len(lights))
+			for id, l := range lights {
+				cur[id] = l.State
+				if prev, ok := last[id]; ok && prev != l.State {
+					q.push(Event{Time: now, Resource: "/lights/" + id, State: l.State})
+				}
+			}
+			last = cur
+		}
+	}
+}
+
+// eventQueue holds the events of a subscription which were not consumed yet.
+type eventQueue struct {
+	max    int
+	policy Overflow
+	list   []Event
+}
+
+// full reports whether the queue holds as many events as it fits.
+func (q *eventQueue) full() bool { return len(q.list) >= q.max }
+
+// push adds e to the queue, applying the overflow policy.
+func (q *eventQueue) push(e Event) {
+	if q.policy == Coalesce {
+		for i := range q.list {
+			if q.list[i].Resource == e.Resource {
+				q.list[i] = e
+				return
+			}
+		}
+	}
+	if q.full() && q.policy != Block {
+		q.list = q.list[1:]
+	}
+	q.list = append(q.list, e)
+}
@@ -0,0 +1,72 @@
+package hue
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSubscribe(t *testing.T) {
+	var polls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// light 1 turns on at the second poll, and off at the fourth
+		n := atomic.AddInt32(&polls, 1)
+		fmt.Fprintf(w, `{"1": {"name": "a", "state": {"on": %v}}, "2": {"name": "b", "state": {"on": true}}}`, n == 2 || n == 3)
+	}))
+	defer srv.Close()
+	b := &Bridge{
+		bridgeID: bridgeID{IP: srv.URL + "/"},
+		username: "u",
+		clock:    &fakeClock{t: time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch := b.Subscribe(ctx, EventOptions{Overflow: Block, OnError: func(err error) { t.Error(err) }})
+	for _, on := range []bool{true, false} {
+		e := <-ch
+		if e.Resource != "/lights/1" || e.State.On != on {
+			t.Fatalf("expected light 1 to be turned on=%v, got %+v", on, e)
+		}
+	}
+	cancel()
+	for range ch {
+	}
+}
+
+func TestEventQueue(t *testing.T) {
+	ev := func(id string, on bool) Event {
+		return Event{Resource: "/lights/" + id, State: LightState{On: on}}
+	}
+	for _, tt := range []struct {
+		policy Overflow
+		want   []Event
+	}{
+		{DropOldest, []Event{ev("1", false), ev("2", true)}},
+		{Block, []Event{ev("1", true), ev("1", false), ev("2", true)}},
+		{Coalesce, []Event{ev("1", false), ev("2", true)}},
+	} {
+		q := &eventQueue{max: 2, policy: tt.policy}
+		q.push(ev("1", true))
+		q.push(ev("1", false))
+		q.push(ev("2", true))
+		if len(q.list) != len(tt.want) {
+			t.Fatalf("policy %d: expected %v, got %v", tt.policy, tt.want, q.list)
+		}
+		for i := range tt.want {
+			if q.list[i] != tt.want[i] {
+				t.Fatalf("policy %d: expected %v, got %v", tt.policy, tt.want, q.list)
+			}
+		}
+	}
+	q := &eventQueue{max: 2, policy: Coalesce}
+	q.push(ev("1", true))
+	q.push(ev("2", true))
+	q.push(ev("3", true))
+	if len(q.list) != 2 || q.list[0].Resource != "/lights/2" {
+		t.Fatalf("expected the oldest event to be dropped, got %v", q.list)
+	}
+}
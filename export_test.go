@@ -0,0 +1,24 @@
+package hue
+
+import "time"
+
+// Internals used by the tests in package hue_test, which run against the
+// simulator of package huetest.
+var (
+	AlertRed   = alertRed
+	AlertBlue  = alertBlue
+	DefaultApp = defaultApp
+	RGBToHSV   = rgbToHSV
+	XYToRGB    = xyToRGB
+)
+
+// LightsByID returns the lights of bridge b, keyed by ID.
+func LightsByID(b *Bridge) (map[string]*Light, error) { return b.Lights().idMap() }
+
+// SetVerifyDelay sets the delay before the first check of verified commands
+// and returns a function which restores it.
+func SetVerifyDelay(d time.Duration) (restore func()) {
+	old := verifyDelay
+	verifyDelay = d
+	return func() { verifyDelay = old }
+}
@@ -0,0 +1,108 @@
+package hue
+
+import (
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// healthCheckInterval is the time between health checks of a primary bridge
+// which is unreachable.
+const healthCheckInterval = 30 * time.Second
+
+// primaryReadTimeout is the maximum time that a read from the primary bridge
+// may take before failing over, unless a shorter timeout is set using
+// WithTimeout. A powered-off bridge does not refuse connections, so without it
+// reads would hang instead of failing over.
+var primaryReadTimeout = 3 * time.Second
+
+// healthClient is used for health checks of the primary bridge.
+var healthClient = &http.Client{Timeout: 2 * time.Second}
+
+// failover routes reads to a secondary gateway while the primary bridge is
+// unreachable.
+type failover struct {
+	// secondary is the secondary gateway.
+	secondary Bridge
+
+	mu       sync.Mutex
+	down     bool      // primary is unreachable
+	checked  time.Time // time of the last health check
+	checking bool      // a health check is in progress
+}
+
+// read sends a GET request to the resource at tokens of primary, or to the
+// secondary gateway if primary is unreachable or does not respond within
+// primaryReadTimeout.
+func (f *failover) read(primary Bridge, tokens []string) ([]byte, error) {
+	if !f.primaryDown(primary) {
+		if t := primary.limits.timeout; t <= 0 || t > primaryReadTimeout {
+			primary.limits.timeout = primaryReadTimeout
+		}
+		msg, err := primary.request(http.MethodGet, nil, tokens...)
+		if !unreachable(err) {
+			return msg, err
+		}
+		f.markDown(primary)
+	}
+	return f.secondary.request(http.MethodGet, nil, tokens...)
+}
+
+// markDown records that primary was found to be unreachable.
+func (f *failover) markDown(primary Bridge) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.down = true
+	f.checked = primary.now()
+}
+
+// primaryDown reports whether primary is known to be unreachable. If the last
+// health check is older than healthCheckInterval, a new one is started.
+func (f *failover) primaryDown(primary Bridge) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.down {
+		return false
+	}
+	if !f.checking && primary.now().Sub(f.checked) >= healthCheckInterval {
+		f.checking = true
+		go f.check(primary)
+	}
+	return true
+}
+
+// check checks whether primary is reachable again.
+func (f *failover) check(primary Bridge) {
+	resp, err := healthClient.Get(primary.addr("config"))
+	if err == nil {
+		resp.Body.Close()
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.down = err != nil
+	f.checked = primary.now()
+	f.checking = false
+}
+
+// unreachable reports whether err is the result of failing to reach the
+// bridge, as opposed to an error reported by it.
+func unreachable(err error) bool {
+	switch err.(type) {
+	case *url.Error, *TimeoutError:
+		return true
+	}
+	return false
+}
+
+// Primary reports whether reads are sent to the bridge itself, rather than to
+// the secondary gateway set using WithSecondary. It is always true when no
+// secondary gateway is set.
+func (b *Bridge) Primary() bool {
+	if b.failover == nil {
+		return true
+	}
+	b.failover.mu.Lock()
+	defer b.failover.mu.Unlock()
+	return !b.failover.down
+}
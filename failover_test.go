@@ -0,0 +1,79 @@
+package hue
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFailover(t *testing.T) {
+	down := int32(1)
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&down) == 1 {
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			conn.Close()
+			return
+		}
+		w.Write([]byte(`{"1": {"name": "primary"}}`))
+	}))
+	defer primary.Close()
+	secondary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/mirror/lights" {
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+		w.Write([]byte(`{"1": {"name": "secondary"}}`))
+	}))
+	defer secondary.Close()
+	var o options
+	WithSecondary(secondary.Listener.Addr().String(), "mirror")(&o)
+	b := o.apply(&Bridge{bridgeID: bridgeID{IP: primary.URL + "/"}, username: "user"})
+
+	l, err := b.Lights().GetByID("1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.Name != "secondary" || b.Primary() {
+		t.Fatalf("expected read from secondary, got %q", l.Name)
+	}
+	if _, err := b.Do(http.MethodPut, "lights/1", []byte(`{}`)); !unreachable(err) {
+		t.Fatalf("expected writes to go to the primary, got %v", err)
+	}
+
+	atomic.StoreInt32(&down, 0)
+	b.failover.check(*b)
+	l, err = b.Lights().GetByID("1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.Name != "primary" || !b.Primary() {
+		t.Fatalf("expected read from primary, got %q", l.Name)
+	}
+}
+
+func TestFailoverTimeout(t *testing.T) {
+	defer func(d time.Duration) { primaryReadTimeout = d }(primaryReadTimeout)
+	primaryReadTimeout = 50 * time.Millisecond
+	release := make(chan struct{})
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer primary.Close()
+	defer close(release)
+	secondary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"1": {"name": "secondary"}}`))
+	}))
+	defer secondary.Close()
+	var o options
+	WithSecondary(secondary.Listener.Addr().String(), "mirror")(&o)
+	b := o.apply(&Bridge{bridgeID: bridgeID{IP: primary.URL + "/"}, username: "user"})
+
+	l, err := b.Lights().GetByID("1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.Name != "secondary" || b.Primary() {
+		t.Fatalf("expected read from secondary, got %q", l.Name)
+	}
+}
@@ -0,0 +1,68 @@
+package hue
+
+import (
+	"strings"
+	"sync"
+)
+
+// gets coalesces concurrent GET requests to the bridges. Commands sent to a
+// bridge make later GET requests to it start a new flight, so that they never
+// return a response read before the command.
+var gets flightGroup
+
+// flightGroup coalesces concurrent calls with the same key into one, so that
+// for example many goroutines listing the lights at once result in a single
+// request to the bridge.
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*flight
+}
+
+// flight is a call in progress.
+type flight struct {
+	wg  sync.WaitGroup
+	msg []byte
+	err error
+}
+
+// do calls fn and returns its results, unless a call with the same key is in
+// progress, in which case it waits for it and returns a copy of its results.
+func (g *flightGroup) do(key string, fn func() ([]byte, error)) ([]byte, error) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*flight)
+	}
+	if f, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		f.wg.Wait()
+		if f.err != nil {
+			return nil, f.err
+		}
+		return append([]byte(nil), f.msg...), nil
+	}
+	f := new(flight)
+	f.wg.Add(1)
+	g.calls[key] = f
+	g.mu.Unlock()
+
+	f.msg, f.err = fn()
+	g.mu.Lock()
+	if g.calls[key] == f {
+		delete(g.calls, key)
+	}
+	g.mu.Unlock()
+	f.wg.Done()
+	return f.msg, f.err
+}
+
+// forget causes calls with keys starting with prefix to no longer be joined.
+// Calls already waiting for them are not affected.
+func (g *flightGroup) forget(prefix string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for key := range g.calls {
+		if strings.HasPrefix(key, prefix) {
+			delete(g.calls, key)
+		}
+	}
+}
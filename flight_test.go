@@ -0,0 +1,91 @@
+package hue
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestCoalesceGets(t *testing.T) {
+	var requests int32
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		<-release
+		w.Write([]byte(`{"1": {"name": "l1"}}`))
+	}))
+	defer srv.Close()
+	b := &Bridge{bridgeID: bridgeID{IP: srv.URL + "/"}, username: "user"}
+
+	const n = 10
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := b.Lights().List()
+			errs <- err
+		}()
+	}
+	// wait for the first request to reach the server
+	for atomic.LoadInt32(&requests) == 0 {
+		runtime.Gosched()
+	}
+	close(release)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := atomic.LoadInt32(&requests); got >= n {
+		t.Fatalf("expected requests to be coalesced, got %d", got)
+	}
+}
+
+func TestCoalesceGetsAfterWrite(t *testing.T) {
+	var requests int32
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Write([]byte(`[{"success": {}}]`))
+			return
+		}
+		if atomic.AddInt32(&requests, 1) == 1 {
+			<-release
+			w.Write([]byte(`{"1": {"name": "before"}}`))
+			return
+		}
+		w.Write([]byte(`{"1": {"name": "after"}}`))
+	}))
+	defer srv.Close()
+	b := &Bridge{bridgeID: bridgeID{IP: srv.URL + "/"}, username: "user"}
+
+	done := make(chan error)
+	go func() {
+		_, err := b.Lights().List()
+		done <- err
+	}()
+	for atomic.LoadInt32(&requests) == 0 {
+		runtime.Gosched()
+	}
+	if _, err := b.call(http.MethodPut, map[string]string{"name": "after"}, "lights", "1"); err != nil {
+		t.Fatal(err)
+	}
+	lights, err := b.Lights().List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lights[0].Name != "after" {
+		t.Fatalf("expected a read made after the write, got %q", lights[0].Name)
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
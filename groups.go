@@ -0,0 +1,467 @@
+package hue
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// ErrGroupNotExist is returned when a group was not found.
+var ErrGroupNotExist = errors.New("group does not exist")
+
+// Group types, as reported by the bridge.
+const (
+	TypeLightGroup    = "LightGroup"
+	TypeRoom          = "Room"
+	TypeLuminaire     = "Luminaire"
+	TypeLightSource   = "LightSource"
+	TypeEntertainment = "Entertainment"
+	TypeZone          = "Zone"
+)
+
+// Room classes (archetypes). The class determines the icon that the official
+// app shows for a room. For more information see:
+// https://developers.meethue.com/documentation/groups-api#22_group_class
+const (
+	ClassLivingRoom  = "Living room"
+	ClassKitchen     = "Kitchen"
+	ClassDining      = "Dining"
+	ClassBedroom     = "Bedroom"
+	ClassKidsBedroom = "Kids bedroom"
+	ClassBathroom    = "Bathroom"
+	ClassNursery     = "Nursery"
+	ClassRecreation  = "Recreation"
+	ClassOffice      = "Office"
+	ClassGym         = "Gym"
+	ClassHallway     = "Hallway"
+	ClassToilet      = "Toilet"
+	ClassFrontDoor   = "Front door"
+	ClassGarage      = "Garage"
+	ClassTerrace     = "Terrace"
+	ClassGarden      = "Garden"
+	ClassDriveway    = "Driveway"
+	ClassCarport     = "Carport"
+	ClassOther       = "Other"
+
+	// As of 1.30.
+	ClassHome        = "Home"
+	ClassDownstairs  = "Downstairs"
+	ClassUpstairs    = "Upstairs"
+	ClassTopFloor    = "Top floor"
+	ClassAttic       = "Attic"
+	ClassGuestRoom   = "Guest room"
+	ClassStaircase   = "Staircase"
+	ClassLounge      = "Lounge"
+	ClassManCave     = "Man cave"
+	ClassComputer    = "Computer"
+	ClassStudio      = "Studio"
+	ClassMusic       = "Music"
+	ClassTV          = "TV"
+	ClassReading     = "Reading"
+	ClassCloset      = "Closet"
+	ClassStorage     = "Storage"
+	ClassLaundryRoom = "Laundry room"
+	ClassBalcony     = "Balcony"
+	ClassPorch       = "Porch"
+	ClassBarbecue    = "Barbecue"
+	ClassPool        = "Pool"
+	ClassFree        = "Free"
+)
+
+// Groups returns the service to interact with the groups on this bridge.
+func (b *Bridge) Groups() *GroupsService { return &GroupsService{bridge: b} }
+
+// GroupsService is the service that allows interacting with the groups API
+// of the bridge.
+type GroupsService struct{ bridge *Bridge }
+
+// List returns a slice of all groups on the bridge.
+func (g *GroupsService) List() ([]*Group, error) {
+	all, err := g.fetch(true)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]*Group, 0, len(all))
+	for _, gg := range all {
+		list = append(list, gg)
+	}
+	return list, nil
+}
+
+// All returns the special group 0, which contains all the lights known to the
+// bridge.
+func (g *GroupsService) All() *Group {
+	return &Group{bridge: g.bridge, ID: "0"}
+}
+
+// LightNameError is returned when a light name does not match exactly one
+// light.
+type LightNameError struct {
+	// Name is the name of the light.
+	Name string
+
+	// IDs holds the IDs of all the lights with the given name. It is empty
+	// if there are none.
+	IDs []string
+}
+
+func (e *LightNameError) Error() string {
+	if len(e.IDs) == 0 {
+		return fmt.Sprintf("no light named %q", e.Name)
+	}
+	return fmt.Sprintf("light name %q is ambiguous (IDs %s)", e.Name, strings.Join(e.IDs, ", "))
+}
+
+// CreateFromNames creates a group of type LightGroup with the given name,
+// containing the lights with the given names. If any of the names does not
+// match exactly one light, a *LightNameError is returned and no group is
+// created.
+func (g *GroupsService) CreateFromNames(name string, lightNames ...string) (*Group, error) {
+	lights, err := g.bridge.Lights().List()
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(lightNames))
+	for _, n := range lightNames {
+		var matches []string
+		for _, l := range lights {
+			if l.Name == n {
+				matches = append(matches, l.ID)
+			}
+		}
+		if len(matches) != 1 {
+			sort.Strings(matches)
+			return nil, &LightNameError{Name: n, IDs: matches}
+		}
+		ids = append(ids, matches[0])
+	}
+	return g.Create(name, ids, TypeLightGroup)
+}
+
+// Create creates a group with the given name, containing the lights with the
+// given IDs, and returns it. The type defaults to LightGroup. A light can only
+// belong to a single room (see CreateRoom), and the bridge rejects
+// entertainment groups containing lights which can not stream. If the bridge
+// would not accept the name, a *NameError is returned.
+func (g *GroupsService) Create(name string, lightIDs []string, groupType string, opts ...GroupOption) (*Group, error) {
+	if groupType == "" {
+		groupType = TypeLightGroup
+	}
+	return g.create(&Group{Name: name, Lights: lightIDs, Type: groupType}, opts)
+}
+
+// GroupOption sets optional attributes of a group created using Create or
+// CreateRoom.
+type GroupOption func(*Group)
+
+// Recyclable allows the bridge to delete the created group once no rule,
+// schedule or resource link refers to it anymore, which suits temporary
+// groups created by automations.
+func Recyclable() GroupOption {
+	return func(grp *Group) { grp.Recycle = true }
+}
+
+// RoomConflictError is returned by CreateRoom when a light already belongs to
+// another room.
+type RoomConflictError struct {
+	// Light is the ID of the light.
+	Light string
+
+	// Room is the room which the light belongs to.
+	Room *Group
+}
+
+func (e *RoomConflictError) Error() string {
+	return fmt.Sprintf("light %s already belongs to room %q", e.Light, e.Room.Name)
+}
+
+// CreateRoom creates a group of type Room with the given name, class (e.g.
+// ClassKitchen) and lights, and returns it. The class defaults to ClassOther.
+// Unlike other groups, a light can only belong to a single room; if one of the
+// lights already does, a *RoomConflictError is returned and no room is
+// created. Use RoomOf to find the room of a light.
+func (g *GroupsService) CreateRoom(name, class string, lightIDs []string, opts ...GroupOption) (*Group, error) {
+	if class == "" {
+		class = ClassOther
+	}
+	return g.create(&Group{Name: name, Lights: lightIDs, Type: TypeRoom, Class: class}, opts)
+}
+
+// RoomOf returns the room which the light with the given ID belongs to, or
+// nil if it does not belong to any.
+func (g *GroupsService) RoomOf(lightID string) (*Group, error) {
+	all, err := g.idMap()
+	if err != nil {
+		return nil, err
+	}
+	return roomOf(all, lightID), nil
+}
+
+// roomOf returns the group of type Room among groups which contains the
+// light with the given ID, if any.
+func roomOf(groups map[string]*Group, lightID string) *Group {
+	for _, gg := range groups {
+		if gg.Type != TypeRoom {
+			continue
+		}
+		for _, id := range gg.Lights {
+			if id == lightID {
+				return gg
+			}
+		}
+	}
+	return nil
+}
+
+// create creates the group grp with the given options, setting its ID. Rooms
+// are checked not to share lights with existing rooms.
+func (g *GroupsService) create(grp *Group, opts []GroupOption) (*Group, error) {
+	for _, opt := range opts {
+		opt(grp)
+	}
+	if grp.Type == TypeRoom {
+		all, err := g.idMap()
+		if err != nil {
+			return nil, err
+		}
+		ids := append([]string(nil), grp.Lights...)
+		sort.Slice(ids, func(i, j int) bool { return lessID(ids[i], ids[j]) })
+		for _, id := range ids {
+			if r := roomOf(all, id); r != nil {
+				return nil, &RoomConflictError{Light: id, Room: r}
+			}
+		}
+	}
+	if grp.Type == TypeEntertainment {
+		if err := g.bridge.supports(featureEntertainment); err != nil {
+			return nil, err
+		}
+	}
+	name, err := g.bridge.validName(grp.Name)
+	if err != nil {
+		return nil, err
+	}
+	grp.bridge = g.bridge
+	grp.Name = name
+	body := map[string]interface{}{
+		"name":   name,
+		"lights": grp.Lights,
+		"type":   grp.Type,
+	}
+	if grp.Class != "" {
+		body["class"] = grp.Class
+	}
+	if grp.Recycle {
+		body["recycle"] = true
+	}
+	msg, err := g.bridge.call(http.MethodPost, body, "groups")
+	if err != nil {
+		return nil, err
+	}
+	grp.ID, err = createdID(msg)
+	if err != nil {
+		return nil, err
+	}
+	return grp, nil
+}
+
+// GetByID returns a group by id.
+func (g *GroupsService) GetByID(id string) (*Group, error) {
+	list, err := g.fetch(true)
+	if err != nil {
+		return nil, err
+	}
+	v, ok := list[id]
+	if !ok {
+		return nil, ErrGroupNotExist
+	}
+	return v, nil
+}
+
+// Get returns a group by name.
+func (g *GroupsService) Get(name string) (*Group, error) {
+	list, err := g.fetch(true)
+	if err != nil {
+		return nil, err
+	}
+	for _, gg := range list {
+		if gg.Name == name {
+			return gg, nil
+		}
+	}
+	return nil, ErrGroupNotExist
+}
+
+func (g *GroupsService) idMap() (map[string]*Group, error) { return g.fetch(false) }
+
+// fetch retrieves the groups, keyed by ID. If allowStale is true and the
+// StaleWhileRevalidate option is used, the groups may be those retrieved
+// previously.
+func (g *GroupsService) fetch(allowStale bool) (map[string]*Group, error) {
+	var (
+		msg   []byte
+		stale bool
+		err   error
+	)
+	if allowStale {
+		msg, stale, err = g.bridge.list("groups")
+	} else {
+		msg, err = g.bridge.call(http.MethodGet, nil, "groups")
+	}
+	if err != nil {
+		return nil, err
+	}
+	var all map[string]*Group
+	err = g.bridge.decode(msg, &all)
+	for id, gg := range all {
+		gg.bridge = g.bridge
+		gg.ID = id
+		gg.stale = stale
+	}
+	return all, err
+}
+
+// Group holds information about a group of lights.
+type Group struct {
+	bridge *Bridge
+
+	// stale is true if the group was retrieved from the responses kept by
+	// the StaleWhileRevalidate option.
+	stale bool
+
+	// ID is the ID that the bridge returns for this group.
+	ID string
+
+	// Name is a unique, editable name given to the group.
+	Name string `json:"name"`
+
+	// Lights holds the IDs of the lights that are members of this group.
+	Lights []string `json:"lights"`
+
+	// Type is the type of the group (e.g. "LightGroup", "Room").
+	Type string `json:"type"`
+
+	// Class is the archetype of a room (e.g. "Living room", "Office"). It is
+	// only used by groups of type "Room" and determines the icon shown in the
+	// official app. It is set when creating a room using CreateRoom, and
+	// changed using SetClass.
+	Class string `json:"class,omitempty"`
+
+	// Action holds the last command that was sent to the group.
+	Action LightState `json:"action"`
+
+	// Stream holds the streaming state of an entertainment group. It is nil
+	// for other groups.
+	Stream *GroupStream `json:"stream,omitempty"`
+
+	// Locations maps the IDs of the lights of an entertainment group to
+	// their positions, as arranged by the user. It is nil for other groups.
+	Locations map[string]Position `json:"locations,omitempty"`
+
+	// Sensors holds the IDs of the sensors linked to the group, if any.
+	Sensors []string `json:"sensors,omitempty"`
+
+	// Recycle, when true, allows the bridge to delete the group once no
+	// rule, schedule or resource link refers to it anymore, which suits
+	// temporary groups created by automations. Set it using the Recyclable
+	// option when creating the group.
+	Recycle bool `json:"recycle,omitempty"`
+}
+
+// Stale reports whether the group was returned from the responses kept by the
+// StaleWhileRevalidate option, and may thus be outdated.
+func (g *Group) Stale() bool { return g.stale }
+
+// Members returns a service which operates only on the lights of the group,
+// as known when the group was retrieved. Each of its methods fetches the
+// lights once, so that for example ForEach does not fetch every light again.
+// For the special group 0, it operates on all lights.
+func (g *Group) Members() *LightsService {
+	if g.ID == "0" {
+		return g.bridge.Lights()
+	}
+	scope := make(map[string]bool, len(g.Lights))
+	for _, id := range g.Lights {
+		scope[id] = true
+	}
+	return &LightsService{bridge: g.bridge, scope: scope}
+}
+
+// Delete deletes the group. If cascade is true, the rules and schedules which
+// refer to it are deleted as well and it is removed from resource links. The
+// bridge deletes the scenes of the group along with it. Groups which can not
+// be deleted, such as group 0, result in an APIError.
+func (g *Group) Delete(cascade bool) error {
+	return g.bridge.deleteResource(cascade, "groups", g.ID)
+}
+
+// Rename changes the name of the group. If the bridge would not accept the
+// name, a *NameError is returned.
+func (g *Group) Rename(name string) error {
+	name, err := g.bridge.validName(name)
+	if err != nil {
+		return err
+	}
+	_, err = g.bridge.call(http.MethodPut, map[string]string{
+		"name": name,
+	}, "groups", g.ID)
+	if err == nil {
+		g.Name = name
+	}
+	return err
+}
+
+// SetClass changes the class (archetype) of a room.
+func (g *Group) SetClass(class string) error {
+	_, err := g.bridge.call(http.MethodPut, map[string]string{
+		"class": class,
+	}, "groups", g.ID)
+	if err == nil {
+		g.Class = class
+	}
+	return err
+}
+
+// On turns all the lights in the group on.
+func (g *Group) On() error { return g.Set(&State{On: true}) }
+
+// Off turns all the lights in the group off.
+func (g *Group) Off() error {
+	return g.setAction(offState{TransitionTime: g.defaultTransition()})
+}
+
+// Breathe makes all the lights in the group perform one breathe cycle.
+func (g *Group) Breathe() error { return g.Set(&State{Alert: AlertSelect}) }
+
+// ColorLoop makes all the lights in the group cycle through all hues. To stop
+// it, set the Effect to NoEffect.
+func (g *Group) ColorLoop() error { return g.Set(&State{Effect: ColorLoop}) }
+
+// RecallScene applies the scene with the given ID to the lights in the group.
+func (g *Group) RecallScene(id string) error {
+	return g.setAction(map[string]string{"scene": id})
+}
+
+// Set sets the new state of all the lights in the group. Note that Set can not
+// turn the lights off. In order to do that, use the provided Off method. If s
+// does not set a transition time, the default of the group or bridge is used,
+// if any.
+func (g *Group) Set(s *State) error {
+	return g.setAction(statePayload(s, g.defaultTransition()))
+}
+
+// setAction sends the given payload to the action endpoint of the group and
+// refreshes it.
+func (g *Group) setAction(payload interface{}) error {
+	_, err := g.bridge.call(http.MethodPut, payload, "groups", g.ID, "action")
+	if err != nil {
+		return err
+	}
+	r, err := g.bridge.call(http.MethodGet, nil, "groups", g.ID)
+	if err != nil {
+		return err
+	}
+	return g.bridge.decode(r, g)
+}
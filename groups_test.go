@@ -0,0 +1,363 @@
+package hue
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+var testGroups = map[string]*Group{
+	"1": &Group{Name: "Living", Type: TypeRoom, Class: ClassLivingRoom, Lights: []string{"l1"}},
+	"2": &Group{Name: "Desk", Type: TypeLightGroup, Lights: []string{"l1", "l2"}},
+}
+
+func TestGroupsService(t *testing.T) {
+	mb := mockBridge(t)
+	defer mb.teardown()
+	mb.nextResponse = testGroups
+
+	t.Run("List", func(t *testing.T) {
+		list, err := mb.b.Groups().List()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(list) != len(testGroups) {
+			t.Fatalf("expected %d entries, got %d", len(testGroups), len(list))
+		}
+		for _, g := range list {
+			if g.ID == "" || g.bridge != mb.b {
+				t.Fatalf("expected to link IDs and bridges")
+			}
+		}
+	})
+
+	t.Run("Get", func(t *testing.T) {
+		g, err := mb.b.Groups().Get("Living")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if g.ID != "1" || g.Class != ClassLivingRoom {
+			t.Fatalf("unexpected group %+v", g)
+		}
+		if _, err := mb.b.Groups().Get("bogus"); err != ErrGroupNotExist {
+			t.Fatalf("expected ErrGroupNotExist, got %v", err)
+		}
+	})
+
+	t.Run("GetByID", func(t *testing.T) {
+		g, err := mb.b.Groups().GetByID("2")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if g.Name != "Desk" {
+			t.Fatalf("unexpected group %+v", g)
+		}
+		if _, err := mb.b.Groups().GetByID("bogus"); err != ErrGroupNotExist {
+			t.Fatalf("expected ErrGroupNotExist, got %v", err)
+		}
+	})
+
+	t.Run("CreateRoom", func(t *testing.T) {
+		mb.nextResponse = json.RawMessage(`[{"success": {"id": "3"}}]`)
+		defer func() { mb.nextResponse = testGroups }()
+		g, err := mb.b.Groups().CreateRoom("Study", ClassOffice, []string{"l2"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if g.ID != "3" || g.Class != ClassOffice || g.Type != TypeRoom {
+			t.Fatalf("unexpected group %+v", g)
+		}
+		if mb.lastMethod != http.MethodPost || mb.lastPath != "/api/bridge_username/groups" {
+			t.Fatalf("unexpected request %s %s", mb.lastMethod, mb.lastPath)
+		}
+		var body map[string]interface{}
+		if err := json.NewDecoder(mb.lastBody).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		want := map[string]interface{}{"name": "Study", "lights": []interface{}{"l2"}, "type": TypeRoom, "class": ClassOffice}
+		if !reflect.DeepEqual(body, want) {
+			t.Fatalf("expected %v, got %v", want, body)
+		}
+	})
+}
+
+func TestGroup(t *testing.T) {
+	mb := mockBridge(t)
+	defer mb.teardown()
+	mb.nextResponse = testGroups
+
+	t.Run("SetClass", func(t *testing.T) {
+		g, err := mb.b.Groups().Get("Living")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := g.SetClass(ClassOffice); err != nil {
+			t.Fatal(err)
+		}
+		if g.Class != ClassOffice {
+			t.Fatalf("expected class to become %q, got %q", ClassOffice, g.Class)
+		}
+		if mb.lastMethod != http.MethodPut || mb.lastPath != "/api/bridge_username/groups/1" {
+			t.Fatalf("unexpected request %s %s", mb.lastMethod, mb.lastPath)
+		}
+		var body map[string]string
+		if err := json.NewDecoder(mb.lastBody).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		if want := map[string]string{"class": ClassOffice}; !reflect.DeepEqual(body, want) {
+			t.Fatalf("expected %v, got %v", want, body)
+		}
+	})
+	t.Run("All", func(t *testing.T) {
+		g := mb.b.Groups().All()
+		if err := g.Breathe(); err != nil {
+			t.Fatal(err)
+		}
+		// the last request refreshes the group
+		if mb.lastMethod != http.MethodGet || mb.lastPath != "/api/bridge_username/groups/0" {
+			t.Fatalf("unexpected request %s %s", mb.lastMethod, mb.lastPath)
+		}
+	})
+}
+
+func TestCreateFromNames(t *testing.T) {
+	mb := mockBridge(t)
+	defer mb.teardown()
+	mb.responses = map[string]interface{}{
+		"/api/bridge_username/lights": map[string]*Light{
+			"1": &Light{Name: "Desk"},
+			"2": &Light{Name: "Couch"},
+			"3": &Light{Name: "Couch"},
+		},
+		"/api/bridge_username/groups": []interface{}{
+			map[string]interface{}{"success": map[string]string{"id": "5"}},
+		},
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		g, err := mb.b.Groups().CreateFromNames("Work", "Desk")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if g.ID != "5" || g.bridge != mb.b || !reflect.DeepEqual(g.Lights, []string{"1"}) {
+			t.Fatalf("unexpected group %+v", g)
+		}
+		var got map[string]interface{}
+		if err := json.NewDecoder(mb.lastBody).Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+		want := map[string]interface{}{
+			"name":   "Work",
+			"lights": []interface{}{"1"},
+			"type":   TypeLightGroup,
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("expected %v, got %v", want, got)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		for name, want := range map[string]*LightNameError{
+			"Couch": {Name: "Couch", IDs: []string{"2", "3"}},
+			"Bogus": {Name: "Bogus"},
+		} {
+			_, err := mb.b.Groups().CreateFromNames("Work", "Desk", name)
+			if !reflect.DeepEqual(err, want) {
+				t.Fatalf("expected %v, got %v", want, err)
+			}
+		}
+	})
+}
+
+func TestGroupsCreate(t *testing.T) {
+	mb := mockBridge(t)
+	defer mb.teardown()
+	mb.nextResponse = []interface{}{
+		map[string]interface{}{"success": map[string]string{"id": "8"}},
+	}
+	for _, typ := range []string{TypeRoom, ""} {
+		g, err := mb.b.Groups().Create("Kitchen", []string{"1", "4"}, typ)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if typ == "" {
+			typ = TypeLightGroup
+		}
+		if g.ID != "8" || g.Type != typ || g.bridge != mb.b {
+			t.Fatalf("unexpected group %+v", g)
+		}
+		if mb.lastMethod != http.MethodPost || mb.lastPath != "/api/bridge_username/groups" {
+			t.Fatalf("unexpected request %s %s", mb.lastMethod, mb.lastPath)
+		}
+		var got map[string]interface{}
+		if err := json.NewDecoder(mb.lastBody).Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+		want := map[string]interface{}{
+			"name":   "Kitchen",
+			"lights": []interface{}{"1", "4"},
+			"type":   typ,
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("expected %v, got %v", want, got)
+		}
+	}
+	mb.nextResponse = []interface{}{
+		map[string]interface{}{"error": APIError{Code: 302, Msg: "too many groups"}},
+	}
+	_, err := mb.b.Groups().Create("Hall", []string{"2"}, "")
+	if aerr, ok := err.(APIError); !ok || aerr.Code != 302 {
+		t.Fatalf("expected an APIError, got %v", err)
+	}
+}
+
+func TestCreateRoom(t *testing.T) {
+	mb := mockBridge(t)
+	defer mb.teardown()
+	mb.responses = map[string]interface{}{
+		"/api/bridge_username/groups": map[string]*Group{
+			"1": &Group{Name: "Kitchen", Type: TypeRoom, Lights: []string{"1", "2"}},
+			"2": &Group{Name: "Downstairs", Type: TypeZone, Lights: []string{"3"}},
+		},
+	}
+	_, err := mb.b.Groups().CreateRoom("Dining", ClassDining, []string{"3", "2"})
+	if cerr, ok := err.(*RoomConflictError); !ok || cerr.Light != "2" || cerr.Room.ID != "1" {
+		t.Fatalf("expected a *RoomConflictError, got %v", err)
+	}
+	_, err = mb.b.Groups().Create("Dining", []string{"2"}, TypeRoom)
+	if _, ok := err.(*RoomConflictError); !ok {
+		t.Fatalf("expected Create to check rooms too, got %v", err)
+	}
+	if r, err := mb.b.Groups().RoomOf("3"); err != nil || r != nil {
+		t.Fatalf("expected no room, got %v (%v)", r, err)
+	}
+	if r, err := mb.b.Groups().RoomOf("1"); err != nil || r == nil || r.Name != "Kitchen" {
+		t.Fatalf("expected the kitchen, got %v (%v)", r, err)
+	}
+
+	var body map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			w.Write([]byte(`{"1": {"name": "Kitchen", "type": "Room", "lights": ["1"]}}`))
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Write([]byte(`[{"success": {"id": "4"}}]`))
+	}))
+	defer srv.Close()
+	b := &Bridge{bridgeID: bridgeID{IP: srv.URL + "/"}, username: "u"}
+	g, err := b.Groups().CreateRoom("Dining", "", []string{"3", "2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.ID != "4" || g.Type != TypeRoom || g.Class != ClassOther {
+		t.Fatalf("unexpected room %+v", g)
+	}
+	want := map[string]interface{}{
+		"name":   "Dining",
+		"lights": []interface{}{"3", "2"},
+		"type":   TypeRoom,
+		"class":  ClassOther,
+	}
+	if !reflect.DeepEqual(body, want) {
+		t.Fatalf("expected %v, got %v", want, body)
+	}
+}
+
+func TestCreateRecyclable(t *testing.T) {
+	mb := mockBridge(t)
+	defer mb.teardown()
+	mb.nextResponse = []interface{}{
+		map[string]interface{}{"success": map[string]string{"id": "9"}},
+	}
+	grp, err := mb.b.Groups().Create("Temporary", []string{"1"}, "", Recyclable())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if grp.ID != "9" || grp.Type != TypeLightGroup || !grp.Recycle {
+		t.Fatalf("unexpected group %+v", grp)
+	}
+	var got map[string]interface{}
+	if err := json.NewDecoder(mb.lastBody).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if got["recycle"] != true {
+		t.Fatalf("expected the group to be recyclable, got %v", got)
+	}
+
+	mb.nextResponse = json.RawMessage(`{"1": {"name": "a", "recycle": true}, "2": {"name": "b"}}`)
+	all, err := mb.b.Groups().idMap()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !all["1"].Recycle || all["2"].Recycle {
+		t.Fatalf("unexpected recycle flags %+v %+v", all["1"], all["2"])
+	}
+}
+
+func TestGroupDelete(t *testing.T) {
+	mb := mockBridge(t)
+	defer mb.teardown()
+	mb.nextResponse = []interface{}{
+		map[string]string{"success": "/groups/3 deleted"},
+	}
+	if err := (&Group{bridge: mb.b, ID: "3"}).Delete(false); err != nil {
+		t.Fatal(err)
+	}
+	if mb.lastMethod != http.MethodDelete || mb.lastPath != "/api/bridge_username/groups/3" {
+		t.Fatalf("unexpected request %s %s", mb.lastMethod, mb.lastPath)
+	}
+	mb.nextResponse = []interface{}{
+		map[string]interface{}{"error": APIError{Code: 305, Msg: "It is not allowed to update or delete group of this type"}},
+	}
+	err := mb.b.Groups().All().Delete(false)
+	if aerr, ok := err.(APIError); !ok || aerr.Code != 305 {
+		t.Fatalf("expected an APIError, got %v", err)
+	}
+}
+
+func BenchmarkGroupSet(b *testing.B) {
+	mb := mockBridge(b)
+	defer mb.teardown()
+	mb.nextResponse = &Group{Name: "g1", Lights: []string{"1", "2"}}
+	g := &Group{bridge: mb.b, ID: "1"}
+	s := &State{On: true, Brightness: 100, XY: &XY{0.3, 0.4}, TransitionTime: 1}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := g.Set(s); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestGroupMembers(t *testing.T) {
+	mb := mockBridge(t)
+	defer mb.teardown()
+	mb.nextResponse = json.RawMessage(`{"1": {"name": "a"}, "2": {"name": "b"}, "3": {"name": "c"}}`)
+	g := &Group{bridge: mb.b, ID: "4", Lights: []string{"1", "3"}}
+	var got []string
+	if err := g.Members().ForEach(func(l *Light) { got = append(got, l.ID) }); err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(got)
+	if want := []string{"1", "3"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	if n, err := g.Members().Count(); err != nil || n != 2 {
+		t.Fatalf("expected 2 lights, got %d (%v)", n, err)
+	}
+	if _, err := g.Members().Get("b"); err != ErrNotExist {
+		t.Fatalf("expected ErrNotExist, got %v", err)
+	}
+	if ok, err := g.Members().Exists("2"); err != nil || ok {
+		t.Fatalf("expected light 2 not to be a member, got %v (%v)", ok, err)
+	}
+	all := mb.b.Groups().All()
+	if n, err := all.Members().Count(); err != nil || n != 3 {
+		t.Fatalf("expected 3 lights, got %d (%v)", n, err)
+	}
+}
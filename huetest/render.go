@@ -0,0 +1,89 @@
+package huetest
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"sort"
+	"time"
+
+	"gbbr.io/hue"
+)
+
+// samples returns the state of each light in the timeline at intervals of
+// step, from the first frame until the last, keyed by light ID, along with
+// the sorted IDs.
+func samples(timeline []Frame, step time.Duration) ([]string, map[string][]hue.LightState) {
+	out := make(map[string][]hue.LightState)
+	if len(timeline) == 0 || step <= 0 {
+		return nil, out
+	}
+	start, end := timeline[0].Time, timeline[len(timeline)-1].Time
+	cur := make(map[string]hue.LightState)
+	var ids []string
+	i := 0
+	for t := start; !t.After(end); t = t.Add(step) {
+		for ; i < len(timeline) && !timeline[i].Time.After(t); i++ {
+			f := timeline[i]
+			if _, ok := cur[f.Light]; !ok {
+				ids = append(ids, f.Light)
+			}
+			cur[f.Light] = f.State
+		}
+		for _, id := range ids {
+			out[id] = append(out[id], cur[id])
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return lessID(ids[i], ids[j]) })
+	// lights first seen after the start lack their earliest samples
+	n := int(end.Sub(start)/step) + 1
+	for _, id := range ids {
+		if pad := n - len(out[id]); pad > 0 {
+			out[id] = append(make([]hue.LightState, pad), out[id]...)
+		}
+	}
+	return ids, out
+}
+
+// RenderANSI writes a preview of the timeline to w, as one row of colored
+// blocks per light, with a block for every step of simulated time. It uses
+// 24-bit color escape sequences, which most terminals support.
+func (s *Simulator) RenderANSI(w io.Writer, step time.Duration) error {
+	ids, rows := samples(s.Timeline(), step)
+	bw := bufio.NewWriter(w)
+	for _, id := range ids {
+		s.mu.Lock()
+		name := s.lights[id].Name
+		s.mu.Unlock()
+		fmt.Fprintf(bw, "%-12.12s ", name)
+		for _, st := range rows[id] {
+			c := st.RGB()
+			fmt.Fprintf(bw, "\x1b[48;2;%d;%d;%dm \x1b[0m", c.R, c.G, c.B)
+		}
+		fmt.Fprintln(bw)
+	}
+	return bw.Flush()
+}
+
+// RenderPNG writes a preview of the timeline to w as a PNG image, with a row
+// of the given height in pixels per light and a pixel column for every step of
+// simulated time.
+func (s *Simulator) RenderPNG(w io.Writer, step time.Duration, height int) error {
+	ids, rows := samples(s.Timeline(), step)
+	var width int
+	if len(ids) > 0 {
+		width = len(rows[ids[0]])
+	}
+	img := image.NewRGBA(image.Rect(0, 0, width, height*len(ids)))
+	for row, id := range ids {
+		for x, st := range rows[id] {
+			c := st.RGB()
+			for y := row * height; y < (row+1)*height; y++ {
+				img.SetRGBA(x, y, c)
+			}
+		}
+	}
+	return png.Encode(w, img)
+}
@@ -0,0 +1,322 @@
+// Package huetest provides a simulated bridge for testing and previewing
+// programs which use package hue, without physical lights.
+package huetest // import "gbbr.io/hue/huetest"
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gbbr.io/hue"
+)
+
+// Simulator is a fake bridge which runs in the process and records the state
+// of its lights over time, so that lighting sequences and scenes can be
+// previewed without physical lights. It is also a hue.Clock: running steps
+// using Step.RunWithClock with the simulator as the clock completes them at
+// once, in simulated time. Transitions are applied instantly.
+type Simulator struct {
+	srv *httptest.Server
+
+	mu       sync.Mutex
+	t        time.Time
+	lights   map[string]*simLight
+	groups   map[string]*simGroup
+	scenes   map[string]*simScene
+	timeline []Frame
+}
+
+type simLight struct {
+	Name    string         `json:"name"`
+	Type    string         `json:"type"`
+	ModelID string         `json:"modelid"`
+	State   hue.LightState `json:"state"`
+}
+
+type simGroup struct {
+	Name   string   `json:"name"`
+	Lights []string `json:"lights"`
+	Type   string   `json:"type"`
+}
+
+type simScene struct {
+	Name        string                         `json:"name"`
+	Lights      []string                       `json:"lights"`
+	LightStates map[string]hue.SceneLightState `json:"lightstates"`
+}
+
+// Frame records the state of a light from a point in simulated time on.
+type Frame struct {
+	// Time is the simulated time at which the light changed.
+	Time time.Time
+
+	// Light is the ID of the light.
+	Light string
+
+	// State is the state of the light.
+	State hue.LightState
+}
+
+// NewSimulator starts a simulator with color lights of the given names, which
+// are given the IDs "1", "2" and so on. They are off initially. Simulated time
+// starts at start. The simulator should be closed after use.
+func NewSimulator(start time.Time, lights ...string) *Simulator {
+	s := &Simulator{
+		t:      start,
+		lights: make(map[string]*simLight),
+		groups: make(map[string]*simGroup),
+		scenes: make(map[string]*simScene),
+	}
+	for i, name := range lights {
+		id := fmt.Sprint(i + 1)
+		s.lights[id] = &simLight{
+			Name:    name,
+			Type:    "Extended color light",
+			ModelID: "LCT015",
+			State:   hue.LightState{Brightness: 254, XY: hue.XY{0.4573, 0.41}, ColorTemp: 366, ColorMode: "ct", Reachable: true},
+		}
+		s.record(id)
+	}
+	s.srv = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// Close stops the simulator.
+func (s *Simulator) Close() { s.srv.Close() }
+
+// Bridge returns a bridge connected to the simulator, which uses it as its
+// clock. The given options are applied after WithClock.
+func (s *Simulator) Bridge(opts ...hue.Option) *hue.Bridge {
+	opts = append([]hue.Option{hue.WithClock(s)}, opts...)
+	return hue.New(s.srv.Listener.Addr().String(), "simulator", opts...)
+}
+
+// AddGroup adds a group of type Room with the given name and lights and
+// returns its ID.
+func (s *Simulator) AddGroup(name string, lights ...string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := fmt.Sprint(len(s.groups) + 1)
+	s.groups[id] = &simGroup{Name: name, Lights: lights, Type: hue.TypeRoom}
+	return id
+}
+
+// AddScene adds a scene with the given name and light states and returns its
+// ID.
+func (s *Simulator) AddScene(name string, states map[string]hue.SceneLightState) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := fmt.Sprintf("sim%d", len(s.scenes)+1)
+	sc := &simScene{Name: name, LightStates: states}
+	for lid := range states {
+		sc.Lights = append(sc.Lights, lid)
+	}
+	sort.Slice(sc.Lights, func(i, j int) bool { return lessID(sc.Lights[i], sc.Lights[j]) })
+	s.scenes[id] = sc
+	return id
+}
+
+// Now returns the simulated time.
+func (s *Simulator) Now() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.t
+}
+
+// After advances the simulated time by d and returns a channel on which the
+// new time is ready.
+func (s *Simulator) After(d time.Duration) <-chan time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.t = s.t.Add(d)
+	ch := make(chan time.Time, 1)
+	ch <- s.t
+	return ch
+}
+
+// Timeline returns the recorded changes of the lights, in order.
+func (s *Simulator) Timeline() []Frame {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Frame(nil), s.timeline...)
+}
+
+// record records the current state of the light with the given ID. s.mu must
+// be held.
+func (s *Simulator) record(id string) {
+	s.timeline = append(s.timeline, Frame{Time: s.t, Light: id, State: s.lights[id].State})
+}
+
+// errResourceNotAvailable is the APIError code returned for unknown
+// resources.
+const errResourceNotAvailable = 3
+
+// serve handles requests to the API.
+func (s *Simulator) serve(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) < 3 || parts[0] != "api" {
+		s.fail(w, r, errResourceNotAvailable, "resource not available")
+		return
+	}
+	parts = parts[2:]
+	var resp interface{}
+	switch {
+	case r.Method == http.MethodGet && len(parts) == 1 && parts[0] == "lights":
+		resp = s.lights
+	case r.Method == http.MethodGet && len(parts) == 1 && parts[0] == "groups":
+		resp = s.groups
+	case r.Method == http.MethodGet && len(parts) == 1 && parts[0] == "scenes":
+		resp = s.scenes
+	case r.Method == http.MethodGet && len(parts) == 2 && parts[0] == "lights" && s.lights[parts[1]] != nil:
+		resp = s.lights[parts[1]]
+	case r.Method == http.MethodGet && len(parts) == 2 && parts[0] == "groups" && parts[1] == "0":
+		resp = &simGroup{Name: "Group 0", Lights: s.lightIDs(), Type: hue.TypeLightGroup}
+	case r.Method == http.MethodGet && len(parts) == 2 && parts[0] == "groups" && s.groups[parts[1]] != nil:
+		resp = s.groups[parts[1]]
+	case r.Method == http.MethodGet && len(parts) == 2 && parts[0] == "scenes" && s.scenes[parts[1]] != nil:
+		resp = s.scenes[parts[1]]
+	case r.Method == http.MethodPut && len(parts) == 3 && parts[0] == "lights" && parts[2] == "state" && s.lights[parts[1]] != nil:
+		resp = s.apply(r, []string{parts[1]})
+	case r.Method == http.MethodPut && len(parts) == 3 && parts[0] == "groups" && parts[2] == "action":
+		var ids []string
+		if parts[1] == "0" {
+			ids = s.lightIDs()
+		} else if g, ok := s.groups[parts[1]]; ok {
+			ids = g.Lights
+		} else {
+			s.fail(w, r, errResourceNotAvailable, "resource not available")
+			return
+		}
+		resp = s.apply(r, ids)
+	default:
+		s.fail(w, r, errResourceNotAvailable, "resource not available")
+		return
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+// lightIDs returns the sorted IDs of the lights. s.mu must be held.
+func (s *Simulator) lightIDs() []string {
+	var ids []string
+	for id := range s.lights {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return lessID(ids[i], ids[j]) })
+	return ids
+}
+
+// fail writes an error response.
+func (s *Simulator) fail(w http.ResponseWriter, r *http.Request, code int, msg string) {
+	json.NewEncoder(w).Encode([]map[string]hue.APIError{{
+		"error": {Code: code, URL: r.URL.Path, Msg: msg},
+	}})
+}
+
+// apply applies the state in the body of request r to the lights with the
+// given IDs and returns the response.
+func (s *Simulator) apply(r *http.Request, ids []string) interface{} {
+	var body struct {
+		On     *bool      `json:"on"`
+		Bri    *uint8     `json:"bri"`
+		Hue    *uint16    `json:"hue"`
+		Sat    *uint8     `json:"sat"`
+		XY     *hue.XY    `json:"xy"`
+		CT     *hue.Mired `json:"ct"`
+		Effect *string    `json:"effect"`
+		Alert  *string    `json:"alert"`
+		BriInc *int       `json:"bri_inc"`
+		Scene  *string    `json:"scene"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		return []map[string]hue.APIError{{"error": {Code: 2, URL: r.URL.Path, Msg: err.Error()}}}
+	}
+	for _, id := range ids {
+		l, ok := s.lights[id]
+		if !ok {
+			continue
+		}
+		st := &l.State
+		if body.Scene != nil {
+			if sc, ok := s.scenes[*body.Scene]; ok {
+				if ls, ok := sc.LightStates[id]; ok {
+					applySceneState(st, &ls)
+				}
+			}
+		}
+		if body.On != nil {
+			st.On = *body.On
+		}
+		if body.Bri != nil {
+			st.Brightness = *body.Bri
+		} else if body.BriInc != nil {
+			st.Brightness = uint8(clampInt(int(st.Brightness)+*body.BriInc, 1, 254))
+		}
+		if body.Hue != nil {
+			st.Hue, st.ColorMode = *body.Hue, "hs"
+		}
+		if body.Sat != nil {
+			st.Saturation, st.ColorMode = *body.Sat, "hs"
+		}
+		if body.XY != nil {
+			st.XY, st.ColorMode = *body.XY, "xy"
+		}
+		if body.CT != nil {
+			st.ColorTemp, st.ColorMode = *body.CT, "ct"
+		}
+		if body.Effect != nil {
+			st.Effect = *body.Effect
+		}
+		if body.Alert != nil {
+			st.Alert = *body.Alert
+		}
+		s.record(id)
+	}
+	return []map[string]interface{}{{"success": map[string]interface{}{r.URL.Path: true}}}
+}
+
+// applySceneState applies the state of a light in a scene to st.
+func applySceneState(st *hue.LightState, ls *hue.SceneLightState) {
+	st.On = ls.On
+	if ls.Brightness != nil {
+		st.Brightness = *ls.Brightness
+	}
+	if ls.XY != nil {
+		st.XY, st.ColorMode = *ls.XY, "xy"
+	}
+	if ls.ColorTemp != nil {
+		st.ColorTemp, st.ColorMode = *ls.ColorTemp, "ct"
+	}
+	if ls.Hue != nil {
+		st.Hue, st.ColorMode = *ls.Hue, "hs"
+	}
+	if ls.Saturation != nil {
+		st.Saturation, st.ColorMode = *ls.Saturation, "hs"
+	}
+}
+
+// clampInt returns v, limited to the range [min, max].
+func clampInt(v, min, max int) int {
+	if v < min {
+		return min
+	}
+	if v > max {
+		return max
+	}
+	return v
+}
+
+// lessID reports whether id a sorts before id b. IDs assigned by the bridge
+// are numeric, so shorter IDs sort first.
+func lessID(a, b string) bool {
+	if len(a) != len(b) {
+		return len(a) < len(b)
+	}
+	return a < b
+}
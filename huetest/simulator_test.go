@@ -0,0 +1,63 @@
+package huetest
+
+import (
+	"bytes"
+	"context"
+	"image/png"
+	"strings"
+	"testing"
+	"time"
+
+	"gbbr.io/hue"
+)
+
+func TestSimulator(t *testing.T) {
+	start := time.Date(2018, 1, 1, 20, 0, 0, 0, time.UTC)
+	sim := NewSimulator(start, "Desk", "Hall")
+	defer sim.Close()
+	b := sim.Bridge()
+	hall, err := b.Groups().GetByID(sim.AddGroup("Hall", "2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := hue.Sequence(
+		hue.SetGroup(b.Groups().All(), &hue.State{On: true, Hue: 0, Saturation: 254, Brightness: 254}),
+		hue.Wait(time.Minute),
+		hue.GroupOff(hall),
+		hue.Wait(time.Minute),
+	)
+	if err := s.RunWithClock(context.Background(), sim, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := sim.Now(); !got.Equal(start.Add(2 * time.Minute)) {
+		t.Fatalf("unexpected simulated time %v", got)
+	}
+	tl := sim.Timeline()
+	last := tl[len(tl)-1]
+	if last.Light != "2" || last.State.On || !last.Time.Equal(start.Add(time.Minute)) {
+		t.Fatalf("unexpected last frame %+v", last)
+	}
+	if c := tl[2].State.RGB(); c.R != 255 || c.G != 0 || c.B != 0 {
+		t.Fatalf("expected red, got %v", c)
+	}
+
+	var buf bytes.Buffer
+	if err := sim.RenderANSI(&buf, 30*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 || strings.Count(lines[1], "\x1b[48;2;") != 3 {
+		t.Fatalf("unexpected preview %q", buf.String())
+	}
+	buf.Reset()
+	if err := sim.RenderPNG(&buf, 30*time.Second, 4); err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := img.Bounds(); r.Dx() != 3 || r.Dy() != 8 {
+		t.Fatalf("unexpected image size %v", r)
+	}
+}
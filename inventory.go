@@ -0,0 +1,126 @@
+package hue
+
+import (
+	"sort"
+)
+
+// Inventory is a report of all the devices known to the bridge.
+type Inventory struct {
+	// Lights holds an entry for each light, sorted by ID.
+	Lights []Device `json:"lights"`
+
+	// Sensors holds an entry for each sensor, sorted by ID.
+	Sensors []Device `json:"sensors"`
+}
+
+// Device holds inventory information about a light or a sensor.
+type Device struct {
+	ID               string `json:"id"`
+	UID              string `json:"uniqueid,omitempty"`
+	Name             string `json:"name"`
+	Type             string `json:"type"`
+	ModelID          string `json:"modelid"`
+	ManufacturerName string `json:"manufacturername"`
+	SWVersion        string `json:"swversion"`
+	Reachable        bool   `json:"reachable"`
+
+	// Battery is the battery level in percent, if the device is battery
+	// powered.
+	Battery *uint8 `json:"battery,omitempty"`
+
+	// ThirdParty is true for lights which are not certified Hue lights.
+	ThirdParty bool `json:"thirdparty,omitempty"`
+
+	// Room is the name of the room that the device is assigned to, if any.
+	Room string `json:"room,omitempty"`
+
+	// LastSeen is the last time that the device reported a change, if known,
+	// in UTC. For sensors, this is the time their state was last updated. For
+	// lights, it is the last time they were seen to be reachable, which is
+	// only known with the TrackReachability option.
+	LastSeen string `json:"lastseen,omitempty"`
+}
+
+// Inventory returns a report of all the lights and sensors known to the
+// bridge. The result is sorted and thus suitable for comparing over time.
+// With the StaleWhileRevalidate option, the report may be built from the
+// previously retrieved lists of lights and groups.
+func (b *Bridge) Inventory() (*Inventory, error) {
+	lights, err := b.Lights().List()
+	if err != nil {
+		return nil, err
+	}
+	sensors, err := b.Sensors().List()
+	if err != nil {
+		return nil, err
+	}
+	groups, err := b.Groups().List()
+	if err != nil {
+		return nil, err
+	}
+	rooms := make(map[string]string)
+	for _, g := range groups {
+		if g.Type != TypeRoom {
+			continue
+		}
+		for _, id := range g.Lights {
+			rooms[id] = g.Name
+		}
+	}
+	inv := &Inventory{
+		Lights:  make([]Device, 0, len(lights)),
+		Sensors: make([]Device, 0, len(sensors)),
+	}
+	for _, l := range lights {
+		var seen string
+		if t := l.LastSeen(); !t.IsZero() {
+			seen = t.UTC().Format(timeLayout)
+		}
+		inv.Lights = append(inv.Lights, Device{
+			ID:               l.ID,
+			UID:              l.UID,
+			Name:             l.Name,
+			Type:             l.Type,
+			ModelID:          l.ModelID,
+			ManufacturerName: l.ManufacturerName,
+			SWVersion:        l.SWVersion,
+			Reachable:        l.State.Reachable,
+			ThirdParty:       l.ThirdParty(),
+			Room:             rooms[l.ID],
+			LastSeen:         seen,
+		})
+	}
+	for _, s := range sensors {
+		inv.Sensors = append(inv.Sensors, Device{
+			ID:               s.ID,
+			UID:              s.UID,
+			Name:             s.Name,
+			Type:             s.Type,
+			ModelID:          s.ModelID,
+			ManufacturerName: s.ManufacturerName,
+			SWVersion:        s.SWVersion,
+			Reachable:        s.Config.Reachable,
+			Battery:          s.Config.Battery,
+			LastSeen:         s.State.LastUpdated,
+		})
+	}
+	sort.Sort(byID(inv.Lights))
+	sort.Sort(byID(inv.Sensors))
+	return inv, nil
+}
+
+// byID sorts devices by their ID.
+type byID []Device
+
+func (d byID) Len() int           { return len(d) }
+func (d byID) Swap(i, j int)      { d[i], d[j] = d[j], d[i] }
+func (d byID) Less(i, j int) bool { return lessID(d[i].ID, d[j].ID) }
+
+// lessID reports whether id a sorts before id b. IDs assigned by the bridge
+// are numeric, so shorter IDs sort first.
+func lessID(a, b string) bool {
+	if len(a) != len(b) {
+		return len(a) < len(b)
+	}
+	return a < b
+}
@@ -0,0 +1,63 @@
+package hue
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestInventory(t *testing.T) {
+	mb := mockBridge(t)
+	defer mb.teardown()
+	battery := uint8(42)
+	mb.responses = map[string]interface{}{
+		"/api/bridge_username/lights": map[string]*Light{
+			"10": &Light{
+				Name:         "Hall",
+				ModelID:      "TRADFRI bulb E27",
+				State:        LightState{Reachable: true},
+				Capabilities: &LightCapabilities{Certified: false},
+			},
+			"2": &Light{Name: "Desk", ModelID: "LCT007", ManufacturerName: "Philips"},
+		},
+		"/api/bridge_username/sensors": map[string]*Sensor{
+			"5": &Sensor{
+				Name:   "Motion",
+				Type:   "ZLLPresence",
+				State:  SensorState{LastUpdated: "2016-10-16T07:30:00"},
+				Config: SensorConfig{Reachable: true, Battery: &battery},
+			},
+		},
+		"/api/bridge_username/groups": map[string]*Group{
+			"1": &Group{Name: "Office", Type: TypeRoom, Lights: []string{"2"}},
+			"3": &Group{Name: "All but office", Type: TypeLightGroup, Lights: []string{"10"}},
+		},
+	}
+	clock := new(fakeClock)
+	clock.set(time.Date(2016, 10, 16, 8, 0, 0, 0, time.UTC))
+	mb.b.clock = clock
+	mb.b.reach = new(reachability)
+	inv, err := mb.b.Inventory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Inventory{
+		Lights: []Device{
+			{ID: "2", Name: "Desk", ModelID: "LCT007", ManufacturerName: "Philips", Room: "Office"},
+			{ID: "10", Name: "Hall", ModelID: "TRADFRI bulb E27", Reachable: true, ThirdParty: true, LastSeen: "2016-10-16T08:00:00"},
+		},
+		Sensors: []Device{
+			{
+				ID:        "5",
+				Name:      "Motion",
+				Type:      "ZLLPresence",
+				Reachable: true,
+				Battery:   &battery,
+				LastSeen:  "2016-10-16T07:30:00",
+			},
+		},
+	}
+	if !reflect.DeepEqual(inv, want) {
+		t.Fatalf("expected %+v, got %+v", want, inv)
+	}
+}
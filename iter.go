@@ -0,0 +1,289 @@
+package hue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// rawIterator decodes a listing of resources, keyed by ID, incrementally
+// while it is received from the bridge.
+type rawIterator struct {
+	bridge *Bridge
+	tokens []string
+	start  time.Time
+
+	resp   *http.Response
+	cancel context.CancelFunc
+	dec    *json.Decoder
+
+	id   string
+	raw  json.RawMessage
+	err  error
+	done bool
+}
+
+// iterate starts retrieving the listing at tokens. It applies the options of
+// the bridge which concern reads, like call does: features which the bridge
+// does not support are rejected, busy responses result in a *BusyError,
+// rejected credentials are repaired once using the flow set by WithRepair and
+// the secondary gateway set by WithSecondary is used while the bridge is
+// unreachable. Listings are not coalesced with concurrent reads, nor kept by
+// the cache of StaleWhileRevalidate. Being reads, they are not recorded by the
+// audit log or the journal either.
+func (b *Bridge) iterate(tokens ...string) *rawIterator {
+	it := &rawIterator{bridge: b, tokens: tokens, start: time.Now()}
+	if f := resourceFeature(tokens); f != "" {
+		if err := b.supports(f); err != nil {
+			it.finish(err)
+			return it
+		}
+	}
+	if b.repair == nil {
+		if err := it.open(*b); err != nil {
+			it.finish(err)
+		}
+		return it
+	}
+	c := b.repair.snapshot(b)
+	err := it.open(c)
+	if err == ErrUnauthorized {
+		if err = b.repair.run(b, c.username); err == nil {
+			err = it.open(b.repair.snapshot(b))
+		}
+	}
+	if err != nil {
+		it.finish(err)
+	}
+	return it
+}
+
+// open requests the listing from bridge c, or from its secondary gateway while
+// c is unreachable, and reads up to the first resource.
+func (it *rawIterator) open(c Bridge) error {
+	f := c.failover
+	if f == nil {
+		return it.request(c, 0)
+	}
+	if f.primaryDown(c) {
+		return it.request(f.secondary, 0)
+	}
+	err := it.request(c, primaryReadTimeout)
+	if !unreachable(err) {
+		return err
+	}
+	f.markDown(c)
+	return it.request(f.secondary, 0)
+}
+
+// request requests the listing from bridge c and reads up to the first
+// resource. If timeout is non-zero, the response must start within it.
+func (it *rawIterator) request(c Bridge, timeout time.Duration) error {
+	it.release()
+	url := c.addr(it.tokens...)
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := c.limits.context()
+	it.cancel = cancel
+	if timeout > 0 {
+		t := time.AfterFunc(timeout, cancel)
+		defer t.Stop()
+	}
+	it.resp, err = http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return c.limits.check(ctx, url, err)
+	}
+	if err := busy(url, it.resp, time.Now()); err != nil {
+		return err
+	}
+	it.dec = json.NewDecoder(c.limits.reader(url, it.resp.Body))
+	tok, err := it.dec.Token()
+	switch {
+	case err != nil:
+		return c.limits.check(ctx, url, err)
+	case tok == json.Delim('['):
+		return it.apiError()
+	case tok != json.Delim('{'):
+		return fmt.Errorf("bad response: unexpected %v", tok)
+	}
+	return nil
+}
+
+// apiError returns the error reported by a response which is an array.
+func (it *rawIterator) apiError() error {
+	for it.dec.More() {
+		var e struct {
+			Err APIError `json:"error"`
+		}
+		if err := it.dec.Decode(&e); err != nil {
+			return err
+		}
+		switch e.Err.Code {
+		case 0:
+			continue
+		case errUnauthorized:
+			return ErrUnauthorized
+		}
+		return e.Err
+	}
+	return fmt.Errorf("bad response: empty array")
+}
+
+// next advances to the next resource, returning false when there are no more
+// or an error occurred.
+func (it *rawIterator) next() bool {
+	if it.done {
+		return false
+	}
+	if !it.dec.More() {
+		it.finish(nil)
+		return false
+	}
+	tok, err := it.dec.Token()
+	if err != nil {
+		it.finish(err)
+		return false
+	}
+	it.id, _ = tok.(string)
+	it.raw = nil
+	if err := it.dec.Decode(&it.raw); err != nil {
+		it.finish(err)
+		return false
+	}
+	return true
+}
+
+// decode decodes the current resource into v.
+func (it *rawIterator) decode(v interface{}) bool {
+	if err := it.bridge.decode(it.raw, v); err != nil {
+		it.finish(err)
+		return false
+	}
+	return true
+}
+
+// finish ends the iteration with the given error, releasing the response and
+// reporting the call to the observer, if any.
+func (it *rawIterator) finish(err error) {
+	if it.done {
+		return
+	}
+	it.done = true
+	it.err = err
+	it.release()
+	if it.bridge.observe != nil {
+		it.bridge.observe(CallInfo{
+			Method:   http.MethodGet,
+			Resource: strings.Join(it.tokens, "/"),
+			Duration: time.Since(it.start),
+			Err:      err,
+		})
+	}
+}
+
+// release releases the response, if any.
+func (it *rawIterator) release() {
+	if it.resp != nil {
+		it.resp.Body.Close()
+		it.resp = nil
+	}
+	if it.cancel != nil {
+		it.cancel()
+		it.cancel = nil
+	}
+}
+
+// SceneIterator yields the scenes of a bridge one at a time, as they are
+// received. It is obtained using ScenesService.Iter.
+type SceneIterator struct {
+	it    *rawIterator
+	scene *Scene
+}
+
+// Iter returns an iterator over the scenes on the bridge. Unlike List, it
+// decodes the scenes while they are received and does not keep them, which
+// lowers memory use and the time until the first scene is available on
+// bridges with hundreds of scenes. The options of the bridge apply as they do
+// to List, except that the listing is neither coalesced with concurrent reads
+// nor cached. The iterator must be closed after use, unless Next returned
+// false.
+//
+//	it := b.Scenes().Iter()
+//	defer it.Close()
+//	for it.Next() {
+//		fmt.Println(it.Scene().Name)
+//	}
+//	if err := it.Err(); err != nil {
+//		// handle error
+//	}
+func (s *ScenesService) Iter() *SceneIterator {
+	return &SceneIterator{it: s.bridge.iterate("scenes")}
+}
+
+// Next advances to the next scene, which is then available using Scene. It
+// returns false when there are no more scenes or an error occurred.
+func (it *SceneIterator) Next() bool {
+	if !it.it.next() {
+		return false
+	}
+	sc := new(Scene)
+	if !it.it.decode(sc) {
+		return false
+	}
+	sc.bridge = it.it.bridge
+	sc.ID = it.it.id
+	it.scene = sc
+	return true
+}
+
+// Scene returns the current scene.
+func (it *SceneIterator) Scene() *Scene { return it.scene }
+
+// Err returns the error which ended the iteration, if any.
+func (it *SceneIterator) Err() error { return it.it.err }
+
+// Close stops the iteration.
+func (it *SceneIterator) Close() { it.it.finish(nil) }
+
+// GroupIterator yields the groups of a bridge one at a time, as they are
+// received. It is obtained using GroupsService.Iter.
+type GroupIterator struct {
+	it    *rawIterator
+	group *Group
+}
+
+// Iter returns an iterator over the groups on the bridge, which works like
+// ScenesService.Iter.
+func (g *GroupsService) Iter() *GroupIterator {
+	return &GroupIterator{it: g.bridge.iterate("groups")}
+}
+
+// Next advances to the next group, which is then available using Group. It
+// returns false when there are no more groups or an error occurred.
+func (it *GroupIterator) Next() bool {
+	if !it.it.next() {
+		return false
+	}
+	gg := new(Group)
+	if !it.it.decode(gg) {
+		return false
+	}
+	gg.bridge = it.it.bridge
+	gg.ID = it.it.id
+	it.group = gg
+	return true
+}
+
+// Group returns the current group.
+func (it *GroupIterator) Group() *Group { return it.group }
+
+// Err returns the error which ended the iteration, if any.
+func (it *GroupIterator) Err() error { return it.it.err }
+
+// Close stops the iteration.
+func (it *GroupIterator) Close() { it.it.finish(nil) }
@@ -0,0 +1,95 @@
+package hue
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestScenesIter(t *testing.T) {
+	mb := mockBridge(t)
+	defer mb.teardown()
+	mb.nextResponse = json.RawMessage(`{
+		"ab": {"name": "Relax", "lights": ["1"]},
+		"cd": {"name": "Read", "lights": ["1", "2"]}}`)
+	var calls []CallInfo
+	mb.b.observe = func(ci CallInfo) { calls = append(calls, ci) }
+	it := mb.b.Scenes().Iter()
+	defer it.Close()
+	var got []string
+	for it.Next() {
+		got = append(got, it.Scene().ID+"="+it.Scene().Name)
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0] != "ab=Relax" || got[1] != "cd=Read" {
+		t.Fatalf("unexpected scenes %v", got)
+	}
+	if len(calls) != 1 || calls[0].Resource != "scenes" || calls[0].Method != http.MethodGet {
+		t.Fatalf("unexpected calls %+v", calls)
+	}
+}
+
+func TestGroupsIterError(t *testing.T) {
+	srv := serverWithResponse(`[{"error": {"type": 1, "address": "/groups", "description": "unauthorized user"}}]`)
+	defer srv.Close()
+	b := &Bridge{bridgeID: bridgeID{IP: srv.URL + "/"}, username: "u"}
+	it := b.Groups().Iter()
+	if it.Next() {
+		t.Fatal("expected no groups")
+	}
+	if err := it.Err(); err != ErrUnauthorized {
+		t.Fatalf("expected ErrUnauthorized, got %v", err)
+	}
+}
+
+func TestScenesIterRepair(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/api":
+			w.Write([]byte(`[{"success": {"username": "new"}}]`))
+		case r.URL.Path == "/api/new/scenes":
+			w.Write([]byte(`{"ab": {"name": "Relax"}}`))
+		default:
+			w.Write([]byte(`[{"error": {"type": 1, "address": "/", "description": "unauthorized user"}}]`))
+		}
+	}))
+	defer srv.Close()
+	var prompted int
+	b := &Bridge{bridgeID: bridgeID{IP: srv.URL + "/"}, username: "old"}
+	b.repair = &repairFlow{prompt: func() error {
+		prompted++
+		return nil
+	}}
+	it := b.Scenes().Iter()
+	defer it.Close()
+	if !it.Next() || it.Scene().Name != "Relax" {
+		t.Fatalf("expected a scene after pairing again, got %v", it.Err())
+	}
+	if prompted != 1 || b.username != "new" {
+		t.Fatalf("expected to pair again once, got prompted=%d username=%q", prompted, b.username)
+	}
+}
+
+func TestGroupsIterFailover(t *testing.T) {
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, _, _ := w.(http.Hijacker).Hijack()
+		conn.Close()
+	}))
+	defer primary.Close()
+	secondary := serverWithResponse(`{"1": {"name": "secondary"}}`)
+	defer secondary.Close()
+	var o options
+	WithSecondary(secondary.Listener.Addr().String(), "mirror")(&o)
+	b := o.apply(&Bridge{bridgeID: bridgeID{IP: primary.URL + "/"}, username: "user"})
+	it := b.Groups().Iter()
+	defer it.Close()
+	if !it.Next() || it.Group().Name != "secondary" {
+		t.Fatalf("expected a group from the secondary gateway, got %v", it.Err())
+	}
+	if it.Group().bridge != b || b.Primary() {
+		t.Fatal("expected the group to belong to the bridge, which is marked down")
+	}
+}
@@ -0,0 +1,270 @@
+package hue
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrJournaled is returned by commands which could not be sent because the
+// bridge was unreachable, and were recorded in the journal set up using
+// WithJournal instead.
+var ErrJournaled = errors.New("bridge unreachable; command journaled for replay")
+
+// JournalEntry is a command recorded in the journal.
+type JournalEntry struct {
+	// Time is the time at which the command was issued.
+	Time time.Time
+
+	// Method is the HTTP method of the command.
+	Method string
+
+	// Tokens holds the elements of the path of the resource, relative to
+	// '<base>/api/<username>'.
+	Tokens []string
+
+	// Body is the body of the command, if any.
+	Body json.RawMessage `json:",omitempty"`
+}
+
+// journal records commands while the bridge is unreachable, and replays them
+// once it can be reached again.
+type journal struct {
+	// path is the file that the entries are persisted to. If empty, they are
+	// only kept in memory.
+	path string
+
+	// maxAge is the age after which entries are dropped instead of replayed.
+	maxAge time.Duration
+
+	mu      sync.Mutex
+	entries []JournalEntry
+}
+
+// newJournal returns a journal persisted at path, restoring the entries
+// stored there, if any.
+func newJournal(path string, maxAge time.Duration) *journal {
+	j := &journal{path: path, maxAge: maxAge}
+	if path == "" {
+		return j
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("could not read journal: %v", err)
+		}
+		return j
+	}
+	if err := json.Unmarshal(data, &j.entries); err != nil {
+		log.Printf("could not read journal: %v", err)
+	}
+	return j
+}
+
+// after is called with the outcome of every request to the bridge. Commands
+// which failed to reach it are recorded, while any success causes the
+// recorded commands to be replayed, except those superseded by the command
+// that succeeded.
+func (j *journal) after(b Bridge, method string, body interface{}, tokens []string, msg []byte, err error) ([]byte, error) {
+	if err == nil {
+		j.replay(b, method, body, tokens)
+		return msg, nil
+	}
+	if method == http.MethodGet || !unreachable(err) {
+		return msg, err
+	}
+	var raw json.RawMessage
+	if body != nil {
+		if raw, err = json.Marshal(body); err != nil {
+			return nil, err
+		}
+	}
+	j.record(JournalEntry{Time: b.now(), Method: method, Tokens: tokens, Body: raw})
+	return nil, ErrJournaled
+}
+
+// record adds e to the journal. Earlier changes to the same resource are
+// dropped, since e supersedes them. Partial updates are merged into e
+// instead, so that the attributes which e does not set are kept.
+func (j *journal) record(e JournalEntry) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	for _, old := range j.supersede(e.Method, e.Tokens) {
+		if partial(e.Method, e.Tokens) {
+			e.Body = mergeBodies(old.Body, e.Body)
+		}
+	}
+	j.entries = append(j.entries, e)
+	j.save()
+}
+
+// supersede drops the entries which a command with the given method to the
+// resource at tokens supersedes, and returns them. Creations supersede
+// nothing. j.mu must be held.
+func (j *journal) supersede(method string, tokens []string) []JournalEntry {
+	if method == http.MethodGet || method == http.MethodPost {
+		return nil
+	}
+	key := strings.Join(tokens, "/")
+	var dropped []JournalEntry
+	kept := j.entries[:0]
+	for _, e := range j.entries {
+		if e.Method != method || strings.Join(e.Tokens, "/") != key {
+			kept = append(kept, e)
+		} else {
+			dropped = append(dropped, e)
+		}
+	}
+	j.entries = kept
+	return dropped
+}
+
+// strip removes the attributes set by body from the partial updates to the
+// resource at tokens, dropping those which are left empty. j.mu must be held.
+func (j *journal) strip(tokens []string, body interface{}) {
+	sent, err := json.Marshal(body)
+	if err != nil {
+		return
+	}
+	key := strings.Join(tokens, "/")
+	kept := j.entries[:0]
+	for _, e := range j.entries {
+		if e.Method == http.MethodPut && strings.Join(e.Tokens, "/") == key {
+			if e.Body = withoutAttrs(e.Body, sent); e.Body == nil {
+				continue
+			}
+		}
+		kept = append(kept, e)
+	}
+	j.entries = kept
+}
+
+// partial reports whether a command with the given method to the resource at
+// tokens updates only the attributes in its body, which is the case for the
+// state of a light and the action of a group.
+func partial(method string, tokens []string) bool {
+	if method != http.MethodPut || len(tokens) != 3 {
+		return false
+	}
+	return tokens[0] == "lights" && tokens[2] == "state" ||
+		tokens[0] == "groups" && tokens[2] == "action"
+}
+
+// mergeBodies returns the JSON object body with the attributes of old which it
+// does not set added. If either is not an object, body is returned.
+func mergeBodies(old, body json.RawMessage) json.RawMessage {
+	var o, b map[string]json.RawMessage
+	if json.Unmarshal(old, &o) != nil || json.Unmarshal(body, &b) != nil {
+		return body
+	}
+	added := false
+	for k, v := range o {
+		if _, ok := b[k]; !ok {
+			b[k] = v
+			added = true
+		}
+	}
+	if !added {
+		return body
+	}
+	merged, err := json.Marshal(b)
+	if err != nil {
+		return body
+	}
+	return merged
+}
+
+// withoutAttrs returns the JSON object body without the attributes set by
+// sent, or nil if none are left. If either is not an object, nil is returned.
+func withoutAttrs(body, sent json.RawMessage) json.RawMessage {
+	var b, s map[string]json.RawMessage
+	if json.Unmarshal(body, &b) != nil || json.Unmarshal(sent, &s) != nil {
+		return nil
+	}
+	removed := false
+	for k := range s {
+		if _, ok := b[k]; ok {
+			delete(b, k)
+			removed = true
+		}
+	}
+	if len(b) == 0 {
+		return nil
+	}
+	if !removed {
+		return body
+	}
+	rest, err := json.Marshal(b)
+	if err != nil {
+		return body
+	}
+	return rest
+}
+
+// replay sends the recorded commands to bridge b, in order, except for those
+// superseded by the given command, which was just sent. Of the partial
+// updates to the same resource, only the attributes that it did not set are
+// sent. Commands older than the maximum age are dropped, as are those
+// permanently rejected by the bridge. Replay stops when the bridge becomes
+// unreachable again or fails with a retryable error.
+func (j *journal) replay(b Bridge, method string, body interface{}, tokens []string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if len(j.entries) == 0 {
+		return
+	}
+	if partial(method, tokens) {
+		j.strip(tokens, body)
+	} else {
+		j.supersede(method, tokens)
+	}
+	now := b.now()
+	for len(j.entries) > 0 {
+		e := j.entries[0]
+		if j.maxAge <= 0 || now.Sub(e.Time) <= j.maxAge {
+			var payload interface{}
+			if e.Body != nil {
+				payload = e.Body
+			}
+			if _, err := b.request(e.Method, payload, e.Tokens...); Retryable(err) {
+				break
+			} else if err != nil {
+				log.Printf("dropping journaled %s %s: %v", e.Method, strings.Join(e.Tokens, "/"), err)
+			}
+		}
+		j.entries = j.entries[1:]
+	}
+	j.save()
+}
+
+// save persists the entries. j.mu must be held.
+func (j *journal) save() {
+	if j.path == "" {
+		return
+	}
+	data, err := json.Marshal(j.entries)
+	if err != nil {
+		log.Printf("could not write journal: %v", err)
+		return
+	}
+	if err := ioutil.WriteFile(j.path, data, 0666); err != nil {
+		log.Printf("could not write journal: %v", err)
+	}
+}
+
+// Journal returns the commands which are waiting to be replayed, oldest
+// first. It is empty if no journal was set up using WithJournal.
+func (b *Bridge) Journal() []JournalEntry {
+	if b.journal == nil {
+		return nil
+	}
+	b.journal.mu.Lock()
+	defer b.journal.mu.Unlock()
+	return append([]JournalEntry(nil), b.journal.entries...)
+}
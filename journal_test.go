@@ -0,0 +1,148 @@
+package hue
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestJournal(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hue")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	p := path.Join(dir, "journal")
+
+	var (
+		mu  sync.Mutex
+		got []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		mu.Lock()
+		got = append(got, r.Method+" "+r.URL.Path+" "+string(body))
+		mu.Unlock()
+		w.Write([]byte(`[{"success": {}}]`))
+	}))
+	defer srv.Close()
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	clock := &fakeClock{t: time.Date(2017, 1, 1, 12, 0, 0, 0, time.UTC)}
+	var o options
+	WithJournal(p, time.Hour)(&o)
+	WithClock(clock)(&o)
+	b := o.apply(&Bridge{bridgeID: bridgeID{IP: down.URL + "/"}, username: "u"})
+
+	l1 := &Light{bridge: b, ID: "1"}
+	l2 := &Light{bridge: b, ID: "2"}
+	l3 := &Light{bridge: b, ID: "3"}
+	if err := l3.On(); err != ErrJournaled {
+		t.Fatalf("expected ErrJournaled, got %v", err)
+	}
+	// l3 is stale by the time that the bridge can be reached
+	clock.set(clock.Now().Add(2 * time.Hour))
+	for _, err := range []error{
+		l1.Set(&State{On: true, Brightness: 10}),
+		l1.Set(&State{On: true, Brightness: 20}),
+		l2.Off(),
+	} {
+		if err != ErrJournaled {
+			t.Fatalf("expected ErrJournaled, got %v", err)
+		}
+	}
+	if n := len(b.Journal()); n != 3 {
+		t.Fatalf("expected 3 entries, got %d", n)
+	}
+
+	// the journal survives restarts
+	b = &Bridge{bridgeID: bridgeID{IP: srv.URL + "/"}, username: "u", clock: clock, journal: newJournal(p, time.Hour)}
+	if n := len(b.Journal()); n != 3 {
+		t.Fatalf("expected 3 restored entries, got %d", n)
+	}
+	if _, err := b.call(http.MethodGet, nil, "config"); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"GET /api/u/config ",
+		`PUT /api/u/lights/1/state {"on":true,"bri":20}`,
+		`PUT /api/u/lights/2/state {"on":false}`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+	if n := len(b.Journal()); n != 0 {
+		t.Fatalf("expected empty journal, got %d entries", n)
+	}
+	data, err := ioutil.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var left []JournalEntry
+	if err := json.Unmarshal(data, &left); err != nil || len(left) != 0 {
+		t.Fatalf("expected empty journal file, got %s (%v)", data, err)
+	}
+}
+
+func TestJournalPartial(t *testing.T) {
+	var (
+		mu  sync.Mutex
+		got []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		mu.Lock()
+		got = append(got, r.Method+" "+r.URL.Path+" "+string(body))
+		mu.Unlock()
+		w.Write([]byte(`[{"success": {}}]`))
+	}))
+	defer srv.Close()
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	var o options
+	WithJournal("", 0)(&o)
+	b := o.apply(&Bridge{bridgeID: bridgeID{IP: down.URL + "/"}, username: "u"})
+	for _, body := range []map[string]interface{}{
+		{"bri": 10},
+		{"hue": 500},
+		{"sat": 20, "bri": 30},
+	} {
+		if _, err := b.call(http.MethodPut, body, "lights", "1", "state"); err != ErrJournaled {
+			t.Fatalf("expected ErrJournaled, got %v", err)
+		}
+	}
+	entries := b.Journal()
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 merged entry, got %d", len(entries))
+	}
+	var merged map[string]int
+	if err := json.Unmarshal(entries[0].Body, &merged); err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]int{"bri": 30, "hue": 500, "sat": 20}; !reflect.DeepEqual(merged, want) {
+		t.Fatalf("expected %v, got %v", want, merged)
+	}
+
+	// a command to the same state which gets through supersedes only the
+	// attributes that it sets
+	b.IP = srv.URL + "/"
+	if _, err := b.call(http.MethodPut, map[string]interface{}{"bri": 40, "sat": 50}, "lights", "1", "state"); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		`PUT /api/u/lights/1/state {"bri":40,"sat":50}`,
+		`PUT /api/u/lights/1/state {"hue":500}`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
@@ -3,7 +3,10 @@ package hue
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
+	"sort"
+	"strings"
 )
 
 // ErrNotExist is returned when a light was not found.
@@ -14,16 +17,29 @@ const (
 	NoEffect  = "none"
 )
 
+// Alert effects.
+const (
+	AlertNone       = "none"
+	AlertSelect     = "select"
+	AlertLongSelect = "lselect"
+)
+
 // Lights returns the service to interact with the lights on this bridge.
 func (b *Bridge) Lights() *LightsService { return &LightsService{bridge: b} }
 
 // LightsService is the service that allows interacting with the lights API
 // of the bridge.
-type LightsService struct{ bridge *Bridge }
+type LightsService struct {
+	bridge *Bridge
+
+	// scope, if non-nil, holds the IDs of the only lights which the service
+	// operates on.
+	scope map[string]bool
+}
 
 // List returns a slice of all lights discovered by the bridge.
 func (l *LightsService) List() ([]*Light, error) {
-	all, err := l.idMap()
+	all, err := l.fetch(true)
 	if err != nil {
 		return nil, err
 	}
@@ -34,19 +50,66 @@ func (l *LightsService) List() ([]*Light, error) {
 	return list, nil
 }
 
-// On turns all lights on.
+// On turns all lights on. All lights are attempted; if some fail, a
+// *LightsError identifying them is returned.
 func (l *LightsService) On() error {
-	return l.ForEach(func(l *Light) { l.On() })
+	return l.forAll((*Light).On)
 }
 
-// Off turns all lights off.
+// Off turns all lights off. All lights are attempted; if some fail, a
+// *LightsError identifying them is returned.
 func (l *LightsService) Off() error {
-	return l.ForEach(func(l *Light) { l.Off() })
+	return l.forAll((*Light).Off)
 }
 
-// Toggle toggles all lights "on" state.
+// Toggle toggles all lights "on" state. All lights are attempted; if some
+// fail, a *LightsError identifying them is returned.
 func (l *LightsService) Toggle() error {
-	return l.ForEach(func(l *Light) { l.Toggle() })
+	return l.forAll((*Light).Toggle)
+}
+
+// LightsError is returned by methods which act on many lights when some of
+// them fail, so that callers may retry those selectively.
+type LightsError struct {
+	// Errors maps the IDs of the lights which failed to their error.
+	Errors map[string]error
+}
+
+// IDs returns the IDs of the lights which failed, sorted.
+func (e *LightsError) IDs() []string {
+	ids := make([]string, 0, len(e.Errors))
+	for id := range e.Errors {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return lessID(ids[i], ids[j]) })
+	return ids
+}
+
+func (e *LightsError) Error() string {
+	ids := e.IDs()
+	msgs := make([]string, len(ids))
+	for i, id := range ids {
+		msgs[i] = fmt.Sprintf("light %s: %v", id, e.Errors[id])
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// forAll calls fn for every light, collecting the errors in a *LightsError.
+func (l *LightsService) forAll(fn func(*Light) error) error {
+	list, err := l.idMap()
+	if err != nil {
+		return err
+	}
+	errs := make(map[string]error)
+	for id, ll := range list {
+		if err := fn(ll); err != nil {
+			errs[id] = err
+		}
+	}
+	if len(errs) > 0 {
+		return &LightsError{Errors: errs}
+	}
+	return nil
 }
 
 // ForEach traverses each light and passes it as an argument to the given function.
@@ -61,9 +124,24 @@ func (l *LightsService) ForEach(fn func(*Light)) error {
 	return nil
 }
 
+// ForEachE is like ForEach, except that the given function may return an error.
+// Traversal stops at the first error, which is returned.
+func (l *LightsService) ForEachE(fn func(*Light) error) error {
+	list, err := l.idMap()
+	if err != nil {
+		return err
+	}
+	for _, l := range list {
+		if err := fn(l); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // GetByID returns a light by id.
 func (l *LightsService) GetByID(id string) (*Light, error) {
-	list, err := l.idMap()
+	list, err := l.fetch(true)
 	if err != nil {
 		return nil, ErrNotExist
 	}
@@ -74,9 +152,61 @@ func (l *LightsService) GetByID(id string) (*Light, error) {
 	return v, nil
 }
 
+// GetByUID returns a light by its unique ID (UID), which unlike its ID does
+// not change when the light is removed from the bridge and added again, or
+// when the bridge is reset. UIDs are compared ignoring case.
+func (l *LightsService) GetByUID(uid string) (*Light, error) {
+	list, err := l.idMap()
+	if err != nil {
+		return nil, err
+	}
+	for _, ll := range list {
+		if strings.EqualFold(ll.UID, uid) {
+			return ll, nil
+		}
+	}
+	return nil, ErrNotExist
+}
+
+// UIDError is returned when no light has the given unique ID.
+type UIDError struct {
+	// UID is the unique ID.
+	UID string
+}
+
+func (e *UIDError) Error() string {
+	return fmt.Sprintf("no light with unique ID %q", e.UID)
+}
+
+// ResolveUIDs returns the IDs of the lights with the given unique IDs, in the
+// same order, fetching the lights once. It allows automations and
+// configuration files to refer to lights by UID, which remains the same
+// across bridge resets, and resolve them to the IDs used by the bridge when
+// needed, for example to create groups or scenes. If a UID does not match
+// any light, a *UIDError is returned.
+func (l *LightsService) ResolveUIDs(uids ...string) ([]string, error) {
+	list, err := l.idMap()
+	if err != nil {
+		return nil, err
+	}
+	byUID := make(map[string]string, len(list))
+	for id, ll := range list {
+		byUID[strings.ToLower(ll.UID)] = id
+	}
+	ids := make([]string, len(uids))
+	for i, uid := range uids {
+		id, ok := byUID[strings.ToLower(uid)]
+		if !ok {
+			return nil, &UIDError{UID: uid}
+		}
+		ids[i] = id
+	}
+	return ids, nil
+}
+
 // Get returns a light by name.
 func (l *LightsService) Get(name string) (*Light, error) {
-	list, err := l.idMap()
+	list, err := l.fetch(true)
 	if err != nil {
 		return nil, err
 	}
@@ -88,22 +218,160 @@ func (l *LightsService) Get(name string) (*Light, error) {
 	return nil, ErrNotExist
 }
 
+// Count returns the number of lights known to the bridge. Unlike List, it does
+// not decode the state of each light.
+func (l *LightsService) Count() (int, error) {
+	msg, err := l.bridge.call(http.MethodGet, nil, "lights")
+	if err != nil {
+		return 0, err
+	}
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(msg, &all); err != nil {
+		return 0, err
+	}
+	if l.scope != nil {
+		n := 0
+		for id := range all {
+			if l.scope[id] {
+				n++
+			}
+		}
+		return n, nil
+	}
+	return len(all), nil
+}
+
+// Exists reports whether a light with the given id exists. It only queries
+// the requested light instead of fetching the entire list.
+func (l *LightsService) Exists(id string) (bool, error) {
+	if l.scope != nil && !l.scope[id] {
+		return false, nil
+	}
+	_, err := l.bridge.call(http.MethodGet, nil, "lights", id)
+	if err != nil {
+		if e, ok := err.(APIError); ok && e.Code == errResourceNotAvailable {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// PendingUpdates returns the lights for which a firmware update is pending.
+func (l *LightsService) PendingUpdates() ([]*Light, error) {
+	all, err := l.idMap()
+	if err != nil {
+		return nil, err
+	}
+	var list []*Light
+	for _, ll := range all {
+		if ll.UpdateAvailable() {
+			list = append(list, ll)
+		}
+	}
+	return list, nil
+}
+
+// New returns the lights which were discovered by the last scan.
+func (l *LightsService) New() ([]*Light, error) {
+	msg, err := l.bridge.call(http.MethodGet, nil, "lights", "new")
+	if err != nil {
+		return nil, err
+	}
+	// the response maps IDs to names, along with the "lastscan" key
+	var found map[string]json.RawMessage
+	if err := json.Unmarshal(msg, &found); err != nil {
+		return nil, err
+	}
+	all, err := l.idMap()
+	if err != nil {
+		return nil, err
+	}
+	var list []*Light
+	for id := range found {
+		if ll, ok := all[id]; ok {
+			list = append(list, ll)
+		}
+	}
+	return list, nil
+}
+
+// SetStartupBehavior sets the startup behavior of all lights for which filter
+// returns true, or of all lights if filter is nil. Lights which do not
+// support configuring their startup behavior are skipped. If progress is not
+// nil, it is called after each light with the number of lights done, the total
+// and the outcome. All lights are attempted and the first error encountered is
+// returned.
+func (l *LightsService) SetStartupBehavior(mode string, filter func(*Light) bool, progress func(done, total int, l *Light, err error)) error {
+	all, err := l.idMap()
+	if err != nil {
+		return err
+	}
+	var list []*Light
+	for _, ll := range all {
+		if ll.Config.Startup.Mode == "" {
+			continue
+		}
+		if filter == nil || filter(ll) {
+			list = append(list, ll)
+		}
+	}
+	var first error
+	for i, ll := range list {
+		err := ll.SetStartupBehavior(mode)
+		if err != nil && first == nil {
+			first = err
+		}
+		if progress != nil {
+			progress(i+1, len(list), ll, err)
+		}
+	}
+	return first
+}
+
 // Scan searches for new lights on the system.
 func (l *LightsService) Scan() error {
 	_, err := l.bridge.call(http.MethodPost, nil, "lights")
 	return err
 }
 
-func (l *LightsService) idMap() (map[string]*Light, error) {
-	msg, err := l.bridge.call(http.MethodGet, nil, "lights")
+func (l *LightsService) idMap() (map[string]*Light, error) { return l.fetch(false) }
+
+// fetch retrieves the lights, keyed by ID. If allowStale is true and the
+// StaleWhileRevalidate option is used, the lights may be those retrieved
+// previously.
+func (l *LightsService) fetch(allowStale bool) (map[string]*Light, error) {
+	var (
+		msg   []byte
+		stale bool
+		err   error
+	)
+	if allowStale {
+		msg, stale, err = l.bridge.list("lights")
+	} else {
+		msg, err = l.bridge.call(http.MethodGet, nil, "lights")
+	}
 	if err != nil {
 		return nil, err
 	}
 	var all map[string]*Light
-	err = json.Unmarshal(msg, &all)
+	err = l.bridge.decode(msg, &all)
 	for id, ll := range all {
 		ll.bridge = l.bridge
 		ll.ID = id
+		ll.stale = stale
+	}
+	if err == nil && !stale {
+		for _, ll := range all {
+			l.bridge.track(ll)
+		}
+	}
+	if l.scope != nil {
+		for id := range all {
+			if !l.scope[id] {
+				delete(all, id)
+			}
+		}
 	}
 	return all, err
 }
@@ -112,6 +380,10 @@ func (l *LightsService) idMap() (map[string]*Light, error) {
 type Light struct {
 	bridge *Bridge
 
+	// stale is true if the light was retrieved from the responses kept by
+	// the StaleWhileRevalidate option.
+	stale bool
+
 	// ID is the ID that the bridge returns for this light.
 	ID string
 
@@ -122,6 +394,10 @@ type Light struct {
 	// SWVersion is an identifier for the software version running on the light.
 	SWVersion string `json:"swversion"`
 
+	// SWUpdate holds information about firmware updates for the light. It is
+	// only reported by bridges running API version 1.22 or newer.
+	SWUpdate SWUpdate `json:"swupdate"`
+
 	// State details the state of the light.
 	State LightState `json:"state"`
 
@@ -137,15 +413,62 @@ type Light struct {
 
 	// ManufacturerName is the manufacturer name.
 	ManufacturerName string `json:"manufacturername"`
+
+	// ProductName is the name of the product (e.g. "Hue color lamp"). It is
+	// only reported by newer firmware.
+	ProductName string `json:"productname,omitempty"`
+
+	// ProductID is the identifier of the product. It is only reported by
+	// newer firmware, and not for all lights.
+	ProductID string `json:"productid,omitempty"`
+
+	// Capabilities holds the capabilities of the light. It is nil for bridges
+	// which do not report them.
+	Capabilities *LightCapabilities `json:"capabilities,omitempty"`
+
+	// Config holds the configuration of the light. It is only reported by
+	// newer firmware.
+	Config LightConfig `json:"config"`
 }
 
-// On turns the light on.
+// Stale reports whether the light was returned from the responses kept by the
+// StaleWhileRevalidate option, and may thus be outdated.
+func (l *Light) Stale() bool { return l.stale }
+
+// UpdateAvailable reports whether a firmware update is pending for the light,
+// i.e. it is being transferred or ready to be installed.
+func (l *Light) UpdateAvailable() bool {
+	switch l.SWUpdate.State {
+	case UpdateTransferring, UpdateReadyToInstall:
+		return true
+	}
+	return false
+}
+
+// ThirdParty reports whether the light is not a certified Hue light, such as
+// a Zigbee bulb made by another manufacturer. Bridges which do not report
+// capabilities are assumed to only certify lights made by Philips.
+func (l *Light) ThirdParty() bool {
+	if l.Capabilities != nil {
+		return !l.Capabilities.Certified
+	}
+	return !certifiedManufacturers[l.ManufacturerName]
+}
+
+// certifiedManufacturers holds the manufacturer names reported by certified
+// lights.
+var certifiedManufacturers = map[string]bool{
+	"Philips":                  true,
+	"Signify Netherlands B.V.": true,
+}
+
+// On turns the light on. It is idempotent and thus safe to retry.
 func (l *Light) On() error { return l.Set(&State{On: true}) }
 
-// Off turns the light off.
+// Off turns the light off. It is idempotent and thus safe to retry.
 func (l *Light) Off() error {
-	_, err := l.bridge.call(http.MethodPut, map[string]bool{
-		"on": false,
+	_, err := l.bridge.call(http.MethodPut, offState{
+		TransitionTime: l.defaultTransition(),
 	}, "lights", l.ID, "state")
 	if err == nil {
 		l.State.On = false
@@ -153,17 +476,47 @@ func (l *Light) Off() error {
 	return err
 }
 
-// Toggle toggles a light on/off.
+// Toggle toggles a light on/off. The current state is fetched from the bridge
+// beforehand, so that a stale local state does not cause the light to be
+// switched the wrong way. Note that Toggle is not idempotent: retrying a call
+// which has reached the bridge will switch the light back.
 func (l *Light) Toggle() error {
+	if err := l.refresh(); err != nil {
+		return err
+	}
 	if l.State.On {
 		return l.Off()
 	}
 	return l.On()
 }
 
-// Rename sets the name by which this light can be addressed.
+// Breathe makes the light perform one breathe cycle.
+func (l *Light) Breathe() error { return l.Set(&State{Alert: AlertSelect}) }
+
+// ColorLoop makes the light cycle through all hues using the current
+// brightness and saturation settings. To stop it, set the Effect to NoEffect.
+func (l *Light) ColorLoop() error { return l.Set(&State{Effect: ColorLoop}) }
+
+// SetStartupBehavior sets the behavior of the light when it is powered on. See
+// Startup.Mode for the possible values.
+func (l *Light) SetStartupBehavior(mode string) error {
+	_, err := l.bridge.call(http.MethodPut, map[string]interface{}{
+		"startup": map[string]string{"mode": mode},
+	}, "lights", l.ID, "config")
+	if err == nil {
+		l.Config.Startup.Mode = mode
+	}
+	return err
+}
+
+// Rename sets the name by which this light can be addressed. If the bridge
+// would not accept the name, a *NameError is returned.
 func (l *Light) Rename(name string) error {
-	_, err := l.bridge.call(http.MethodPut, map[string]string{
+	name, err := l.bridge.validName(name)
+	if err != nil {
+		return err
+	}
+	_, err = l.bridge.call(http.MethodPut, map[string]string{
 		"name": name,
 	}, "lights", l.ID)
 	if err == nil {
@@ -173,20 +526,151 @@ func (l *Light) Rename(name string) error {
 }
 
 // Set sets the new state of the light. Note that Set can not turn the light off.
-// In order to do that, use the provided Off method.
+// In order to do that, use the provided Off method. If s does not set a
+// transition time, the default of the light or bridge is used, if any.
 func (l *Light) Set(s *State) error {
-	_, err := l.bridge.call(http.MethodPut, s, "lights", l.ID, "state")
+	if err := l.send(s); err != nil {
+		return err
+	}
+	return l.refresh()
+}
+
+// send sends state s to the light as Set does, without refreshing the light
+// afterwards.
+func (l *Light) send(s *State) error {
+	_, err := l.bridge.call(http.MethodPut, statePayload(l.compensate(s), l.defaultTransition()), "lights", l.ID, "state")
+	return err
+}
+
+// IncrementBrightness increments the brightness of the light by delta, which
+// may be negative to decrement it. A delta of 0 stops any ongoing transition.
+func (l *Light) IncrementBrightness(delta int) error {
+	return l.setState(map[string]int{"bri_inc": delta})
+}
+
+// IncrementSaturation increments the saturation of the light by delta, which
+// may be negative to decrement it.
+func (l *Light) IncrementSaturation(delta int) error {
+	return l.setState(map[string]int{"sat_inc": delta})
+}
+
+// IncrementHue increments the hue of the light by delta, which may be negative
+// to decrement it. The resulting value wraps around.
+func (l *Light) IncrementHue(delta int) error {
+	return l.setState(map[string]int{"hue_inc": delta})
+}
+
+// IncrementColorTemp increments the color temperature of the light by delta,
+// which may be negative to decrement it.
+func (l *Light) IncrementColorTemp(delta int) error {
+	return l.setState(map[string]int{"ct_inc": delta})
+}
+
+// StopTransition stops any ongoing transition of the light. The zero value of
+// the increment fields in State is omitted, so it can not be used for this.
+func (l *Light) StopTransition() error {
+	return l.setState(map[string]int{"bri_inc": 0})
+}
+
+// setState sends the given payload to the state endpoint of the light and
+// refreshes it.
+func (l *Light) setState(payload interface{}) error {
+	_, err := l.bridge.call(http.MethodPut, payload, "lights", l.ID, "state")
 	if err != nil {
 		return err
 	}
+	return l.refresh()
+}
+
+// refresh updates the light with its current attributes and state, as known
+// by the bridge.
+func (l *Light) refresh() error {
 	r, err := l.bridge.call(http.MethodGet, nil, "lights", l.ID)
 	if err != nil {
 		return err
 	}
-	if err := json.Unmarshal(r, l); err != nil {
+	if err := l.bridge.decode(r, l); err != nil {
 		return err
 	}
-	return err
+	l.bridge.track(l)
+	return nil
+}
+
+// track records the state of light l, as retrieved from the bridge, for the
+// trackers that are enabled.
+func (b *Bridge) track(l *Light) {
+	if b.reach != nil {
+		b.reach.observe(l, b.now())
+	}
+	if b.energy != nil {
+		b.energy.observe(l, b.now())
+	}
+	if b.audit != nil {
+		b.audit.observe(l, b.now())
+	}
+}
+
+// LightConfig holds the configuration of a light.
+type LightConfig struct {
+	// Archetype is the kind of light, as chosen in the official app (e.g.
+	// "sultanbulb", "floorshade").
+	Archetype string `json:"archetype,omitempty"`
+
+	// Function is the purpose of the light: "functional", "decorative",
+	// "mixed" or "unknown".
+	Function string `json:"function,omitempty"`
+
+	// Direction is the direction in which the light shines: "omnidirectional",
+	// "upwards", "downwards", "horizontal", "vertical" or "unknown".
+	Direction string `json:"direction,omitempty"`
+
+	// Startup holds the behavior of the light when it is powered on.
+	Startup Startup `json:"startup"`
+}
+
+// LightCapabilities holds the capabilities of a light.
+type LightCapabilities struct {
+	// Certified reports whether the light is certified by Philips ("Friends
+	// of Hue"). Third-party lights are not certified.
+	Certified bool `json:"certified"`
+}
+
+// Startup behaviors of a light.
+const (
+	StartupSafety      = "safety"
+	StartupPowerFail   = "powerfail"
+	StartupLastOnState = "lastonstate"
+	StartupCustom      = "custom"
+)
+
+// Startup describes the behavior of a light when it is powered on.
+type Startup struct {
+	// Mode is one of "safety" (the default, bright white), "powerfail"
+	// (restore the state prior to a power failure), "lastonstate" (restore
+	// the last state the light was on in) or "custom".
+	Mode string `json:"mode"`
+
+	// Configured reports whether the light applied the mode.
+	Configured bool `json:"configured"`
+}
+
+// Firmware update states of a light.
+const (
+	UpdateNone           = "noupdates"
+	UpdateTransferring   = "transferring"
+	UpdateReadyToInstall = "readytoinstall"
+	UpdateInstalling     = "installing"
+	UpdateNotUpdatable   = "notupdatable"
+)
+
+// SWUpdate holds information about firmware updates of a device.
+type SWUpdate struct {
+	// State is the state of the update, for example "noupdates" or
+	// "readytoinstall".
+	State string `json:"state"`
+
+	// LastInstall is the time at which the last update was installed, in UTC.
+	LastInstall string `json:"lastinstall"`
 }
 
 // State holds a structure that is used to update a light's state.
@@ -213,11 +697,11 @@ type State struct {
 	// is the x coordinate and the second entry is the y coordinate. Both x and
 	// y must be between 0 and 1. If the specified coordinates are not in the
 	// CIE color space, the closest color to the coordinates will be chosen.
-	XY *[2]float64 `json:"xy,omitempty"`
+	XY *XY `json:"xy,omitempty"`
 
 	// The Mired Color temperature of the light. 2012 connected lights are
 	// capable of 153 (6500K) to 500 (2000K).
-	Ct float64 `json:"ct,omitempty"`
+	Ct Mired `json:"ct,omitempty"`
 
 	// The alert effect, is a temporary change to the bulb’s state, and has one
 	// of the following values:
@@ -245,7 +729,8 @@ type State struct {
 	// As of 1.7. Increments or decrements the value of the brightness. It is
 	// ignored if the Brightness field is provided. Any ongoing brightness
 	// transition is stopped. Setting a value of 0 also stops any ongoing
-	// transition.
+	// transition, however a value of 0 is omitted from the request. To stop
+	// a transition, use Light.StopTransition instead.
 	BriInc int `json:"bri_inc,omitempty"`
 
 	// As of 1.7. Increments or decrements the value of Saturation. It is
@@ -270,7 +755,8 @@ type State struct {
 	// As of 1.7. Increments or decrements the value of the XY. It is ignored
 	// if the XY attribute is provided. Any ongoing color transition is stopped.
 	// Setting a value of 0 also stops any ongoing transition. Will stop at it's
-	// gamut boundaries. Max value [0.5, 0.5].
+	// gamut boundaries. Max value [0.5, 0.5]. Unlike XY, the values are signed
+	// deltas between -0.5 and 0.5, so they are not of type XY.
 	XYInc *[2]float64 `json:"xy_inc,omitempty"`
 }
 
@@ -294,11 +780,11 @@ type LightState struct {
 	// The x and y coordinates of a color in CIE color space. The first entry
 	// is the x coordinate and the second entry is the y coordinate. Both x and
 	// y are between 0 and 1.
-	XY [2]float64 `json:"xy"`
+	XY XY `json:"xy"`
 
 	// The Mired Color temperature of the light. 2012 connected lights are
 	// capable of 153 (6500K) to 500 (2000K).
-	ColorTemp float64 `json:"ct"`
+	ColorTemp Mired `json:"ct"`
 
 	// The alert effect, which is a temporary change to the bulb’s state. This
 	// can take one of the following values:
@@ -322,4 +808,18 @@ type LightState struct {
 
 	// Indicates if a light can be reached by the bridge.
 	Reachable bool `json:"reachable"`
+
+	// Mode is "homeautomation" normally, or "streaming" while the light is
+	// used by an entertainment stream. It is only reported by newer firmware.
+	Mode string `json:"mode,omitempty"`
 }
+
+// Modes of a light.
+const (
+	ModeHomeAutomation = "homeautomation"
+	ModeStreaming      = "streaming"
+)
+
+// Streaming reports whether the light is captured by an entertainment stream,
+// during which it ignores commands sent using this package.
+func (l *Light) Streaming() bool { return l.State.Mode == ModeStreaming }
@@ -1,12 +1,16 @@
 package hue
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"io"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
 	"testing"
+	"time"
 )
 
 var testLights = map[string]*Light{
@@ -23,6 +27,9 @@ type serviceTestTools struct {
 	srv *httptest.Server
 	// nextResponse is the next response that the server will provide.
 	nextResponse interface{}
+	// responses, if set, maps request paths to the response that the server
+	// will provide for them, taking precedence over nextResponse.
+	responses map[string]interface{}
 	// lastMethod is the last request method that the server received.
 	lastMethod string
 	// lastBody is the last request body that the server received.
@@ -34,26 +41,61 @@ type serviceTestTools struct {
 func (st *serviceTestTools) teardown() { st.srv.Close() }
 
 // mockBridge returns a set of tools that allows testing services on the bridge.
-func mockBridge(t *testing.T) *serviceTestTools {
+func mockBridge(t testing.TB) *serviceTestTools {
 	stt := new(serviceTestTools)
 	srv := httptest.NewServer(http.HandlerFunc(
 		func(w http.ResponseWriter, r *http.Request) {
 			stt.lastMethod = r.Method
-			stt.lastBody = r.Body
+			body, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			stt.lastBody = bytes.NewReader(body)
 			stt.lastPath = r.URL.Path
-			if err := json.NewEncoder(w).Encode(stt.nextResponse); err != nil {
+			resp := stt.nextResponse
+			if v, ok := stt.responses[r.URL.Path]; ok {
+				resp = v
+			}
+			if err := json.NewEncoder(w).Encode(resp); err != nil {
 				t.Fatal(err)
 			}
 		},
 	))
 	stt.b = &Bridge{
-		bridgeID: bridgeID{ID: "bridge_id", IP: srv.URL + "/"},
-		username: "bridge_username",
+		bridgeID:    bridgeID{ID: "bridge_id", IP: srv.URL + "/"},
+		username:    "bridge_username",
+		groupPrefs:  newGroupPrefs(),
+		transitions: newTransitions(),
 	}
 	stt.srv = srv
 	return stt
 }
 
+// pollLights lists the lights of b once for each of the given bodies, which
+// the bridge serves in turn as its lights. Poll i happens at time start plus
+// i steps on a fake clock, which is installed on b and returned along with the
+// lights of the last poll.
+func pollLights(t testing.TB, b *Bridge, start time.Time, step time.Duration, bodies ...string) ([]*Light, *fakeClock) {
+	var i int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, bodies[i])
+	}))
+	defer srv.Close()
+	clock := new(fakeClock)
+	b.bridgeID = bridgeID{IP: srv.URL + "/"}
+	b.username = "user"
+	b.clock = clock
+	var list []*Light
+	for i = range bodies {
+		clock.set(start.Add(time.Duration(i) * step))
+		var err error
+		if list, err = b.Lights().List(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return list, clock
+}
+
 func TestLightsService(t *testing.T) {
 	mb := mockBridge(t)
 	defer mb.teardown()
@@ -94,6 +136,37 @@ func TestLightsService(t *testing.T) {
 		}
 	})
 
+	t.Run("ForEachE", func(t *testing.T) {
+		t.Run("ok", func(t *testing.T) {
+			var i int
+			err := mb.b.Lights().ForEachE(func(l *Light) error {
+				i++
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if i != len(testLights) {
+				t.Fatal("did not go through all lights")
+			}
+		})
+
+		t.Run("error", func(t *testing.T) {
+			var i int
+			want := errors.New("stop")
+			err := mb.b.Lights().ForEachE(func(l *Light) error {
+				i++
+				return want
+			})
+			if err != want {
+				t.Fatalf("expected %v, got %v", want, err)
+			}
+			if i != 1 {
+				t.Fatalf("expected traversal to stop after 1 light, got %d", i)
+			}
+		})
+	})
+
 	t.Run("Get", func(t *testing.T) {
 		t.Run("ok", func(t *testing.T) {
 			l, err := mb.b.Lights().Get("l1name")
@@ -137,6 +210,107 @@ func TestLightsService(t *testing.T) {
 			}
 		})
 	})
+
+	t.Run("PendingUpdates", func(t *testing.T) {
+		defer func() { mb.nextResponse = testLights }()
+		mb.nextResponse = map[string]*Light{
+			"1": &Light{SWUpdate: SWUpdate{State: UpdateNone}},
+			"2": &Light{SWUpdate: SWUpdate{State: UpdateReadyToInstall}},
+			"3": &Light{},
+		}
+		list, err := mb.b.Lights().PendingUpdates()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(list) != 1 || list[0].ID != "2" {
+			t.Fatalf("expected light 2 to be pending, got %v", list)
+		}
+	})
+
+	t.Run("New", func(t *testing.T) {
+		defer func() { mb.responses = nil }()
+		mb.responses = map[string]interface{}{
+			"/api/bridge_username/lights/new": map[string]interface{}{
+				"l2":       map[string]string{"name": "l2name"},
+				"lastscan": "2016-10-16T07:30:00",
+			},
+		}
+		list, err := mb.b.Lights().New()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(list) != 1 || list[0].ID != "l2" {
+			t.Fatalf("expected light l2, got %v", list)
+		}
+	})
+
+	t.Run("SetStartupBehavior", func(t *testing.T) {
+		defer func() { mb.nextResponse = testLights }()
+		supported := LightConfig{Startup: Startup{Mode: StartupSafety}}
+		mb.nextResponse = map[string]*Light{
+			"1": &Light{Name: "a", Config: supported},
+			"2": &Light{Name: "b", Config: supported},
+			"3": &Light{Name: "c"},
+		}
+		var done []string
+		err := mb.b.Lights().SetStartupBehavior(StartupPowerFail,
+			func(l *Light) bool { return l.Name != "b" },
+			func(n, total int, l *Light, err error) {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if total != 1 {
+					t.Fatalf("expected 1 light in total, got %d", total)
+				}
+				done = append(done, l.ID)
+			})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(done, []string{"1"}) {
+			t.Fatalf("expected light 1 to be configured, got %v", done)
+		}
+		if mb.lastMethod != http.MethodPut || mb.lastPath != "/api/bridge_username/lights/1/config" {
+			t.Fatalf("unexpected request %s %s", mb.lastMethod, mb.lastPath)
+		}
+	})
+
+	t.Run("Count", func(t *testing.T) {
+		n, err := mb.b.Lights().Count()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != len(testLights) {
+			t.Fatalf("expected %d, got %d", len(testLights), n)
+		}
+	})
+
+	t.Run("Exists", func(t *testing.T) {
+		t.Run("ok", func(t *testing.T) {
+			ok, err := mb.b.Lights().Exists("l1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !ok {
+				t.Fatal("expected light to exist")
+			}
+			if mb.lastPath != "/api/bridge_username/lights/l1" {
+				t.Fatalf("unexpected path %s", mb.lastPath)
+			}
+		})
+
+		t.Run("missing", func(t *testing.T) {
+			defer func() { mb.nextResponse = testLights }()
+			mb.nextResponse = []map[string]APIError{{"error": {Code: 3, Msg: "resource not available"}}}
+			ok, err := mb.b.Lights().Exists("bogus")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok {
+				t.Fatal("expected light not to exist")
+			}
+		})
+	})
 }
 
 func TestLight(t *testing.T) {
@@ -204,4 +378,198 @@ func TestLight(t *testing.T) {
 			t.Fatalf("expected 'Alert' to be 'alert123', got '%s'", l.State.Alert)
 		}
 	})
+	t.Run("Toggle", func(t *testing.T) {
+		var put map[string]bool
+		srv := httptest.NewServer(
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.Method {
+				case http.MethodPut:
+					if err := json.NewDecoder(r.Body).Decode(&put); err != nil {
+						t.Fatal(err)
+					}
+					w.Write([]byte(`[{"success":{}}]`))
+				case http.MethodGet:
+					// the bridge knows the light is on
+					w.Write([]byte(`{"state":{"on":true}}`))
+				default:
+					t.Fatal("unexpected request")
+				}
+			}))
+		defer srv.Close()
+
+		// stale local state
+		l := &Light{
+			bridge: &Bridge{bridgeID: bridgeID{IP: srv.URL + "/"}},
+			ID:     "1",
+		}
+		if err := l.Toggle(); err != nil {
+			t.Fatal(err)
+		}
+		if want := map[string]bool{"on": false}; !reflect.DeepEqual(put, want) {
+			t.Fatalf("expected %v, got %v", want, put)
+		}
+		if l.State.On {
+			t.Fatal("expected light to be off")
+		}
+	})
+	t.Run("Increment", func(t *testing.T) {
+		for name, tt := range map[string]struct {
+			fn   func(*Light) error
+			want map[string]int
+		}{
+			"brightness": {
+				fn:   func(l *Light) error { return l.IncrementBrightness(-10) },
+				want: map[string]int{"bri_inc": -10},
+			},
+			"saturation": {
+				fn:   func(l *Light) error { return l.IncrementSaturation(5) },
+				want: map[string]int{"sat_inc": 5},
+			},
+			"hue": {
+				fn:   func(l *Light) error { return l.IncrementHue(-2) },
+				want: map[string]int{"hue_inc": -2},
+			},
+			"colortemp": {
+				fn:   func(l *Light) error { return l.IncrementColorTemp(20) },
+				want: map[string]int{"ct_inc": 20},
+			},
+			"stop": {
+				fn:   (*Light).StopTransition,
+				want: map[string]int{"bri_inc": 0},
+			},
+		} {
+			t.Run(name, func(t *testing.T) {
+				var got map[string]int
+				srv := httptest.NewServer(
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						if r.Method == http.MethodPut {
+							if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+								t.Fatal(err)
+							}
+						}
+						w.Write([]byte(`{}`))
+					}))
+				defer srv.Close()
+				l := &Light{
+					bridge: &Bridge{bridgeID: bridgeID{IP: srv.URL + "/"}},
+					ID:     "1",
+				}
+				if err := tt.fn(l); err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Fatalf("expected %v, got %v", tt.want, got)
+				}
+			})
+		}
+	})
+}
+
+func BenchmarkLightSet(b *testing.B) {
+	mb := mockBridge(b)
+	defer mb.teardown()
+	mb.nextResponse = testLights["l1"]
+	l := &Light{bridge: mb.b, ID: "1"}
+	s := &State{On: true, Brightness: 100, XY: &XY{0.3, 0.4}, TransitionTime: 1}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := l.Set(s); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestLightThirdParty(t *testing.T) {
+	for _, tt := range []struct {
+		in   string
+		want bool
+	}{
+		{`{"manufacturername": "Philips", "capabilities": {"certified": true}}`, false},
+		{`{"manufacturername": "IKEA of Sweden", "capabilities": {"certified": false}}`, true},
+		{`{"manufacturername": "Signify Netherlands B.V."}`, false},
+		{`{"manufacturername": "innr"}`, true},
+	} {
+		var l Light
+		if err := json.Unmarshal([]byte(tt.in), &l); err != nil {
+			t.Fatal(err)
+		}
+		if got := l.ThirdParty(); got != tt.want {
+			t.Fatalf("%s: expected %v, got %v", tt.in, tt.want, got)
+		}
+	}
+}
+
+func TestLightsOffPartialFailure(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/u/lights":
+			w.Write([]byte(`{"1": {}, "2": {}, "3": {}}`))
+		case "/api/u/lights/2/state":
+			w.Write([]byte(`[{"error": {"type": 201, "address": "/lights/2/state/on", "description": "device is not reachable"}}]`))
+		default:
+			w.Write([]byte(`[{"success": {}}]`))
+		}
+	}))
+	defer srv.Close()
+	b := &Bridge{bridgeID: bridgeID{IP: srv.URL + "/"}, username: "u"}
+	err := b.Lights().Off()
+	e, ok := err.(*LightsError)
+	if !ok {
+		t.Fatalf("expected *LightsError, got %v", err)
+	}
+	if ids := e.IDs(); !reflect.DeepEqual(ids, []string{"2"}) {
+		t.Fatalf("expected light 2 to fail, got %v", ids)
+	}
+	if ae, ok := e.Errors["2"].(APIError); !ok || ae.Code != 201 {
+		t.Fatalf("unexpected error %v", e.Errors["2"])
+	}
+}
+
+func TestLightsByUID(t *testing.T) {
+	mb := mockBridge(t)
+	defer mb.teardown()
+	mb.nextResponse = json.RawMessage(`{
+		"4": {"name": "a", "uniqueid": "00:17:88:01:00:bd:c7:b9-0b"},
+		"7": {"name": "b", "uniqueid": "00:17:88:01:10:2a:3f:01-0b"}}`)
+	l, err := mb.b.Lights().GetByUID("00:17:88:01:10:2A:3F:01-0B")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.ID != "7" {
+		t.Fatalf("expected light 7, got %s", l.ID)
+	}
+	if _, err := mb.b.Lights().GetByUID("00:00:00:00:00:00:00:00-0b"); err != ErrNotExist {
+		t.Fatalf("expected ErrNotExist, got %v", err)
+	}
+	ids, err := mb.b.Lights().ResolveUIDs("00:17:88:01:10:2a:3f:01-0b", "00:17:88:01:00:bd:c7:b9-0b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"7", "4"}; !reflect.DeepEqual(ids, want) {
+		t.Fatalf("expected %v, got %v", want, ids)
+	}
+	if _, err := mb.b.Lights().ResolveUIDs("x"); err == nil {
+		t.Fatal("expected error")
+	} else if e, ok := err.(*UIDError); !ok || e.UID != "x" {
+		t.Fatalf("expected *UIDError, got %v", err)
+	}
+}
+
+func TestLightModeAndConfig(t *testing.T) {
+	data := []byte(`{
+		"state": {"on": true, "bri": 254, "alert": "none", "mode": "streaming", "reachable": true},
+		"config": {"archetype": "huebulb", "function": "mixed", "direction": "omnidirectional", "startup": {"mode": "safety", "configured": true}},
+		"name": "Desk"}`)
+	var l Light
+	if err := (Bridge{strict: true}).decode(data, &l); err != nil {
+		t.Fatal(err)
+	}
+	if !l.Streaming() {
+		t.Fatal("expected light to be streaming")
+	}
+	want := LightConfig{Archetype: "huebulb", Function: "mixed", Direction: "omnidirectional", Startup: Startup{Mode: StartupSafety, Configured: true}}
+	if l.Config != want {
+		t.Fatalf("expected %+v, got %+v", want, l.Config)
+	}
 }
@@ -0,0 +1,108 @@
+package hue
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"time"
+)
+
+// limits holds the safeguards applied to responses of the bridge.
+type limits struct {
+	// maxSize is the maximum size of a response body in bytes. If zero, it is
+	// unlimited.
+	maxSize int64
+
+	// timeout is the maximum time that a request, including reading the
+	// response, may take. If zero, it is unlimited.
+	timeout time.Duration
+}
+
+// ResponseTooLargeError is returned when a response is larger than allowed
+// by the MaxResponseSize option.
+type ResponseTooLargeError struct {
+	// URL is the URL of the request.
+	URL string
+
+	// Limit is the maximum size of a response in bytes.
+	Limit int64
+}
+
+func (e *ResponseTooLargeError) Error() string {
+	return fmt.Sprintf("response of %s exceeds %d bytes", e.URL, e.Limit)
+}
+
+// TimeoutError is returned when a request takes longer than allowed by the
+// WithTimeout option. Like failing to connect, it counts as the bridge being
+// unreachable.
+type TimeoutError struct {
+	// URL is the URL of the request.
+	URL string
+
+	// Timeout is the time that the request was allowed to take.
+	Timeout time.Duration
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("request to %s timed out after %v", e.URL, e.Timeout)
+}
+
+// context returns the context of a request, which is cancelled once the
+// timeout expires.
+func (l limits) context() (context.Context, context.CancelFunc) {
+	if l.timeout <= 0 {
+		return context.Background(), func() {}
+	}
+	return context.WithTimeout(context.Background(), l.timeout)
+}
+
+// read reads the response body r of the request to url, made using ctx.
+func (l limits) read(ctx context.Context, url string, r io.Reader) ([]byte, error) {
+	if l.maxSize > 0 {
+		r = io.LimitReader(r, l.maxSize+1)
+	}
+	slurp, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, l.check(ctx, url, err)
+	}
+	if l.maxSize > 0 && int64(len(slurp)) > l.maxSize {
+		return nil, &ResponseTooLargeError{URL: url, Limit: l.maxSize}
+	}
+	return slurp, nil
+}
+
+// check returns a *TimeoutError in place of err if the request made using ctx
+// timed out.
+func (l limits) check(ctx context.Context, url string, err error) error {
+	if ctx.Err() == context.DeadlineExceeded {
+		return &TimeoutError{URL: url, Timeout: l.timeout}
+	}
+	return err
+}
+
+// reader returns r, limited to the maximum size of a response. Reading past
+// it results in a *ResponseTooLargeError.
+func (l limits) reader(url string, r io.Reader) io.Reader {
+	if l.maxSize <= 0 {
+		return r
+	}
+	return &limitedReader{r: r, url: url, max: l.maxSize}
+}
+
+// limitedReader fails once more than max bytes are read from r.
+type limitedReader struct {
+	r   io.Reader
+	url string
+	n   int64
+	max int64
+}
+
+func (lr *limitedReader) Read(p []byte) (int, error) {
+	n, err := lr.r.Read(p)
+	lr.n += int64(n)
+	if lr.n > lr.max {
+		return n, &ResponseTooLargeError{URL: lr.url, Limit: lr.max}
+	}
+	return n, err
+}
@@ -0,0 +1,62 @@
+package hue
+
+import (
+	"errors"
+	"net/http"
+)
+
+// ErrNotDangerous is returned by maintenance operations, such as Reboot, when
+// the bridge was not obtained using the Dangerous option.
+var ErrNotDangerous = errors.New("operation requires the Dangerous option")
+
+// Reboot restarts the bridge. The API has no documented reboot command, so
+// Reboot uses the swupdate2 attributes of the configuration (see "Modify
+// configuration" in the Configuration API): it asks the bridge to check for
+// firmware updates and to install them, which restarts the bridge. Any
+// pending update of the bridge is therefore installed too. The bridge is
+// unreachable for about a minute afterwards. It requires the Dangerous option.
+func (b *Bridge) Reboot() error {
+	if !b.dangerous {
+		return ErrNotDangerous
+	}
+	_, err := b.call(http.MethodPut, map[string]interface{}{
+		"swupdate2": map[string]bool{"checkforupdate": true, "install": true},
+	}, "config")
+	return err
+}
+
+// DeleteAllRules deletes every rule on the bridge, including those created by
+// other applications. It requires the Dangerous option.
+func (b *Bridge) DeleteAllRules() error {
+	if !b.dangerous {
+		return ErrNotDangerous
+	}
+	rules, err := b.Rules().List()
+	if err != nil {
+		return err
+	}
+	for _, r := range rules {
+		if err := r.Delete(false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteAllSchedules deletes every schedule on the bridge, including those
+// created by other applications. It requires the Dangerous option.
+func (b *Bridge) DeleteAllSchedules() error {
+	if !b.dangerous {
+		return ErrNotDangerous
+	}
+	schedules, err := b.Schedules().List()
+	if err != nil {
+		return err
+	}
+	for _, s := range schedules {
+		if err := s.Delete(false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
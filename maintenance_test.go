@@ -0,0 +1,44 @@
+package hue
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestMaintenance(t *testing.T) {
+	mb := mockBridge(t)
+	defer mb.teardown()
+	mb.nextResponse = json.RawMessage(`{"1": {"name": "r1"}, "2": {"name": "r2"}}`)
+	if err := mb.b.Reboot(); err != ErrNotDangerous {
+		t.Fatalf("expected ErrNotDangerous, got %v", err)
+	}
+	if err := mb.b.DeleteAllRules(); err != ErrNotDangerous {
+		t.Fatalf("expected ErrNotDangerous, got %v", err)
+	}
+	if mb.lastMethod != "" {
+		t.Fatalf("unexpected request %s %s", mb.lastMethod, mb.lastPath)
+	}
+	mb.b.dangerous = true
+	if err := mb.b.Reboot(); err != nil {
+		t.Fatal(err)
+	}
+	if mb.lastMethod != http.MethodPut || mb.lastPath != "/api/bridge_username/config" {
+		t.Fatalf("unexpected request %s %s", mb.lastMethod, mb.lastPath)
+	}
+	body, err := ioutil.ReadAll(mb.lastBody)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"swupdate2":{"checkforupdate":true,"install":true}}`; strings.TrimSpace(string(body)) != want {
+		t.Fatalf("expected body %s, got %s", want, body)
+	}
+	if err := mb.b.DeleteAllSchedules(); err != nil {
+		t.Fatal(err)
+	}
+	if mb.lastMethod != http.MethodDelete {
+		t.Fatalf("unexpected request %s %s", mb.lastMethod, mb.lastPath)
+	}
+}
@@ -0,0 +1,38 @@
+package hue
+
+import (
+	"encoding/json"
+	"math"
+)
+
+// Mired is a color temperature in mireds (micro reciprocal degrees), as used
+// by the bridge. Lights made since 2012 are capable of 153 (6500K) to 500
+// (2000K). It is encoded as an integer, as the bridge expects.
+type Mired uint16
+
+// MiredFromKelvin returns the color temperature k, in Kelvin, in mireds.
+func MiredFromKelvin(k int) Mired {
+	if k <= 0 {
+		return 0
+	}
+	return Mired(math.Min(math.Round(1e6/float64(k)), math.MaxUint16))
+}
+
+// Kelvin returns the color temperature in Kelvin, or 0 if m is 0.
+func (m Mired) Kelvin() int {
+	if m == 0 {
+		return 0
+	}
+	return int(math.Round(1e6 / float64(m)))
+}
+
+// UnmarshalJSON decodes a color temperature, rounding fractional values, which
+// some bridge emulators report.
+func (m *Mired) UnmarshalJSON(data []byte) error {
+	var f float64
+	if err := json.Unmarshal(data, &f); err != nil {
+		return err
+	}
+	*m = Mired(math.Max(0, math.Min(math.Round(f), math.MaxUint16)))
+	return nil
+}
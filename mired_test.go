@@ -0,0 +1,29 @@
+package hue
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestMired(t *testing.T) {
+	if m := MiredFromKelvin(2700); m != 370 {
+		t.Fatalf("expected 370, got %d", m)
+	}
+	if k := Mired(153).Kelvin(); k != 6536 {
+		t.Fatalf("expected 6536, got %d", k)
+	}
+	var ls LightState
+	if err := json.Unmarshal([]byte(`{"ct": 366.6}`), &ls); err != nil {
+		t.Fatal(err)
+	}
+	if ls.ColorTemp != 367 {
+		t.Fatalf("expected 367, got %d", ls.ColorTemp)
+	}
+	data, err := json.Marshal(&State{Ct: 366})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"ct":366}` {
+		t.Fatalf("unexpected encoding %s", data)
+	}
+}
@@ -0,0 +1,108 @@
+package hue
+
+import (
+	"sort"
+	"strings"
+)
+
+// MotionSensor bundles the sensors that a physical Hue motion sensor exposes:
+// presence, light level and temperature.
+type MotionSensor struct {
+	// MAC is the MAC address of the physical device.
+	MAC string
+
+	// Presence is the presence sensor of the device.
+	Presence *Sensor
+
+	// LightLevel is the light level sensor of the device, if known.
+	LightLevel *Sensor
+
+	// Temperature is the temperature sensor of the device, if known.
+	Temperature *Sensor
+}
+
+// Name returns the name of the device, which is the name of its presence
+// sensor, as shown in the official app.
+func (m *MotionSensor) Name() string { return m.Presence.Name }
+
+// Config returns the configuration of the device, which is shared by all of its
+// sensors.
+func (m *MotionSensor) Config() SensorConfig { return m.Presence.Config }
+
+// SetOn enables or disables all the sensors of the device.
+func (m *MotionSensor) SetOn(on bool) error {
+	for _, s := range m.sensors() {
+		if err := s.SetOn(on); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sensors returns the known sensors of the device.
+func (m *MotionSensor) sensors() []*Sensor {
+	list := []*Sensor{m.Presence}
+	if m.LightLevel != nil {
+		list = append(list, m.LightLevel)
+	}
+	if m.Temperature != nil {
+		list = append(list, m.Temperature)
+	}
+	return list
+}
+
+// MotionSensors returns the physical motion sensors known to the bridge, sorted
+// by the ID of their presence sensor. The sensors of each device are matched
+// using the MAC address in their unique ID.
+func (s *SensorsService) MotionSensors() ([]*MotionSensor, error) {
+	list, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	byMAC := make(map[string]*MotionSensor)
+	for _, ss := range list {
+		mac := deviceMAC(ss.UID)
+		if mac == "" {
+			continue
+		}
+		m, ok := byMAC[mac]
+		if !ok {
+			m = &MotionSensor{MAC: mac}
+			byMAC[mac] = m
+		}
+		switch ss.Type {
+		case "ZLLPresence":
+			m.Presence = ss
+		case "ZLLLightLevel":
+			m.LightLevel = ss
+		case "ZLLTemperature":
+			m.Temperature = ss
+		}
+	}
+	var all []*MotionSensor
+	for _, m := range byMAC {
+		if m.Presence != nil {
+			all = append(all, m)
+		}
+	}
+	sort.Sort(motionSensorsByID(all))
+	return all, nil
+}
+
+// deviceMAC returns the MAC address part of a unique ID, which has the form
+// "AA:BB:CC:DD:EE:FF:00:11-XX-YYYY".
+func deviceMAC(uid string) string {
+	if i := strings.Index(uid, "-"); i >= 0 {
+		return uid[:i]
+	}
+	return uid
+}
+
+// motionSensorsByID sorts motion sensors by the ID of their presence sensor.
+type motionSensorsByID []*MotionSensor
+
+func (m motionSensorsByID) Len() int      { return len(m) }
+func (m motionSensorsByID) Swap(i, j int) { m[i], m[j] = m[j], m[i] }
+func (m motionSensorsByID) Less(i, j int) bool {
+	return lessID(m[i].Presence.ID, m[j].Presence.ID)
+}
@@ -0,0 +1,43 @@
+package hue
+
+import "testing"
+
+func TestMotionSensors(t *testing.T) {
+	mb := mockBridge(t)
+	defer mb.teardown()
+	battery := uint8(80)
+	mb.nextResponse = map[string]*Sensor{
+		"1": &Sensor{Name: "Daylight", Type: "Daylight"},
+		"4": &Sensor{UID: "00:17:88:01:02:00:af:28-02-0406", Type: "ZLLPresence", Name: "Hall", Config: SensorConfig{Battery: &battery}},
+		"5": &Sensor{UID: "00:17:88:01:02:00:af:28-02-0400", Type: "ZLLLightLevel"},
+		"6": &Sensor{UID: "00:17:88:01:02:00:af:28-02-0402", Type: "ZLLTemperature"},
+		"7": &Sensor{UID: "00:17:88:01:02:00:bb:11-02-0406", Type: "ZLLPresence", Name: "Kitchen"},
+		"8": &Sensor{UID: "00:17:88:01:02:00:cc:22-02-fc00", Type: "ZLLSwitch"},
+	}
+	list, err := mb.b.Sensors().MotionSensors()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 {
+		t.Fatalf("expected 2 motion sensors, got %d", len(list))
+	}
+	m := list[0]
+	if m.Name() != "Hall" || m.MAC != "00:17:88:01:02:00:af:28" {
+		t.Fatalf("unexpected motion sensor %+v", m)
+	}
+	if m.LightLevel == nil || m.LightLevel.ID != "5" || m.Temperature == nil || m.Temperature.ID != "6" {
+		t.Fatalf("expected companion sensors to be grouped, got %+v", m)
+	}
+	if b := m.Config().Battery; b == nil || *b != 80 {
+		t.Fatalf("expected battery of 80, got %v", b)
+	}
+	if list[1].Name() != "Kitchen" || list[1].LightLevel != nil {
+		t.Fatalf("unexpected motion sensor %+v", list[1])
+	}
+	if err := m.SetOn(false); err != nil {
+		t.Fatal(err)
+	}
+	if mb.lastPath != "/api/bridge_username/sensors/6/config" {
+		t.Fatalf("expected all sensors to be configured, last path was %s", mb.lastPath)
+	}
+}
@@ -0,0 +1,383 @@
+package hue
+
+import (
+	"fmt"
+	"time"
+)
+
+// Option configures the behaviour of Discover and of the returned Bridge.
+type Option func(*options)
+
+// options holds the settings that may be changed using an Option.
+type options struct {
+	// cachePath is the path of the cache file. If empty, the default location
+	// is used.
+	cachePath string
+
+	// noCache disables reading and writing the cache.
+	noCache bool
+
+	// strict enables strict decoding of responses.
+	strict bool
+
+	// wantID is the ID of the expected bridge. If empty, any bridge is
+	// accepted.
+	wantID string
+
+	// observe is called after every call to the API.
+	observe func(CallInfo)
+
+	// localOnly disables the remote discovery service.
+	localOnly bool
+
+	// cacheOnly disables discovery, leaving only the cache.
+	cacheOnly bool
+
+	// ssdpMX is the MX value of UPNP search requests. If zero, defaultMX is
+	// used.
+	ssdpMX int
+
+	// ssdpWindow is the time during which UPNP responses are collected. If
+	// zero, connDeadline is used.
+	ssdpWindow time.Duration
+
+	// readOnly disables all API calls which change the bridge.
+	readOnly bool
+
+	// repair is called before pairing again when credentials are rejected.
+	repair func() error
+
+	// trackReach enables tracking the reachability of lights.
+	trackReach bool
+
+	// trackEnergy enables estimating the energy used by lights.
+	trackEnergy bool
+
+	// secondary is a gateway which mirrors the bridge, if any.
+	secondary *Bridge
+
+	// dangerous allows maintenance operations.
+	dangerous bool
+
+	// clock is the source of time of time-based features.
+	clock Clock
+
+	// truncateNames truncates names which are too long.
+	truncateNames bool
+
+	// journal enables the command journal.
+	journal bool
+
+	// journalPath is the file that the journal is persisted to, if any.
+	journalPath string
+
+	// journalMaxAge is the age after which journaled commands are dropped.
+	journalMaxAge time.Duration
+
+	// auditPath is the file that the audit log is written to, if any.
+	auditPath string
+
+	// calibrate adjusts the colors sent to lights by model, if set.
+	calibrate Calibration
+
+	// limits holds the safeguards applied to responses.
+	limits limits
+
+	// app is the name of the application, if not the default.
+	app string
+
+	// swr enables returning stale listings while refreshing them.
+	swr bool
+
+	// detectStreaming enables checking for entertainment streams.
+	detectStreaming bool
+}
+
+// newOptions returns the options resulting from applying opts.
+func newOptions(opts []Option) *options {
+	o := new(options)
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// cacheFile returns the path of the cache file, or an empty string if caching
+// is disabled.
+func (o *options) cacheFile() string {
+	if o.noCache {
+		return ""
+	}
+	if o.cachePath == "" {
+		return defaultCachePath()
+	}
+	return o.cachePath
+}
+
+// apply applies the options to the bridge b.
+func (o *options) apply(b *Bridge) *Bridge {
+	b.strict = o.strict
+	b.verifyID = o.wantID != ""
+	b.observe = o.observe
+	b.readOnly = o.readOnly
+	b.dangerous = o.dangerous
+	b.clock = o.clock
+	b.truncateNames = o.truncateNames
+	b.limits = o.limits
+	b.detectStreaming = o.detectStreaming
+	b.calibrate = o.calibrate
+	if o.repair != nil {
+		b.repair = &repairFlow{prompt: o.repair}
+	}
+	b.warnings = new(warnings)
+	b.profile = new(profile)
+	b.groupPrefs = newGroupPrefs()
+	b.transitions = newTransitions()
+	if o.trackReach {
+		b.reach = new(reachability)
+	}
+	if o.trackEnergy {
+		b.energy = new(energyTracker)
+	}
+	if o.swr {
+		b.swr = &swrCache{entries: make(map[string][]byte), refreshing: make(map[string]bool)}
+	}
+	if o.journal {
+		b.journal = newJournal(o.journalPath, o.journalMaxAge)
+	}
+	if o.auditPath != "" {
+		b.audit = &auditLog{path: o.auditPath, states: make(map[string]LightState)}
+	}
+	if o.secondary != nil {
+		b.failover = &failover{secondary: *o.secondary}
+	}
+	return b
+}
+
+// CacheOnly causes Discover to only return a bridge found in the cache, and
+// ErrNotFound otherwise, instead of searching the network. It is meant for
+// quick lookups, such as shell completion, which should not block.
+func CacheOnly() Option {
+	return func(o *options) { o.cacheOnly = true }
+}
+
+// WithCachePath sets the path of the file where pairing data is cached. By
+// default, it is stored in ~/.hue.
+func WithCachePath(p string) Option {
+	return func(o *options) { o.cachePath = p }
+}
+
+// WithoutCache disables caching entirely. The bridge will be discovered on
+// every call to Discover and pairing data will not be persisted.
+func WithoutCache() Option {
+	return func(o *options) { o.noCache = true }
+}
+
+// Strict causes responses from the bridge which contain unknown fields, or
+// fields of an unexpected type, to result in errors. By default, such fields
+// are ignored and reported by Bridge.Warnings, so that the package keeps
+// working after bridge firmware updates. Strict mode is useful in tests.
+func Strict() Option {
+	return func(o *options) { o.strict = true }
+}
+
+// WithBridgeID causes Discover to only return the bridge with the given ID,
+// after checking that the device at the discovered address reports it. The
+// same check is done before pairing, so that credentials are never stored for
+// a different bridge, or a device merely answering UPNP requests in its place.
+// The ID may be given in its 16 character form or as the MAC address of the
+// bridge.
+func WithBridgeID(id string) Option {
+	return func(o *options) { o.wantID = id }
+}
+
+// WithObserver causes fn to be called after every call that the bridge makes
+// to its API. It may be used to collect metrics such as request counts,
+// latencies and error rates, for example to tell when an application is
+// sending commands faster than the bridge can handle (which it reports using
+// APIError code 901). fn is called synchronously and should return quickly.
+func WithObserver(fn func(CallInfo)) Option {
+	return func(o *options) { o.observe = fn }
+}
+
+// LocalOnly prevents Discover and DiscoverAll from contacting the remote
+// discovery service at meethue.com, so that no traffic leaves the local
+// network. Only UPNP is used. When Discover fails and the remote service
+// would have been tried, the returned *DiscoveryError reports ErrLocalOnly
+// for the "cloud" mechanism.
+func LocalOnly() Option {
+	return func(o *options) { o.localOnly = true }
+}
+
+// WithSSDP configures UPNP (SSDP) discovery. mx is the maximum number of
+// seconds that devices may wait before responding to a search, and window is
+// the time during which responses are collected. On busy networks, a lower mx
+// spreads responses less and allows for a shorter window; window should not
+// be shorter than mx seconds. Zero values keep the defaults: an mx of 10 and a
+// window of 5 seconds.
+func WithSSDP(mx int, window time.Duration) Option {
+	return func(o *options) {
+		o.ssdpMX = mx
+		o.ssdpWindow = window
+	}
+}
+
+// ReadOnly prevents the bridge from being changed: all methods which would
+// change it, including Pair, return ErrReadOnly without contacting it. It is
+// meant for monitoring dashboards and exporters, so that they can be given
+// credentials without the risk of changing anything.
+func ReadOnly() Option {
+	return func(o *options) { o.readOnly = true }
+}
+
+// WithRepair sets up pairing again when the bridge rejects the credentials,
+// for example because they were removed from its whitelist. Instead of
+// failing with ErrUnauthorized, the call invokes prompt, which should ask the
+// user to press the link button on the bridge and return once they have. The
+// bridge is then paired with, which updates the cache, and the call is
+// retried. If prompt returns an error, the call fails with it.
+func WithRepair(prompt func() error) Option {
+	return func(o *options) { o.repair = prompt }
+}
+
+// TrackReachability records the reachability of lights every time that they
+// are retrieved from the bridge, so that Light.LastSeen and
+// Bridge.ReachabilityHistory can be used to identify lights that often become
+// unreachable. Lights must be retrieved periodically for the history to be
+// useful, for example by calling LightsService.List once a minute.
+func TrackReachability() Option {
+	return func(o *options) { o.trackReach = true }
+}
+
+// TrackEnergy records the power draw of lights, estimated from their model,
+// on state and brightness, every time that they are retrieved from the
+// bridge, so that Light.EnergyEstimate and Room.EnergyEstimate can be used.
+// As with TrackReachability, lights must be retrieved periodically.
+func TrackEnergy() Option {
+	return func(o *options) { o.trackEnergy = true }
+}
+
+// WithSecondary sets a secondary gateway mirroring the bridge, such as a
+// diyHue or deCONZ instance, at the given address (e.g. "192.168.1.3:8080")
+// using the given username. While the bridge is unreachable, for example
+// during a reboot or firmware update, reads are sent to the secondary gateway
+// instead. Reads which get no response from the bridge within three seconds
+// also fail over. The bridge is checked every 30 seconds, and used again once
+// it responds. Commands that change state are always sent to the bridge.
+func WithSecondary(addr, username string) Option {
+	return func(o *options) {
+		o.secondary = &Bridge{
+			bridgeID: bridgeID{IP: fmt.Sprintf("http://%s/", addr)},
+			username: username,
+		}
+	}
+}
+
+// Dangerous allows the maintenance operations of the bridge, such as Reboot
+// and DeleteAllRules, which otherwise return ErrNotDangerous. They are meant
+// for managing bridges in labs and CI, and should not be used with a bridge
+// that a household depends on.
+func Dangerous() Option {
+	return func(o *options) { o.dangerous = true }
+}
+
+// WithClock sets the source of time of the time-based features of the bridge,
+// such as quiet hours, verified commands and the reachability and energy
+// trackers. By default, the system clock is used.
+func WithClock(c Clock) Option {
+	return func(o *options) { o.clock = c }
+}
+
+// TruncateNames causes names of lights, groups, scenes and schedules which are
+// longer than the 32 characters allowed by the bridge to be truncated, as the
+// bridge itself would do. By default, they are rejected with a *NameError.
+func TruncateNames() Option {
+	return func(o *options) { o.truncateNames = true }
+}
+
+// WithJournal records commands which fail because the bridge is unreachable,
+// returning ErrJournaled, and replays them in order once a request to the
+// bridge succeeds again, so that automations eventually converge on flaky
+// networks. A command replaces earlier ones to the same resource, and commands
+// older than maxAge are dropped (none are if maxAge is zero). If path is not
+// empty, the journal is persisted to the file at path, so that commands are
+// replayed even after a restart. Commands rejected by the bridge on replay are
+// logged and dropped.
+func WithJournal(path string, maxAge time.Duration) Option {
+	return func(o *options) {
+		o.journal = true
+		o.journalPath = path
+		o.journalMaxAge = maxAge
+	}
+}
+
+// WithAuditLog appends every command sent to the bridge, and every change of
+// the state of a light observed when retrieving lights, to the file at path,
+// as one JSON encoded AuditEntry per line. Changes are only observed when
+// lights are retrieved, for example using Bridge.Subscribe, and are
+// attributed to SourceBridge; those following commands of this application
+// are not recorded. The log helps finding out what turned a light on at 3am.
+func WithAuditLog(path string) Option {
+	return func(o *options) { o.auditPath = path }
+}
+
+// WithColorCompensation adjusts the colors and brightness sent to each light
+// by Light.Set and Group.SetUniform using the given calibration, so that
+// rooms mixing generations of lights look uniform. If cal is nil,
+// DefaultCompensation is used. Commands sent to groups using Set are not
+// adjusted, since the lights of a group may be of different models.
+func WithColorCompensation(cal Calibration) Option {
+	if cal == nil {
+		cal = DefaultCompensation.Calibrate
+	}
+	return func(o *options) { o.calibrate = cal }
+}
+
+// MaxResponseSize limits the size of the responses of the bridge to n bytes.
+// Larger responses result in a *ResponseTooLargeError instead of being read
+// into memory. It protects against misbehaving emulators, or a different
+// device answering at the address of the bridge. Bridges with many resources
+// return large responses when listing them, so n should be generous.
+func MaxResponseSize(n int64) Option {
+	return func(o *options) { o.limits.maxSize = n }
+}
+
+// WithTimeout limits the time that requests to the bridge may take, including
+// reading the response, to d. Requests which take longer result in a
+// *TimeoutError instead of blocking.
+func WithTimeout(d time.Duration) Option {
+	return func(o *options) { o.limits.timeout = d }
+}
+
+// WithAppName sets the name of the application, which is used when pairing
+// and keys the credentials stored in the cache, along with the ID of the
+// bridge. Programs on the same machine which use different names thus each
+// pair and keep their own credentials, rather than sharing them. By default,
+// the name "gbbr/hue" is used.
+func WithAppName(name string) Option {
+	return func(o *options) {
+		if name == defaultApp {
+			name = ""
+		}
+		o.app = name
+	}
+}
+
+// StaleWhileRevalidate makes the List, Get and GetByID methods of lights and
+// groups return the previously retrieved response immediately, if there is
+// one, while retrieving it again in the background for the next call. This
+// keeps user interfaces responsive, at the cost of showing changes one call
+// late. Lights and groups returned this way report so using their Stale
+// method. Other methods always retrieve current data.
+func StaleWhileRevalidate() Option {
+	return func(o *options) { o.swr = true }
+}
+
+// DetectStreaming makes commands to lights which are used by an active
+// entertainment stream fail with ErrStreamingActive, rather than appear to
+// succeed while the lights ignore them. Commands to a group fail if any of its
+// lights is used by a stream. This takes an additional request for every
+// command, to retrieve the groups.
+func DetectStreaming() Option {
+	return func(o *options) { o.detectStreaming = true }
+}
@@ -0,0 +1,118 @@
+package hue
+
+import (
+	"errors"
+	"sort"
+)
+
+// ErrNoCache is returned when setting the display order of a bridge whose
+// cache is disabled.
+var ErrNoCache = errors.New("caching is disabled")
+
+// displayOrder holds the user-defined display order of resources, as IDs.
+type displayOrder struct {
+	Lights []string `json:",omitempty"`
+	Groups []string `json:",omitempty"`
+}
+
+// SetLightOrder sets the order in which LightsService.Sorted returns lights
+// to the given IDs, so that applications can present lights in the same order
+// as the official app, or one chosen by the user. The order is stored in the
+// cache file along with the pairing data, and shared by all applications using
+// it, whichever name they paired with. Lights which are not listed come after
+// the listed ones, sorted by ID.
+func (b *Bridge) SetLightOrder(ids ...string) error {
+	return b.setOrder(func(o *displayOrder) { o.Lights = ids })
+}
+
+// SetGroupOrder sets the order in which GroupsService.Sorted returns groups,
+// in the same way as SetLightOrder.
+func (b *Bridge) SetGroupOrder(ids ...string) error {
+	return b.setOrder(func(o *displayOrder) { o.Groups = ids })
+}
+
+// setOrder applies fn to the display order of b, and stores the result in the
+// cache entries of b of all applications.
+func (b *Bridge) setOrder(fn func(*displayOrder)) error {
+	if b.cachePath == "" {
+		return ErrNoCache
+	}
+	list := readCache(b.cachePath)
+	order := orderOf(list, b)
+	fn(&order)
+	found := false
+	for i := range list {
+		if sameID(list[i].ID, b.ID) {
+			o := order
+			list[i].Order = &o
+			found = found || list[i].is(b)
+		}
+	}
+	if !found {
+		list = append(list, cachedBridge{ID: b.ID, IP: b.IP, Username: b.username, Name: b.Name, App: b.app, Order: &order})
+	}
+	return writeCache(b.cachePath, list)
+}
+
+// order returns the display order of b, which is empty if none was set.
+func (b *Bridge) order() displayOrder {
+	return orderOf(readCache(b.cachePath), b)
+}
+
+// orderOf returns the display order of b found in the cache entries of any
+// application, which is empty if none was set. The order is keyed by bridge
+// ID only.
+func orderOf(list []cachedBridge, b *Bridge) displayOrder {
+	for _, c := range list {
+		if sameID(c.ID, b.ID) && c.Order != nil {
+			return *c.Order
+		}
+	}
+	return displayOrder{}
+}
+
+// orderLess returns a function reporting whether the resource with ID a comes
+// before the one with ID b, given the display order of IDs.
+func orderLess(order []string) func(a, b string) bool {
+	rank := make(map[string]int, len(order))
+	for i, id := range order {
+		if _, ok := rank[id]; !ok {
+			rank[id] = i
+		}
+	}
+	return func(a, b string) bool {
+		ra, oka := rank[a]
+		rb, okb := rank[b]
+		switch {
+		case oka && okb:
+			return ra < rb
+		case oka != okb:
+			return oka
+		}
+		return lessID(a, b)
+	}
+}
+
+// Sorted returns a slice of all lights on the bridge, in the display order
+// set using Bridge.SetLightOrder.
+func (l *LightsService) Sorted() ([]*Light, error) {
+	list, err := l.List()
+	if err != nil {
+		return nil, err
+	}
+	less := orderLess(l.bridge.order().Lights)
+	sort.Slice(list, func(i, j int) bool { return less(list[i].ID, list[j].ID) })
+	return list, nil
+}
+
+// Sorted returns a slice of all groups on the bridge, in the display order
+// set using Bridge.SetGroupOrder.
+func (g *GroupsService) Sorted() ([]*Group, error) {
+	list, err := g.List()
+	if err != nil {
+		return nil, err
+	}
+	less := orderLess(g.bridge.order().Groups)
+	sort.Slice(list, func(i, j int) bool { return less(list[i].ID, list[j].ID) })
+	return list, nil
+}
@@ -0,0 +1,61 @@
+package hue
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"testing"
+)
+
+func TestSortedLights(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hue")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	mb := mockBridge(t)
+	defer mb.teardown()
+	mb.responses = map[string]interface{}{
+		"/api/bridge_username/lights": json.RawMessage(`{"1": {}, "2": {}, "3": {}, "10": {}}`),
+		"/api/bridge_username/groups": json.RawMessage(`{"1": {}, "2": {}}`),
+	}
+	if err := mb.b.SetLightOrder("3", "1"); err != ErrNoCache {
+		t.Fatalf("expected ErrNoCache, got %v", err)
+	}
+	mb.b.cachePath = path.Join(dir, ".hue-test")
+	toCache(mb.b)
+	if err := mb.b.SetLightOrder("3", "1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := mb.b.SetGroupOrder("2"); err != nil {
+		t.Fatal(err)
+	}
+	// pairing again keeps the order
+	toCache(mb.b)
+	lights, err := mb.b.Lights().Sorted()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, l := range lights {
+		got = append(got, l.ID)
+	}
+	if want := []string{"3", "1", "2", "10"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	groups, err := mb.b.Groups().Sorted()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(groups) != 2 || groups[0].ID != "2" || groups[1].ID != "1" {
+		t.Fatalf("unexpected order %v, %v", groups[0].ID, groups[1].ID)
+	}
+
+	// the order is shared by applications using other names
+	other := &Bridge{bridgeID: mb.b.bridgeID, cachePath: mb.b.cachePath, app: "other"}
+	if o := other.order(); !reflect.DeepEqual(o.Lights, []string{"3", "1"}) || !reflect.DeepEqual(o.Groups, []string{"2"}) {
+		t.Fatalf("expected the order to be shared, got %+v", o)
+	}
+}
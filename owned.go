@@ -0,0 +1,88 @@
+package hue
+
+import "net/http"
+
+// OwnerTag marks resources created by this package. Schedules created without
+// a description are given OwnerTag followed by a colon and the username of the
+// bridge, e.g. "gbbr/hue:1a2b3c". Bridges do not record the owner of
+// schedules, so it is used to tell which ones CleanupOwned should remove.
+const OwnerTag = "gbbr/hue"
+
+// ownerTag returns the description which marks schedules created using the
+// credentials of the bridge.
+func (b *Bridge) ownerTag() string { return OwnerTag + ":" + b.username }
+
+// CleanupOwned deletes the resource links, rules, schedules and scenes that
+// were created using the credentials of this bridge. Resource links, rules
+// and scenes are recognized by their owner, as recorded by the bridge, while
+// schedules are recognized by the description given to them by
+// SchedulesService.Create. CLIP sensors are not removed, since the bridge
+// does not record who created them. It is intended for test environments and
+// for iterating on automations without leaving resources behind.
+func (b *Bridge) CleanupOwned() error {
+	if err := b.cleanupLinks(); err != nil {
+		return err
+	}
+	rules, err := b.Rules().List()
+	if err != nil {
+		return err
+	}
+	for _, r := range rules {
+		if r.Owner == b.username {
+			if err := r.Delete(false); err != nil {
+				return err
+			}
+		}
+	}
+	schedules, err := b.Schedules().List()
+	if err != nil {
+		return err
+	}
+	for _, s := range schedules {
+		if s.Description == b.ownerTag() {
+			if err := s.Delete(false); err != nil {
+				return err
+			}
+		}
+	}
+	scenes, err := b.Scenes().List()
+	if err != nil {
+		return err
+	}
+	for _, s := range scenes {
+		if s.Owner == b.username {
+			if err := s.Delete(false); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// cleanupLinks deletes the resource links owned by the bridge's username, such
+// as those of installed templates and imported bundles. Bridges which do not
+// support resource links are skipped.
+func (b *Bridge) cleanupLinks() error {
+	msg, err := b.call(http.MethodGet, nil, "resourcelinks")
+	if _, ok := err.(*UnsupportedError); ok {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var links map[string]struct {
+		Owner string `json:"owner"`
+	}
+	if err := b.decode(msg, &links); err != nil {
+		return err
+	}
+	for id, l := range links {
+		if l.Owner != b.username {
+			continue
+		}
+		if _, err := b.call(http.MethodDelete, nil, "resourcelinks", id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package hue
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestCleanupOwned(t *testing.T) {
+	responses := map[string]string{
+		"GET /api/user/rules": `{
+			"1": {"name": "mine", "owner": "user", "conditions": [], "actions": []},
+			"2": {"name": "theirs", "owner": "app", "conditions": [], "actions": []}}`,
+		"GET /api/user/schedules": `{
+			"3": {"name": "mine", "description": "gbbr/hue:user", "localtime": "PT00:01:00",
+				"command": {"address": "/api/user/groups/0/action", "method": "PUT", "body": {}}},
+			"4": {"name": "theirs", "description": "gbbr/hue:app", "localtime": "PT00:01:00",
+				"command": {"address": "/api/user/groups/0/action", "method": "PUT", "body": {}}},
+			"5": {"name": "copied", "description": "gbbr/hue", "localtime": "PT00:01:00",
+				"command": {"address": "/api/user/groups/0/action", "method": "PUT", "body": {}}}}`,
+		"GET /api/user/resourcelinks": `{
+			"6": {"name": "mine", "owner": "user", "links": ["/rules/1"]},
+			"7": {"name": "theirs", "owner": "app", "links": ["/rules/2"]}}`,
+		"GET /api/user/scenes": `{
+			"ab12": {"name": "mine", "owner": "user", "lights": ["1"]},
+			"cd34": {"name": "theirs", "owner": "app", "lights": ["1"]}}`,
+	}
+	var deleted []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if resp, ok := responses[r.Method+" "+r.URL.Path]; ok {
+			w.Write([]byte(resp))
+			return
+		}
+		deleted = append(deleted, r.Method+" "+r.URL.Path)
+		w.Write([]byte(`[{"success": "ok"}]`))
+	}))
+	defer srv.Close()
+	b := &Bridge{bridgeID: bridgeID{IP: srv.URL + "/"}, username: "user"}
+	if err := b.CleanupOwned(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"DELETE /api/user/resourcelinks/6",
+		"DELETE /api/user/rules/1",
+		"DELETE /api/user/schedules/3",
+		"DELETE /api/user/scenes/ab12",
+	}
+	if !reflect.DeepEqual(deleted, want) {
+		t.Fatalf("expected %v, got %v", want, deleted)
+	}
+}
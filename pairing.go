@@ -0,0 +1,147 @@
+package hue
+
+import (
+	"context"
+	"time"
+)
+
+// errLinkButton is the APIError code returned by the bridge when pairing is
+// attempted before its link button is pressed.
+const errLinkButton = 101
+
+// pairInterval is the interval between pairing attempts while waiting for the
+// link button to be pressed.
+var pairInterval = time.Second
+
+// PairingState is a state of the pairing process run by StartPairing.
+type PairingState int
+
+// Pairing states. The process starts by discovering a bridge, then waits for
+// its link button to be pressed and ends in either PairingPaired or
+// PairingFailed.
+const (
+	PairingDiscovering PairingState = iota
+	PairingWaitingForButton
+	PairingPaired
+	PairingFailed
+)
+
+func (s PairingState) String() string {
+	switch s {
+	case PairingDiscovering:
+		return "discovering"
+	case PairingWaitingForButton:
+		return "waiting for button"
+	case PairingPaired:
+		return "paired"
+	case PairingFailed:
+		return "failed"
+	}
+	return "unknown"
+}
+
+// PairingProgress reports the progress of the pairing process.
+type PairingProgress struct {
+	// State is the current state of the process.
+	State PairingState
+
+	// TimeLeft is the time left for pressing the link button, in state
+	// PairingWaitingForButton.
+	TimeLeft time.Duration
+
+	// Bridge is the bridge being paired with. It is nil while discovering.
+	Bridge *Bridge
+
+	// Err is the reason of failure, in state PairingFailed.
+	Err error
+}
+
+// StartPairing discovers a bridge using the given options and pairs with it,
+// allowing the user the given time to press its link button. It is meant for
+// applications which show onboarding screens: the progress of the process is
+// sent on the returned channel, which is closed after the final state,
+// PairingPaired or PairingFailed. While waiting for the button, progress is
+// sent about once a second. If the bridge was already paired with, pairing is
+// skipped. Cancelling the context stops the process, which then fails with the
+// error of the context. The final state is always delivered: once the context
+// is done, progress which was not read yet is dropped in its favor, so that a
+// caller which stops reading and cancels the context does not block the
+// process.
+func StartPairing(ctx context.Context, timeout time.Duration, opts ...Option) <-chan PairingProgress {
+	ch := make(chan PairingProgress, 1)
+	go func() {
+		defer close(ch)
+		var (
+			b   *Bridge
+			err error
+		)
+		if sendProgress(ctx, ch, PairingProgress{State: PairingDiscovering}) {
+			b, err = Discover(opts...)
+		}
+		if err == nil {
+			err = ctx.Err()
+		}
+		if err == nil && !b.IsPaired() {
+			err = waitForButton(ctx, b, timeout, ch)
+		}
+		if err != nil {
+			sendFinal(ctx, ch, PairingProgress{State: PairingFailed, Bridge: b, Err: err})
+			return
+		}
+		sendFinal(ctx, ch, PairingProgress{State: PairingPaired, Bridge: b})
+	}()
+	return ch
+}
+
+// sendProgress sends pr on ch, unless the context is done first. It reports
+// whether pr was sent.
+func sendProgress(ctx context.Context, ch chan<- PairingProgress, pr PairingProgress) bool {
+	select {
+	case ch <- pr:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// sendFinal sends the final state pr on ch, which has a buffer of one and
+// only this process sends on. Once the context is done, the progress left
+// unread in the buffer, if any, is replaced by pr, so that sending does not
+// block.
+func sendFinal(ctx context.Context, ch chan PairingProgress, pr PairingProgress) {
+	if sendProgress(ctx, ch, pr) {
+		return
+	}
+	select {
+	case <-ch:
+	default:
+	}
+	ch <- pr
+}
+
+// waitForButton attempts to pair with bridge b until the link button is
+// pressed, the timeout expires or the context is done, reporting progress on
+// ch.
+func waitForButton(ctx context.Context, b *Bridge, timeout time.Duration, ch chan<- PairingProgress) error {
+	deadline := time.Now().Add(timeout)
+	tick := time.NewTicker(pairInterval)
+	defer tick.Stop()
+	for {
+		left := time.Until(deadline)
+		if left < 0 {
+			left = 0
+		}
+		if !sendProgress(ctx, ch, PairingProgress{State: PairingWaitingForButton, TimeLeft: left, Bridge: b}) {
+			return ctx.Err()
+		}
+		err := b.Pair()
+		if e, ok := err.(APIError); !ok || e.Code != errLinkButton || left == 0 {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-tick.C:
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package hue
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"testing"
+	"time"
+)
+
+func TestStartPairing(t *testing.T) {
+	defer func(d time.Duration) { pairInterval = d }(pairInterval)
+	pairInterval = time.Millisecond
+	var attempts int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts < 3 {
+			w.Write([]byte(`[{"error": {"type": 101, "address": "", "description": "link button not pressed"}}]`))
+			return
+		}
+		w.Write([]byte(`[{"success": {"username": "user"}}]`))
+	}))
+	defer srv.Close()
+	dir, err := ioutil.TempDir("", "hue")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	p := path.Join(dir, "bridge.json")
+	toCache(&Bridge{bridgeID: bridgeID{ID: "id", IP: srv.URL + "/"}, cachePath: p})
+
+	var states []PairingState
+	var last PairingProgress
+	for pr := range StartPairing(context.Background(), time.Minute, WithCachePath(p)) {
+		states = append(states, pr.State)
+		last = pr
+	}
+	want := []PairingState{PairingDiscovering, PairingWaitingForButton, PairingWaitingForButton, PairingWaitingForButton, PairingPaired}
+	if len(states) != len(want) {
+		t.Fatalf("expected %v, got %v", want, states)
+	}
+	for i := range want {
+		if states[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, states)
+		}
+	}
+	if last.Bridge == nil || last.Bridge.username != "user" {
+		t.Fatalf("unexpected result %+v", last)
+	}
+}
+
+func TestStartPairingCancel(t *testing.T) {
+	defer func(d time.Duration) { pairInterval = d }(pairInterval)
+	pairInterval = time.Millisecond
+	srv := serverWithResponse(`[{"error": {"type": 101, "address": "", "description": "link button not pressed"}}]`)
+	defer srv.Close()
+	dir, err := ioutil.TempDir("", "hue")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	p := path.Join(dir, "bridge.json")
+	toCache(&Bridge{bridgeID: bridgeID{ID: "id", IP: srv.URL + "/"}, cachePath: p})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := StartPairing(ctx, time.Minute, WithCachePath(p))
+	if pr := <-ch; pr.State != PairingDiscovering {
+		t.Fatalf("unexpected progress %+v", pr)
+	}
+	// stop reading: the process must not block on sending progress, and
+	// still delivers its final state
+	cancel()
+	time.Sleep(50 * time.Millisecond)
+	if pr := <-ch; pr.State != PairingFailed || pr.Err != context.Canceled {
+		t.Fatalf("expected to fail with %v, got %+v", context.Canceled, pr)
+	}
+	select {
+	case pr, ok := <-ch:
+		if ok {
+			t.Fatalf("expected the channel to be closed, got %+v", pr)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the channel to be closed")
+	}
+}
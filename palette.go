@@ -0,0 +1,107 @@
+package hue
+
+import (
+	"image/color"
+	"math"
+	"sort"
+)
+
+// rotate returns the color p with its hue rotated by the given number of
+// degrees, keeping its saturation.
+func rotate(p XY, degrees float64) XY {
+	h, s, v := rgbToHSV(xyToRGB(p[0], p[1]))
+	h = math.Mod(h+degrees, 360)
+	if h < 0 {
+		h += 360
+	}
+	return xyFromHSV(h, s, v)
+}
+
+// xyFromHSV returns the color with the given hue, in degrees, saturation and
+// value, which are between 0 and 1.
+func xyFromHSV(h, s, v float64) XY {
+	r, g, b := hsvToRGB(h, s, v)
+	return XYFromRGB(color.RGBA64{
+		R: uint16(math.Round(r * 0xffff)),
+		G: uint16(math.Round(g * 0xffff)),
+		B: uint16(math.Round(b * 0xffff)),
+		A: 0xffff,
+	})
+}
+
+// rgbToHSV converts a color from RGB to HSV, with components between 0 and 1
+// and the hue in degrees.
+func rgbToHSV(r, g, b float64) (h, s, v float64) {
+	v = math.Max(r, math.Max(g, b))
+	c := v - math.Min(r, math.Min(g, b))
+	if v > 0 {
+		s = c / v
+	}
+	switch {
+	case c == 0:
+		h = 0
+	case v == r:
+		h = 60 * math.Mod((g-b)/c, 6)
+	case v == g:
+		h = 60 * ((b-r)/c + 2)
+	default:
+		h = 60 * ((r-g)/c + 4)
+	}
+	if h < 0 {
+		h += 360
+	}
+	return h, s, v
+}
+
+// Complementary returns the seed color and the color opposite to it on the
+// color wheel.
+func Complementary(seed XY) []XY {
+	return []XY{seed, rotate(seed, 180)}
+}
+
+// Triadic returns the seed color and the two colors which are evenly spaced
+// from it on the color wheel.
+func Triadic(seed XY) []XY {
+	return []XY{seed, rotate(seed, 120), rotate(seed, 240)}
+}
+
+// Analogous returns n colors which are next to each other on the color wheel,
+// spread evenly over the given number of degrees and centered on the seed
+// color. A spread of 30 to 60 degrees gives harmonious results.
+func Analogous(seed XY, n int, spread float64) []XY {
+	if n <= 1 {
+		return []XY{seed}
+	}
+	list := make([]XY, n)
+	for i := range list {
+		list[i] = rotate(seed, -spread/2+spread*float64(i)/float64(n-1))
+	}
+	return list
+}
+
+// SetPalette assigns the given colors to the lights of the group, in the
+// order of their IDs, starting over when there are more lights than colors.
+// The lights are turned on at the given brightness. Colors which a light can
+// not show are mapped to the closest ones it can by the bridge. All lights are
+// attempted; if some fail, a *LightsError identifying them is returned.
+func (g *Group) SetPalette(colors []XY, brightness uint8) error {
+	if len(colors) == 0 {
+		return nil
+	}
+	lights, err := g.Members().List()
+	if err != nil {
+		return err
+	}
+	sort.Slice(lights, func(i, j int) bool { return lessID(lights[i].ID, lights[j].ID) })
+	errs := make(map[string]error)
+	for i, l := range lights {
+		xy := colors[i%len(colors)]
+		if err := l.Set(&State{On: true, Brightness: brightness, XY: &xy}); err != nil {
+			errs[l.ID] = err
+		}
+	}
+	if len(errs) > 0 {
+		return &LightsError{Errors: errs}
+	}
+	return nil
+}
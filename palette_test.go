@@ -0,0 +1,56 @@
+package hue_test
+
+import (
+	"image/color"
+	"math"
+	"testing"
+	"time"
+
+	"gbbr.io/hue"
+	"gbbr.io/hue/huetest"
+)
+
+func TestPalettes(t *testing.T) {
+	red := hue.XYFromRGB(color.RGBA{R: 255, A: 255})
+	cyan := hue.XYFromRGB(color.RGBA{G: 255, B: 255, A: 255})
+	if got := hue.Complementary(red); len(got) != 2 || got[1].Distance(cyan) > 0.001 {
+		t.Fatalf("expected cyan, got %v", got)
+	}
+	green := hue.XYFromRGB(color.RGBA{G: 255, A: 255})
+	blue := hue.XYFromRGB(color.RGBA{B: 255, A: 255})
+	if got := hue.Triadic(red); got[1].Distance(green) > 0.001 || got[2].Distance(blue) > 0.001 {
+		t.Fatalf("expected red, green and blue, got %v", got)
+	}
+	got := hue.Analogous(red, 3, 60)
+	if len(got) != 3 || got[1].Distance(red) > 0.001 {
+		t.Fatalf("expected red in the middle, got %v", got)
+	}
+	h0, _, _ := hue.RGBToHSV(hue.XYToRGB(got[0][0], got[0][1]))
+	h2, _, _ := hue.RGBToHSV(hue.XYToRGB(got[2][0], got[2][1]))
+	if math.Abs(h0-330) > 1 || math.Abs(h2-30) > 1 {
+		t.Fatalf("expected hues 330 and 30, got %v and %v", h0, h2)
+	}
+}
+
+func TestSetPalette(t *testing.T) {
+	sim := huetest.NewSimulator(time.Date(2017, 1, 1, 20, 0, 0, 0, time.UTC), "a", "b", "c")
+	defer sim.Close()
+	b := sim.Bridge()
+	g, err := b.Groups().GetByID(sim.AddGroup("Living", "1", "2", "3"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	colors := []hue.XY{{0.6, 0.3}, {0.2, 0.2}}
+	if err := g.SetPalette(colors, 100); err != nil {
+		t.Fatal(err)
+	}
+	lights, err := hue.LightsByID(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for id, want := range map[string]hue.XY{"1": colors[0], "2": colors[1], "3": colors[0]} {
+		if st := lights[id].State; !st.On || st.Brightness != 100 || st.XY != want {
+			t.Fatalf("light %s: expected %v, got %+v", id, want, st)
+		}
+	}
+}
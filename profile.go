@@ -0,0 +1,79 @@
+package hue
+
+import (
+	"fmt"
+	"sync"
+)
+
+// ModelV1 is the model ID of the original, round bridge. It implements an old
+// version of the API, lacking resource links, group scenes and entertainment
+// areas.
+const ModelV1 = "BSB001"
+
+// UnsupportedError is returned when using a feature which the bridge does not
+// implement, instead of the error that it would report.
+type UnsupportedError struct {
+	// Feature describes the feature that was used.
+	Feature string
+
+	// ModelID is the model ID of the bridge.
+	ModelID string
+}
+
+func (e *UnsupportedError) Error() string {
+	return fmt.Sprintf("%s not supported by bridge model %s", e.Feature, e.ModelID)
+}
+
+// Features which are not supported by all bridges.
+const (
+	featureResourceLinks = "resource links"
+	featureGroupScenes   = "group scenes"
+	featureEntertainment = "entertainment areas"
+)
+
+// v1Unsupported holds the features which are not supported by the v1 bridge.
+var v1Unsupported = map[string]bool{
+	featureResourceLinks: true,
+	featureGroupScenes:   true,
+	featureEntertainment: true,
+}
+
+// profile holds the model of a bridge, which is detected on first use.
+type profile struct {
+	mu       sync.Mutex
+	detected bool
+	modelID  string
+}
+
+// supports returns an *UnsupportedError if the bridge does not support
+// feature. The model of the bridge is detected on the first call; if that
+// fails, the error is returned and detection is tried again on the next one.
+func (b *Bridge) supports(feature string) error {
+	if b.profile == nil {
+		return nil
+	}
+	p := b.profile
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.detected {
+		c, err := b.Config()
+		if err != nil {
+			return err
+		}
+		p.modelID = c.ModelID
+		p.detected = true
+	}
+	if p.modelID == ModelV1 && v1Unsupported[feature] {
+		return &UnsupportedError{Feature: feature, ModelID: p.modelID}
+	}
+	return nil
+}
+
+// resourceFeature returns the feature used when accessing the resource at
+// tokens, if it is not supported by all bridges.
+func resourceFeature(tokens []string) string {
+	if len(tokens) > 0 && tokens[0] == "resourcelinks" {
+		return featureResourceLinks
+	}
+	return ""
+}
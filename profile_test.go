@@ -0,0 +1,69 @@
+package hue
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestProfileV1(t *testing.T) {
+	var configs, links int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/user/config":
+			configs++
+			w.Write([]byte(`{"modelid": "BSB001"}`))
+		case "/api/user/resourcelinks":
+			links++
+			w.Write([]byte(`{}`))
+		default:
+			w.Write([]byte(`{}`))
+		}
+	}))
+	defer srv.Close()
+	b := &Bridge{bridgeID: bridgeID{IP: srv.URL + "/"}, username: "user", profile: new(profile)}
+	_, err := b.Do(http.MethodGet, "resourcelinks", nil)
+	if e, ok := err.(*UnsupportedError); !ok || e.Feature != featureResourceLinks || e.ModelID != ModelV1 {
+		t.Fatalf("expected *UnsupportedError, got %v", err)
+	}
+	err = b.Scenes().Create(&Scene{Name: "s", Type: GroupScene, Group: "1"})
+	if _, ok := err.(*UnsupportedError); !ok {
+		t.Fatalf("expected *UnsupportedError, got %v", err)
+	}
+	if _, err := b.References("lights", "1"); err != nil {
+		t.Fatal(err)
+	}
+	if configs != 1 || links != 0 {
+		t.Fatalf("expected 1 config and no resource link requests, got %d and %d", configs, links)
+	}
+}
+
+func TestProfileRetry(t *testing.T) {
+	var configs int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/user/config":
+			configs++
+			if configs == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.Write([]byte(`{"modelid": "BSB002"}`))
+		default:
+			w.Write([]byte(`{}`))
+		}
+	}))
+	defer srv.Close()
+	b := &Bridge{bridgeID: bridgeID{IP: srv.URL + "/"}, username: "user", profile: new(profile)}
+	if _, err := b.Do(http.MethodGet, "resourcelinks", nil); err == nil {
+		t.Fatal("expected the failed detection to be reported")
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := b.Do(http.MethodGet, "resourcelinks", nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if configs != 2 {
+		t.Fatalf("expected detection to be retried once, got %d config requests", configs)
+	}
+}
@@ -0,0 +1,72 @@
+package hue
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrQuietHours is returned by automated changes to a group which are
+// suppressed because of its quiet hours.
+var ErrQuietHours = errors.New("change suppressed during quiet hours")
+
+// QuietHours is a daily period during which automated changes to a group are
+// suppressed, such as the night hours of a nursery.
+type QuietHours struct {
+	// From and To are the times of day, in local time, at which the period
+	// starts and ends, given as the time since midnight. If To is before
+	// From, the period spans midnight.
+	From, To time.Duration
+}
+
+// Contains reports whether the time of day of t falls within the period.
+func (q QuietHours) Contains(t time.Time) bool {
+	h, m, s := t.Clock()
+	d := time.Duration(h)*time.Hour + time.Duration(m)*time.Minute + time.Duration(s)*time.Second
+	if q.From <= q.To {
+		return d >= q.From && d < q.To
+	}
+	return d >= q.From || d < q.To
+}
+
+// SetQuietHours sets the periods during which automated changes to the group
+// with the given ID are suppressed, replacing any previous ones. Without
+// periods, the quiet hours of the group are removed. Automated changes are
+// those made by Follower, SetIfDark, SetAutomated, RunEffect, Notify and
+// TurnOnAppropriately; all other methods, such as Set, are meant for explicit
+// requests of the user and are never affected.
+func (b *Bridge) SetQuietHours(groupID string, periods ...QuietHours) {
+	p := b.groupPrefs
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(periods) == 0 {
+		delete(p.quiet, groupID)
+		return
+	}
+	p.quiet[groupID] = periods
+}
+
+// isQuiet reports whether the group is within its quiet hours at time t.
+func (g *Group) isQuiet(t time.Time) bool {
+	q := g.bridge.groupPrefs
+	if q == nil {
+		return false
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, p := range q.quiet[g.ID] {
+		if p.Contains(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// SetAutomated is like Set, but is meant for changes which are not explicitly
+// requested by the user. During the quiet hours of the group, it does nothing
+// and returns ErrQuietHours.
+func (g *Group) SetAutomated(s *State) error {
+	if g.isQuiet(g.bridge.now()) {
+		return ErrQuietHours
+	}
+	return g.Set(s)
+}
@@ -0,0 +1,56 @@
+package hue
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestQuietHoursContains(t *testing.T) {
+	at := func(h, m int) time.Time { return time.Date(2018, 1, 1, h, m, 0, 0, time.Local) }
+	for _, tt := range []struct {
+		q    QuietHours
+		t    time.Time
+		want bool
+	}{
+		{QuietHours{From: 13 * time.Hour, To: 15 * time.Hour}, at(14, 0), true},
+		{QuietHours{From: 13 * time.Hour, To: 15 * time.Hour}, at(15, 0), false},
+		{QuietHours{From: 19 * time.Hour, To: 7 * time.Hour}, at(23, 30), true},
+		{QuietHours{From: 19 * time.Hour, To: 7 * time.Hour}, at(6, 59), true},
+		{QuietHours{From: 19 * time.Hour, To: 7 * time.Hour}, at(12, 0), false},
+	} {
+		if got := tt.q.Contains(tt.t); got != tt.want {
+			t.Errorf("%v.Contains(%v): expected %v, got %v", tt.q, tt.t, tt.want, got)
+		}
+	}
+}
+
+func TestSetAutomated(t *testing.T) {
+	mb := mockBridge(t)
+	defer mb.teardown()
+	clock := new(fakeClock)
+	mb.b.clock = clock
+	mb.nextResponse = json.RawMessage(`{"name": "Nursery"}`)
+	g := &Group{bridge: mb.b, ID: "2"}
+	mb.b.SetQuietHours("2", QuietHours{From: 19 * time.Hour, To: 7 * time.Hour})
+
+	clock.set(time.Date(2018, 1, 1, 22, 0, 0, 0, time.Local))
+	if err := g.SetAutomated(&State{On: true}); err != ErrQuietHours {
+		t.Fatalf("expected ErrQuietHours, got %v", err)
+	}
+	if mb.lastMethod != "" {
+		t.Fatalf("unexpected request %s %s", mb.lastMethod, mb.lastPath)
+	}
+	if err := g.Set(&State{On: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	clock.set(time.Date(2018, 1, 1, 9, 0, 0, 0, time.Local))
+	mb.lastMethod = ""
+	if err := g.SetAutomated(&State{On: true}); err != nil {
+		t.Fatal(err)
+	}
+	if mb.lastMethod == "" {
+		t.Fatal("expected request")
+	}
+}
@@ -0,0 +1,73 @@
+package hue
+
+import (
+	"sync"
+	"time"
+)
+
+// maxReachabilityHistory is the number of changes kept per light.
+const maxReachabilityHistory = 100
+
+// ReachabilityChange records the reachability of a light changing, as seen by
+// the package.
+type ReachabilityChange struct {
+	// Time is the time at which the change was observed.
+	Time time.Time
+
+	// Reachable reports whether the light became reachable or unreachable.
+	Reachable bool
+}
+
+// reachability tracks the reachability of lights across calls.
+type reachability struct {
+	mu       sync.Mutex
+	lastSeen map[string]time.Time
+	history  map[string][]ReachabilityChange
+}
+
+// observe records the reachability of light l at time t.
+func (r *reachability) observe(l *Light, t time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.history == nil {
+		r.lastSeen = make(map[string]time.Time)
+		r.history = make(map[string][]ReachabilityChange)
+	}
+	reachable := l.State.Reachable
+	if reachable {
+		r.lastSeen[l.ID] = t
+	}
+	h := r.history[l.ID]
+	if len(h) > 0 && h[len(h)-1].Reachable == reachable {
+		return
+	}
+	if len(h) == maxReachabilityHistory {
+		h = append(h[:0], h[1:]...)
+	}
+	r.history[l.ID] = append(h, ReachabilityChange{Time: t, Reachable: reachable})
+}
+
+// LastSeen returns the last time at which the light was seen to be reachable,
+// or the zero time if it never was. It requires the TrackReachability option.
+func (l *Light) LastSeen() time.Time {
+	r := l.bridge.reach
+	if r == nil {
+		return time.Time{}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.lastSeen[l.ID]
+}
+
+// ReachabilityHistory returns the changes in reachability of the light with
+// the given ID, oldest first, up to the last 100. The first entry holds the
+// reachability of the light when it was first seen. It requires the
+// TrackReachability option.
+func (b *Bridge) ReachabilityHistory(id string) []ReachabilityChange {
+	if b.reach == nil {
+		return nil
+	}
+	b.reach.mu.Lock()
+	defer b.reach.mu.Unlock()
+	return append([]ReachabilityChange(nil), b.reach.history[id]...)
+}
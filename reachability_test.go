@@ -0,0 +1,34 @@
+package hue
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestReachabilityHistory(t *testing.T) {
+	var bodies []string
+	for _, reachable := range []bool{true, true, false, false, true} {
+		bodies = append(bodies, fmt.Sprintf(`{"1": {"name": "l1", "state": {"reachable": %v}}}`, reachable))
+	}
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	b := &Bridge{reach: new(reachability)}
+	list, _ := pollLights(t, b, start, time.Minute, bodies...)
+	if got, want := list[0].LastSeen(), start.Add(4*time.Minute); !got.Equal(want) {
+		t.Fatalf("expected last seen %v, got %v", want, got)
+	}
+	h := b.ReachabilityHistory("1")
+	want := []ReachabilityChange{
+		{Time: start, Reachable: true},
+		{Time: start.Add(2 * time.Minute), Reachable: false},
+		{Time: start.Add(4 * time.Minute), Reachable: true},
+	}
+	if len(h) != len(want) {
+		t.Fatalf("expected %v, got %v", want, h)
+	}
+	for j := range want {
+		if !h[j].Time.Equal(want[j].Time) || h[j].Reachable != want[j].Reachable {
+			t.Fatalf("expected %v, got %v", want, h)
+		}
+	}
+}
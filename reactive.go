@@ -0,0 +1,119 @@
+package hue
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Envelope smooths a signal, such as the amplitude of audio, using separate
+// attack and decay times. The zero value follows the signal without smoothing.
+type Envelope struct {
+	// Attack is the time it takes the envelope to rise about 63% of the way
+	// towards a higher input.
+	Attack time.Duration
+
+	// Decay is the time it takes the envelope to fall about 63% of the way
+	// towards a lower input.
+	Decay time.Duration
+
+	level float64
+	last  time.Time
+}
+
+// Next feeds value v, observed at time t, to the envelope and returns the new
+// level of the envelope.
+func (e *Envelope) Next(v float64, t time.Time) float64 {
+	tau := e.Decay
+	if v > e.level {
+		tau = e.Attack
+	}
+	if e.last.IsZero() || tau <= 0 {
+		e.level = v
+	} else {
+		dt := t.Sub(e.last)
+		e.level += (v - e.level) * (1 - math.Exp(-float64(dt)/float64(tau)))
+	}
+	e.last = t
+	return e.level
+}
+
+// Level returns the current level of the envelope.
+func (e *Envelope) Level() float64 { return e.level }
+
+// Mapping converts the level of an envelope, between 0 and 1, into the state
+// that should be applied to a group.
+type Mapping func(level float64) *State
+
+// BrightnessMapping maps the level linearly onto the brightness range.
+func BrightnessMapping(level float64) *State {
+	level = math.Max(0, math.Min(1, level))
+	return &State{On: true, Brightness: uint8(1 + level*253)}
+}
+
+// defaultFollowInterval is the default interval between updates of a group.
+// The bridge can not handle more than about one group command per second.
+const defaultFollowInterval = time.Second
+
+// Follower drives groups of lights from a stream of amplitude or beat
+// events, smoothing them using an envelope.
+type Follower struct {
+	// Envelope is used to smooth incoming events.
+	Envelope Envelope
+
+	// Groups maps each group that follows the envelope to the function
+	// which computes its state. A nil Mapping defaults to BrightnessMapping.
+	Groups map[*Group]Mapping
+
+	// Interval is the time between updates sent to the bridge. It defaults
+	// to one second. The transition time of each update matches it, so that
+	// the lights change smoothly.
+	Interval time.Duration
+
+	// Clock is the source of time of the follower. If nil, the system clock
+	// is used.
+	Clock Clock
+}
+
+// Run consumes levels, between 0 and 1, from the given channel and applies
+// them to the groups until the channel is closed or the context is done. It
+// returns the first error encountered while updating a group. Groups within
+// their quiet hours are not updated.
+func (f *Follower) Run(ctx context.Context, levels <-chan float64) error {
+	interval := f.Interval
+	if interval <= 0 {
+		interval = defaultFollowInterval
+	}
+	clock := clockOf(f.Clock)
+	tick := clock.After(interval)
+	var changed bool
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case v, ok := <-levels:
+			if !ok {
+				return nil
+			}
+			f.Envelope.Next(v, clock.Now())
+			changed = true
+		case <-tick:
+			tick = clock.After(interval)
+			if !changed {
+				continue
+			}
+			changed = false
+			level := f.Envelope.Level()
+			for g, fn := range f.Groups {
+				if fn == nil {
+					fn = BrightnessMapping
+				}
+				s := fn(level)
+				s.TransitionTime = uint16(interval / (100 * time.Millisecond))
+				if err := g.SetAutomated(s); err != nil && err != ErrQuietHours {
+					return err
+				}
+			}
+		}
+	}
+}
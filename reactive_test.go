@@ -0,0 +1,69 @@
+package hue
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestEnvelope(t *testing.T) {
+	now := time.Now()
+	e := Envelope{Attack: time.Second, Decay: 2 * time.Second}
+	if got := e.Next(0.5, now); got != 0.5 {
+		t.Fatalf("expected first value to be taken as is, got %v", got)
+	}
+	// rise by 1-1/e after one attack period
+	want := 0.5 + 0.5*(1-math.Exp(-1))
+	if got := e.Next(1, now.Add(time.Second)); math.Abs(got-want) > 1e-9 {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	// fall by 1-1/e after one decay period
+	want = want * math.Exp(-1)
+	if got := e.Next(0, now.Add(3*time.Second)); math.Abs(got-want) > 1e-9 {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestBrightnessMapping(t *testing.T) {
+	for level, want := range map[float64]uint8{-1: 1, 0: 1, 0.5: 127, 1: 254, 2: 254} {
+		if got := BrightnessMapping(level).Brightness; got != want {
+			t.Fatalf("level %v: expected %d, got %d", level, want, got)
+		}
+	}
+}
+
+func TestFollower(t *testing.T) {
+	mb := mockBridge(t)
+	defer mb.teardown()
+	var sent []*State
+	f := &Follower{
+		Groups: map[*Group]Mapping{
+			&Group{bridge: mb.b, ID: "1"}: func(level float64) *State {
+				s := BrightnessMapping(level)
+				sent = append(sent, s)
+				return s
+			},
+		},
+		Interval: 10 * time.Millisecond,
+	}
+	levels := make(chan float64)
+	done := make(chan error)
+	go func() { done <- f.Run(context.Background(), levels) }()
+	levels <- 1
+	time.Sleep(50 * time.Millisecond)
+	close(levels)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if len(sent) != 1 {
+		t.Fatalf("expected exactly one update, got %d", len(sent))
+	}
+	if sent[0].Brightness != 254 || sent[0].TransitionTime != 0 {
+		t.Fatalf("unexpected state %+v", sent[0])
+	}
+	if mb.lastPath != "/api/bridge_username/groups/1" || mb.lastMethod != http.MethodGet {
+		t.Fatalf("unexpected request %s %s", mb.lastMethod, mb.lastPath)
+	}
+}
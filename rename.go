@@ -0,0 +1,157 @@
+package hue
+
+import (
+	"fmt"
+	"sort"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxNameLength is the maximum length of the name of a resource, in
+// characters.
+const maxNameLength = 32
+
+// NameError is returned when a name is not accepted by the bridge.
+type NameError struct {
+	// Name is the rejected name.
+	Name string
+
+	// Reason describes why the name is rejected.
+	Reason string
+}
+
+func (e *NameError) Error() string {
+	return fmt.Sprintf("invalid name %q: %s", e.Name, e.Reason)
+}
+
+// checkName returns a *NameError if the bridge does not accept name. The
+// bridge truncates names which are too long and rejects those containing
+// control characters or invalid UTF-8.
+func checkName(name string) error {
+	if !utf8.ValidString(name) {
+		return &NameError{Name: name, Reason: "invalid UTF-8"}
+	}
+	for _, r := range name {
+		if unicode.IsControl(r) {
+			return &NameError{Name: name, Reason: fmt.Sprintf("contains control character %U", r)}
+		}
+	}
+	switch n := utf8.RuneCountInString(name); {
+	case n == 0:
+		return &NameError{Name: name, Reason: "empty"}
+	case n > maxNameLength:
+		return &NameError{Name: name, Reason: fmt.Sprintf("longer than %d characters", maxNameLength)}
+	}
+	return nil
+}
+
+// validName returns the name to send to the bridge in place of name, which is
+// truncated if b was set up to do so, or a *NameError if it is not accepted.
+func (b *Bridge) validName(name string) (string, error) {
+	if b.truncateNames && utf8.ValidString(name) && utf8.RuneCountInString(name) > maxNameLength {
+		name = string([]rune(name)[:maxNameLength])
+	}
+	if err := checkName(name); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// NameCollisionError is returned by RenameAll when lights would end up with
+// the same name.
+type NameCollisionError struct {
+	// Name is the name shared by the lights.
+	Name string
+
+	// IDs holds the IDs of the lights which would be named Name, sorted.
+	IDs []string
+}
+
+func (e *NameCollisionError) Error() string {
+	return fmt.Sprintf("lights %v would all be named %q", e.IDs, e.Name)
+}
+
+// RenameError is returned by RenameAll when renaming a light fails.
+type RenameError struct {
+	// Name is the old name of the light which failed to be renamed.
+	Name string
+
+	// Err is the error of renaming the light.
+	Err error
+
+	// Renamed holds the old names of the lights which were renamed before
+	// the failure and could not be given their old name back, sorted. It is
+	// empty if all changes were reverted.
+	Renamed []string
+}
+
+func (e *RenameError) Error() string {
+	msg := fmt.Sprintf("renaming light %q: %v", e.Name, e.Err)
+	if len(e.Renamed) > 0 {
+		msg += fmt.Sprintf(" (could not revert %q)", e.Renamed)
+	}
+	return msg
+}
+
+// RenameAll renames lights, mapping old names to new ones. All the changes
+// are checked before any request is made: each old name must match exactly
+// one light (otherwise a *LightNameError is returned), each new name must be
+// accepted by the bridge (*NameError, unless it is only too long and
+// TruncateNames is used) and no two lights may end up with the same name
+// (*NameCollisionError). The lights are then renamed in the order of their
+// old names. If renaming one fails, the lights renamed so far are given their
+// old names back and a *RenameError is returned.
+func (l *LightsService) RenameAll(names map[string]string) error {
+	all, err := l.idMap()
+	if err != nil {
+		return err
+	}
+	byName := make(map[string][]string)
+	for id, ll := range all {
+		byName[ll.Name] = append(byName[ll.Name], id)
+	}
+	olds := make([]string, 0, len(names))
+	valid := make(map[string]string, len(names))
+	for old, name := range names {
+		if ids := byName[old]; len(ids) != 1 {
+			sort.Slice(ids, func(i, j int) bool { return lessID(ids[i], ids[j]) })
+			return &LightNameError{Name: old, IDs: ids}
+		}
+		name, err := l.bridge.validName(name)
+		if err != nil {
+			return err
+		}
+		valid[old] = name
+		olds = append(olds, old)
+	}
+	names = valid
+	sort.Strings(olds)
+	// check the names which all lights would end up with
+	final := make(map[string][]string)
+	for id, ll := range all {
+		name := ll.Name
+		if n, ok := names[name]; ok {
+			name = n
+		}
+		final[name] = append(final[name], id)
+	}
+	for _, old := range olds {
+		if ids := final[names[old]]; len(ids) > 1 {
+			sort.Slice(ids, func(i, j int) bool { return lessID(ids[i], ids[j]) })
+			return &NameCollisionError{Name: names[old], IDs: ids}
+		}
+	}
+	for i, old := range olds {
+		ll := all[byName[old][0]]
+		if err := ll.Rename(names[old]); err != nil {
+			rerr := &RenameError{Name: old, Err: err}
+			for _, done := range olds[:i] {
+				if all[byName[done][0]].Rename(done) != nil {
+					rerr.Renamed = append(rerr.Renamed, done)
+				}
+			}
+			return rerr
+		}
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package hue
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// nameServer serves lights whose names can be changed, except those of the
+// lights in fail.
+type nameServer struct {
+	mu    sync.Mutex
+	names map[string]string
+	fail  map[string]bool
+	puts  int
+}
+
+func (ns *nameServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ns.mu.Lock()
+	defer ns.mu.Unlock()
+	if r.Method == http.MethodGet {
+		all := make(map[string]json.RawMessage)
+		for id, name := range ns.names {
+			all[id], _ = json.Marshal(map[string]string{"name": name})
+		}
+		json.NewEncoder(w).Encode(all)
+		return
+	}
+	ns.puts++
+	id := strings.Split(r.URL.Path, "/")[4]
+	if ns.fail[id] {
+		w.Write([]byte(`[{"error": {"type": 201, "address": "/lights/` + id + `/name", "description": "device is set to off"}}]`))
+		return
+	}
+	var body map[string]string
+	json.NewDecoder(r.Body).Decode(&body)
+	ns.names[id] = body["name"]
+	w.Write([]byte(`[{"success": {}}]`))
+}
+
+func TestRenameAll(t *testing.T) {
+	ns := &nameServer{names: map[string]string{"1": "a", "2": "b", "3": "c", "4": "c"}}
+	srv := httptest.NewServer(ns)
+	defer srv.Close()
+	b := &Bridge{bridgeID: bridgeID{IP: srv.URL + "/"}, username: "u"}
+
+	for name, tt := range map[string]struct {
+		names map[string]string
+		check func(error) bool
+	}{
+		"ambiguous": {map[string]string{"c": "x"}, func(err error) bool {
+			e, ok := err.(*LightNameError)
+			return ok && reflect.DeepEqual(e.IDs, []string{"3", "4"})
+		}},
+		"too-long": {map[string]string{"a": strings.Repeat("x", 33)}, func(err error) bool {
+			_, ok := err.(*NameError)
+			return ok
+		}},
+		"collision": {map[string]string{"a": "b"}, func(err error) bool {
+			e, ok := err.(*NameCollisionError)
+			return ok && e.Name == "b" && reflect.DeepEqual(e.IDs, []string{"1", "2"})
+		}},
+	} {
+		if err := b.Lights().RenameAll(tt.names); !tt.check(err) {
+			t.Fatalf("%s: unexpected error %v", name, err)
+		}
+	}
+	if ns.puts != 0 {
+		t.Fatalf("expected no changes, got %d", ns.puts)
+	}
+
+	// swapping names is not a collision
+	if err := b.Lights().RenameAll(map[string]string{"a": "b", "b": "a"}); err != nil {
+		t.Fatal(err)
+	}
+	if ns.names["1"] != "b" || ns.names["2"] != "a" {
+		t.Fatalf("unexpected names %v", ns.names)
+	}
+
+	// failures revert earlier changes
+	ns.fail = map[string]bool{"1": true}
+	err := b.Lights().RenameAll(map[string]string{"a": "y", "b": "z"})
+	if e, ok := err.(*RenameError); !ok || e.Name != "b" || len(e.Renamed) != 0 {
+		t.Fatalf("expected *RenameError for b, got %v", err)
+	}
+	if ns.names["1"] != "b" || ns.names["2"] != "a" {
+		t.Fatalf("expected changes to be reverted, got %v", ns.names)
+	}
+}
+
+func TestValidName(t *testing.T) {
+	long := strings.Repeat("é", 40)
+	for _, tt := range []struct {
+		name     string
+		truncate bool
+		want     string
+		ok       bool
+	}{
+		{"Kitchen", false, "Kitchen", true},
+		{"", false, "", false},
+		{"a\nb", false, "", false},
+		{"\xff", false, "", false},
+		{long, false, "", false},
+		{long, true, strings.Repeat("é", 32), true},
+		{"a\tb" + long, true, "", false},
+	} {
+		b := &Bridge{truncateNames: tt.truncate}
+		got, err := b.validName(tt.name)
+		if _, isNameErr := err.(*NameError); isNameErr == tt.ok || got != tt.want {
+			t.Fatalf("%q (truncate=%v): expected %q, got %q (%v)", tt.name, tt.truncate, tt.want, got, err)
+		}
+	}
+}
+
+func TestGroupRenameRejected(t *testing.T) {
+	mb := mockBridge(t)
+	defer mb.teardown()
+	g := &Group{bridge: mb.b, ID: "1", Name: "Office"}
+	if err := g.Rename(strings.Repeat("x", 33)); err == nil {
+		t.Fatal("expected error")
+	}
+	if mb.lastMethod != "" || g.Name != "Office" {
+		t.Fatalf("unexpected request %s %s", mb.lastMethod, mb.lastPath)
+	}
+	if err := g.Rename("Study"); err != nil {
+		t.Fatal(err)
+	}
+	if mb.lastMethod != http.MethodPut || mb.lastPath != "/api/bridge_username/groups/1" || g.Name != "Study" {
+		t.Fatalf("unexpected request %s %s", mb.lastMethod, mb.lastPath)
+	}
+}
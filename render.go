@@ -0,0 +1,96 @@
+package hue
+
+import (
+	"image/color"
+	"math"
+)
+
+// RGB returns an approximation of the color of a light in state ls, as it
+// would be displayed on a screen. Lights which are off are black.
+func (ls *LightState) RGB() color.RGBA {
+	if !ls.On {
+		return color.RGBA{A: 255}
+	}
+	v := float64(ls.Brightness) / 254
+	var r, g, b float64
+	switch ls.ColorMode {
+	case "hs":
+		r, g, b = hsvToRGB(float64(ls.Hue)/65536*360, float64(ls.Saturation)/254, 1)
+	case "ct":
+		r, g, b = kelvinToRGB(1e6 / math.Max(float64(ls.ColorTemp), 1))
+	default:
+		r, g, b = xyToRGB(ls.XY[0], ls.XY[1])
+	}
+	return color.RGBA{R: uint8(r * v * 255), G: uint8(g * v * 255), B: uint8(b * v * 255), A: 255}
+}
+
+// hsvToRGB converts a color from HSV to RGB, with components between 0 and 1.
+func hsvToRGB(h, s, v float64) (r, g, b float64) {
+	c := v * s
+	x := c * (1 - math.Abs(math.Mod(h/60, 2)-1))
+	m := v - c
+	switch {
+	case h < 60:
+		r, g, b = c, x, 0
+	case h < 120:
+		r, g, b = x, c, 0
+	case h < 180:
+		r, g, b = 0, c, x
+	case h < 240:
+		r, g, b = 0, x, c
+	case h < 300:
+		r, g, b = x, 0, c
+	default:
+		r, g, b = c, 0, x
+	}
+	return r + m, g + m, b + m
+}
+
+// xyToRGB converts a color from CIE xy coordinates to sRGB at full
+// brightness, with components between 0 and 1.
+func xyToRGB(x, y float64) (r, g, b float64) {
+	if y <= 0 {
+		return 1, 1, 1
+	}
+	X, Y, Z := x/y, 1.0, (1-x-y)/y
+	r = X*3.2406 - Y*1.5372 - Z*0.4986
+	g = -X*0.9689 + Y*1.8758 + Z*0.0415
+	b = X*0.0557 - Y*0.2040 + Z*1.0570
+	max := math.Max(r, math.Max(g, b))
+	if max <= 0 {
+		return 0, 0, 0
+	}
+	return gamma(r / max), gamma(g / max), gamma(b / max)
+}
+
+// gamma applies the sRGB transfer function to the linear component c.
+func gamma(c float64) float64 {
+	c = math.Max(0, c)
+	if c <= 0.0031308 {
+		return 12.92 * c
+	}
+	return 1.055*math.Pow(c, 1/2.4) - 0.055
+}
+
+// kelvinToRGB approximates the color of black body radiation at the given
+// temperature, with components between 0 and 1.
+func kelvinToRGB(k float64) (r, g, b float64) {
+	t := k / 100
+	if t <= 66 {
+		r = 255
+		g = 99.4708025861*math.Log(t) - 161.1195681661
+	} else {
+		r = 329.698727446 * math.Pow(t-60, -0.1332047592)
+		g = 288.1221695283 * math.Pow(t-60, -0.0755148492)
+	}
+	switch {
+	case t >= 66:
+		b = 255
+	case t <= 19:
+		b = 0
+	default:
+		b = 138.5177312231*math.Log(t-10) - 305.0447927307
+	}
+	clamp := func(v float64) float64 { return math.Max(0, math.Min(255, v)) / 255 }
+	return clamp(r), clamp(g), clamp(b)
+}
@@ -0,0 +1,88 @@
+package hue
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Codes of APIErrors which are temporary. All others are permanent: sending
+// the same request again fails the same way.
+const (
+	errLinkButtonNotPressed = 101
+	errSceneBufferBusy      = 401
+	errInternal             = 901
+)
+
+// Retryable reports whether the error is temporary, so that the request may
+// succeed when it is sent again later. This is the case when pairing before the
+// link button was pressed, while the bridge is busy creating a scene, and for
+// internal errors of the bridge, which mostly occur while it is overloaded.
+// Errors such as invalid values (code 7) or missing resources (code 3) are
+// permanent.
+func (e APIError) Retryable() bool {
+	switch e.Code {
+	case errLinkButtonNotPressed, errSceneBufferBusy, errInternal:
+		return true
+	}
+	return false
+}
+
+// BusyError is returned when the bridge responds that it is too busy to
+// handle a request, with HTTP status 429 or 503.
+type BusyError struct {
+	// URL is the URL of the request.
+	URL string
+
+	// RetryAfter is the time after which the request may be sent again, as
+	// given by the Retry-After header. It is zero if the bridge did not say.
+	RetryAfter time.Duration
+}
+
+func (e *BusyError) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("bridge busy handling %s; retry after %v", e.URL, e.RetryAfter)
+	}
+	return fmt.Sprintf("bridge busy handling %s", e.URL)
+}
+
+// busy returns a *BusyError if the response resp to the request to url tells
+// that the bridge is too busy, and nil otherwise. Retry-After headers may give
+// either a number of seconds or a date, which is relative to now.
+func busy(url string, resp *http.Response, now time.Time) error {
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable {
+		return nil
+	}
+	err := &BusyError{URL: url}
+	h := resp.Header.Get("Retry-After")
+	if secs, perr := strconv.Atoi(h); perr == nil && secs > 0 {
+		err.RetryAfter = time.Duration(secs) * time.Second
+	} else if t, perr := http.ParseTime(h); perr == nil && t.After(now) {
+		err.RetryAfter = t.Sub(now)
+	}
+	return err
+}
+
+// Retryable reports whether err, as returned by this package, is temporary,
+// so that the operation may succeed when it is attempted again later: the
+// bridge could not be reached or was busy, or it returned a retryable
+// APIError. Application-level retry loops should give up on other errors.
+func Retryable(err error) bool {
+	switch e := err.(type) {
+	case APIError:
+		return e.Retryable()
+	case *BusyError:
+		return true
+	}
+	return unreachable(err)
+}
+
+// RetryAfter returns the time to wait before retrying after err, as requested
+// by the bridge, or zero if it did not say.
+func RetryAfter(err error) time.Duration {
+	if e, ok := err.(*BusyError); ok {
+		return e.RetryAfter
+	}
+	return 0
+}
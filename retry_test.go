@@ -0,0 +1,60 @@
+package hue
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestRetryable(t *testing.T) {
+	for _, tt := range []struct {
+		err  error
+		want bool
+	}{
+		{APIError{Code: errInternal}, true},
+		{APIError{Code: errLinkButtonNotPressed}, true},
+		{APIError{Code: 7}, false},
+		{APIError{Code: errResourceNotAvailable}, false},
+		{&BusyError{}, true},
+		{&url.Error{Op: "Get", URL: "http://x", Err: http.ErrHandlerTimeout}, true},
+		{&TimeoutError{}, true},
+		{ErrUnauthorized, false},
+		{nil, false},
+	} {
+		if got := Retryable(tt.err); got != tt.want {
+			t.Fatalf("%#v: expected %v, got %v", tt.err, tt.want, got)
+		}
+	}
+}
+
+func TestBusy(t *testing.T) {
+	var header string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if header != "" {
+			w.Header().Set("Retry-After", header)
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+	b := &Bridge{bridgeID: bridgeID{IP: srv.URL + "/"}, username: "u"}
+	for _, tt := range []struct {
+		header string
+		want   time.Duration
+	}{
+		{"", 0},
+		{"3", 3 * time.Second},
+		{time.Now().Add(time.Minute).UTC().Format(http.TimeFormat), time.Minute},
+	} {
+		header = tt.header
+		_, err := b.Lights().List()
+		berr, ok := err.(*BusyError)
+		if !ok {
+			t.Fatalf("expected *BusyError, got %v", err)
+		}
+		if d := RetryAfter(err); d > tt.want || d < tt.want-2*time.Second {
+			t.Fatalf("expected to retry after %v, got %v", tt.want, berr.RetryAfter)
+		}
+	}
+}
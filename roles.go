@@ -0,0 +1,38 @@
+package hue
+
+// The interfaces below each describe a narrow capability provided by this
+// package. Applications can accept the smallest one they need, rather than a
+// whole Bridge, which documents what they do and allows tests to stub only
+// that part.
+
+// LightController switches and sets the state of lights. It is implemented by
+// *Light and *Group.
+type LightController interface {
+	// On turns the lights on.
+	On() error
+
+	// Off turns the lights off.
+	Off() error
+
+	// Set sets the new state of the lights.
+	Set(s *State) error
+}
+
+// SceneRecaller recalls scenes by ID. It is implemented by *Group.
+type SceneRecaller interface {
+	// RecallScene applies the scene with the given ID.
+	RecallScene(id string) error
+}
+
+// SensorReader reads the sensors known to a bridge. It is implemented by
+// *SensorsService.
+type SensorReader interface {
+	// List returns all sensors.
+	List() ([]*Sensor, error)
+
+	// GetByID returns a sensor by ID.
+	GetByID(id string) (*Sensor, error)
+
+	// Get returns a sensor by name.
+	Get(name string) (*Sensor, error)
+}
@@ -0,0 +1,8 @@
+package hue
+
+var (
+	_ LightController = (*Light)(nil)
+	_ LightController = (*Group)(nil)
+	_ SceneRecaller   = (*Group)(nil)
+	_ SensorReader    = (*SensorsService)(nil)
+)
@@ -0,0 +1,93 @@
+package hue
+
+import "strings"
+
+// Room gives access to a room: its group of lights, the scenes of the group
+// and its motion sensor. These are looked up once, when the Room is obtained
+// using Bridge.Room, so that application code can act on a room directly.
+type Room struct {
+	// Group is the group of the room.
+	Group *Group
+
+	// scenes holds the scenes of the group.
+	scenes []*Scene
+
+	// motion is the motion sensor of the room, or nil if none was found.
+	motion *MotionSensor
+}
+
+// Room returns the room with the given name. Groups of type Room are
+// preferred over other groups with the same name. The scenes of the room are
+// those of type GroupScene which belong to its group. Since the bridge does
+// not associate sensors with rooms, the motion sensor of the room is the
+// first one whose name starts with the name of the room (e.g. "Kitchen
+// sensor"), ignoring case.
+func (b *Bridge) Room(name string) (*Room, error) {
+	groups, err := b.Groups().List()
+	if err != nil {
+		return nil, err
+	}
+	r := new(Room)
+	for _, g := range groups {
+		if g.Name == name && (r.Group == nil || g.Type == TypeRoom) {
+			r.Group = g
+		}
+	}
+	if r.Group == nil {
+		return nil, ErrGroupNotExist
+	}
+	scenes, err := b.Scenes().List()
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range scenes {
+		if s.Type == GroupScene && s.Group == r.Group.ID {
+			r.scenes = append(r.scenes, s)
+		}
+	}
+	sensors, err := b.Sensors().MotionSensors()
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range sensors {
+		if strings.HasPrefix(strings.ToLower(m.Name()), strings.ToLower(name)) {
+			r.motion = m
+			break
+		}
+	}
+	return r, nil
+}
+
+// On turns all the lights in the room on.
+func (r *Room) On() error { return r.Group.On() }
+
+// Off turns all the lights in the room off.
+func (r *Room) Off() error { return r.Group.Off() }
+
+// Brightness turns all the lights in the room on, at the given brightness.
+func (r *Room) Brightness(bri uint8) error {
+	return r.Group.Set(&State{On: true, Brightness: bri})
+}
+
+// Scenes returns the scenes of the room.
+func (r *Room) Scenes() []*Scene { return r.scenes }
+
+// Scene recalls the scene of the room with the given name. If there is no such
+// scene, ErrSceneNotExist is returned.
+func (r *Room) Scene(name string) error {
+	for _, s := range r.scenes {
+		if s.Name == name {
+			return r.Group.RecallScene(s.ID)
+		}
+	}
+	return ErrSceneNotExist
+}
+
+// MotionSensor returns the motion sensor of the room. If there is none,
+// ErrSensorNotExist is returned.
+func (r *Room) MotionSensor() (*MotionSensor, error) {
+	if r.motion == nil {
+		return nil, ErrSensorNotExist
+	}
+	return r.motion, nil
+}
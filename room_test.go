@@ -0,0 +1,48 @@
+package hue
+
+import "testing"
+
+func TestRoom(t *testing.T) {
+	mb := mockBridge(t)
+	defer mb.teardown()
+	mb.responses = map[string]interface{}{
+		"/api/bridge_username/groups": map[string]*Group{
+			"1": &Group{Name: "Kitchen", Type: TypeLightGroup},
+			"2": &Group{Name: "Kitchen", Type: TypeRoom, Lights: []string{"1"}},
+			"3": &Group{Name: "Hall", Type: TypeRoom},
+		},
+		"/api/bridge_username/scenes": map[string]*Scene{
+			"ab12": &Scene{Name: "Relax", Type: GroupScene, Group: "2"},
+			"cd34": &Scene{Name: "Relax", Type: GroupScene, Group: "3"},
+		},
+		"/api/bridge_username/sensors": map[string]*Sensor{
+			"4": &Sensor{UID: "00:17:88:01:02:00:af:28-02-0406", Type: "ZLLPresence", Name: "Hall sensor"},
+			"7": &Sensor{UID: "00:17:88:01:02:00:bb:11-02-0406", Type: "ZLLPresence", Name: "kitchen sensor"},
+		},
+	}
+	r, err := mb.b.Room("Kitchen")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Group.ID != "2" || len(r.Scenes()) != 1 || r.Scenes()[0].ID != "ab12" {
+		t.Fatalf("unexpected room %+v", r)
+	}
+	m, err := r.MotionSensor()
+	if err != nil || m.Presence.ID != "7" {
+		t.Fatalf("unexpected motion sensor %v (%v)", m, err)
+	}
+
+	mb.nextResponse = []interface{}{}
+	if err := r.Scene("Relax"); err != nil {
+		t.Fatal(err)
+	}
+	if mb.lastPath != "/api/bridge_username/groups/2" {
+		t.Fatalf("unexpected path %s", mb.lastPath)
+	}
+	if err := r.Scene("Energize"); err != ErrSceneNotExist {
+		t.Fatalf("expected ErrSceneNotExist, got %v", err)
+	}
+	if _, err := mb.b.Room("Attic"); err != ErrGroupNotExist {
+		t.Fatalf("expected ErrGroupNotExist, got %v", err)
+	}
+}
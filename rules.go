@@ -0,0 +1,136 @@
+package hue
+
+import (
+	"errors"
+	"net/http"
+)
+
+// ErrRuleNotExist is returned when a rule was not found.
+var ErrRuleNotExist = errors.New("rule does not exist")
+
+// Rules returns the service to interact with the rules on this bridge.
+func (b *Bridge) Rules() *RulesService { return &RulesService{bridge: b} }
+
+// RulesService is the service that allows interacting with the rules API of
+// the bridge.
+type RulesService struct{ bridge *Bridge }
+
+// List returns a slice of all rules on the bridge.
+func (r *RulesService) List() ([]*Rule, error) {
+	all, err := r.idMap()
+	if err != nil {
+		return nil, err
+	}
+	list := make([]*Rule, 0, len(all))
+	for _, rr := range all {
+		list = append(list, rr)
+	}
+	return list, nil
+}
+
+// GetByID returns a rule by id.
+func (r *RulesService) GetByID(id string) (*Rule, error) {
+	list, err := r.idMap()
+	if err != nil {
+		return nil, err
+	}
+	v, ok := list[id]
+	if !ok {
+		return nil, ErrRuleNotExist
+	}
+	return v, nil
+}
+
+// Create creates the given rule on the bridge. On success, the ID of the rule
+// is updated.
+func (r *RulesService) Create(rule *Rule) error {
+	msg, err := r.bridge.call(http.MethodPost, rule, "rules")
+	if err != nil {
+		return err
+	}
+	id, err := createdID(msg)
+	if err != nil {
+		return err
+	}
+	rule.bridge = r.bridge
+	rule.ID = id
+	return nil
+}
+
+// Delete deletes the rule. If cascade is true, it is also removed from the
+// resource links which include it.
+func (r *Rule) Delete(cascade bool) error {
+	return r.bridge.deleteResource(cascade, "rules", r.ID)
+}
+
+func (r *RulesService) idMap() (map[string]*Rule, error) {
+	msg, err := r.bridge.call(http.MethodGet, nil, "rules")
+	if err != nil {
+		return nil, err
+	}
+	var all map[string]*Rule
+	err = r.bridge.decode(msg, &all)
+	for id, rr := range all {
+		rr.bridge = r.bridge
+		rr.ID = id
+	}
+	return all, err
+}
+
+// Rule holds information about a rule, which executes actions when all of its
+// conditions are met. For more information see:
+// http://www.developers.meethue.com/documentation/rules-api
+type Rule struct {
+	bridge *Bridge
+
+	// ID is the ID that the bridge returns for this rule.
+	ID string `json:"-"`
+
+	// Name is the name of the rule.
+	Name string `json:"name"`
+
+	// Conditions must all be met for the rule to be triggered.
+	Conditions []Condition `json:"conditions"`
+
+	// Actions are executed when the rule is triggered.
+	Actions []Command `json:"actions"`
+
+	// Status is either "enabled" or "disabled".
+	Status string `json:"status,omitempty"`
+
+	// Owner is the username of the application which created the rule. It
+	// is set by the bridge.
+	Owner string `json:"owner,omitempty"`
+
+	// Recycle, when true, allows the bridge to delete the rule once the
+	// resources it refers to are deleted, which suits temporary rules
+	// created by automations.
+	Recycle bool `json:"recycle,omitempty"`
+}
+
+// Condition operators.
+const (
+	OpEq        = "eq"
+	OpGt        = "gt"
+	OpLt        = "lt"
+	OpDx        = "dx"
+	OpDdx       = "ddx"
+	OpStable    = "stable"
+	OpNotStable = "not stable"
+	OpIn        = "in"
+	OpNotIn     = "not in"
+)
+
+// Condition holds a condition of a rule.
+type Condition struct {
+	// Address is the path of the attribute of a resource, for example
+	// "/sensors/2/state/presence".
+	Address string `json:"address"`
+
+	// Operator is the operator of the condition, such as "eq" or "dx".
+	Operator string `json:"operator"`
+
+	// Value is the value to compare the attribute with. It is not used by
+	// all operators.
+	Value string `json:"value,omitempty"`
+}
@@ -0,0 +1,75 @@
+package hue
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestRulesService(t *testing.T) {
+	mb := mockBridge(t)
+	defer mb.teardown()
+
+	t.Run("List", func(t *testing.T) {
+		mb.nextResponse = map[string]*Rule{"1": &Rule{Name: "r1"}}
+		list, err := mb.b.Rules().List()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(list) != 1 || list[0].ID != "1" || list[0].bridge != mb.b {
+			t.Fatalf("unexpected list %v", list)
+		}
+		if _, err := mb.b.Rules().GetByID("2"); err != ErrRuleNotExist {
+			t.Fatalf("expected ErrRuleNotExist, got %v", err)
+		}
+	})
+
+	t.Run("Recycle", func(t *testing.T) {
+		mb.nextResponse = json.RawMessage(`{"1": {"name": "r1", "recycle": true}}`)
+		list, err := mb.b.Rules().List()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(list) != 1 || !list[0].Recycle {
+			t.Fatalf("expected a recyclable rule, got %v", list)
+		}
+	})
+
+	t.Run("Create", func(t *testing.T) {
+		mb.nextResponse = []interface{}{
+			map[string]interface{}{"success": map[string]string{"id": "4"}},
+		}
+		r := &Rule{
+			Name:       "r",
+			Conditions: []Condition{{Address: "/sensors/2/state/presence", Operator: OpDx}},
+			Actions:    []Command{{Address: "/groups/0/action", Method: http.MethodPut, Body: map[string]bool{"on": true}}},
+		}
+		if err := mb.b.Rules().Create(r); err != nil {
+			t.Fatal(err)
+		}
+		if r.ID != "4" || mb.lastMethod != http.MethodPost || mb.lastPath != "/api/bridge_username/rules" {
+			t.Fatalf("unexpected result %v after %s %s", r, mb.lastMethod, mb.lastPath)
+		}
+		var got map[string]interface{}
+		if err := json.NewDecoder(mb.lastBody).Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+		want := map[string]interface{}{
+			"name": "r",
+			"conditions": []interface{}{
+				map[string]interface{}{"address": "/sensors/2/state/presence", "operator": "dx"},
+			},
+			"actions": []interface{}{
+				map[string]interface{}{
+					"address": "/groups/0/action",
+					"method":  "PUT",
+					"body":    map[string]interface{}{"on": true},
+				},
+			},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("expected %v, got %v", want, got)
+		}
+	})
+}
@@ -0,0 +1,211 @@
+package hue
+
+import (
+	"math"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// SceneLightState holds the state of a light in a scene. Fields which the
+// scene does not set are nil.
+type SceneLightState struct {
+	// On is the on state of the light.
+	On bool `json:"on"`
+
+	// Brightness is the brightness of the light.
+	Brightness *uint8 `json:"bri,omitempty"`
+
+	// XY holds the x and y coordinates of the color of the light.
+	XY *XY `json:"xy,omitempty"`
+
+	// ColorTemp is the Mired color temperature of the light.
+	ColorTemp *Mired `json:"ct,omitempty"`
+
+	// Hue is the hue of the light.
+	Hue *uint16 `json:"hue,omitempty"`
+
+	// Saturation is the saturation of the light.
+	Saturation *uint8 `json:"sat,omitempty"`
+}
+
+// LightDiff describes how the current state of a light deviates from its
+// state in a scene.
+type LightDiff struct {
+	// Light is the ID of the light.
+	Light string
+
+	// Scene is the state of the light in the scene.
+	Scene SceneLightState
+
+	// Current is the current state of the light.
+	Current LightState
+
+	// Fields holds the JSON names of the fields which differ (e.g. "on",
+	// "bri", "xy"). It holds "reachable" if the light is unreachable.
+	Fields []string
+}
+
+// LightStates returns the state of each light in the scene, keyed by the ID of
+// the light. The bridge only reports them when a single scene is retrieved.
+func (s *Scene) LightStates() (map[string]SceneLightState, error) {
+	msg, err := s.bridge.call(http.MethodGet, nil, "scenes", s.ID)
+	if err != nil {
+		return nil, err
+	}
+	var sc struct {
+		LightStates map[string]SceneLightState `json:"lightstates"`
+	}
+	if err := s.bridge.decode(msg, &sc); err != nil {
+		return nil, err
+	}
+	return sc.LightStates, nil
+}
+
+// Tolerance holds the largest differences between the state of a light and
+// its state in a scene, for which the light is considered to match the scene.
+// The zero value requires an exact match.
+type Tolerance struct {
+	// Brightness is the largest difference in brightness.
+	Brightness uint8
+
+	// XY is the largest distance between colors in CIE color space.
+	XY float64
+
+	// ColorTemp is the largest difference in color temperature.
+	ColorTemp Mired
+
+	// Hue is the largest difference in hue, which wraps around.
+	Hue uint16
+
+	// Saturation is the largest difference in saturation.
+	Saturation uint8
+}
+
+// Diff compares the current state of the lights in the scene with the scene
+// and returns the lights which deviate from it, sorted by ID. If it returns
+// no lights, the scene is effectively active. Lights which are off in the
+// scene only need to be off.
+func (s *Scene) Diff() ([]LightDiff, error) { return s.DiffWithin(Tolerance{}) }
+
+// DiffWithin is like Diff, but ignores differences within the given tolerance.
+func (s *Scene) DiffWithin(tol Tolerance) ([]LightDiff, error) {
+	states, err := s.LightStates()
+	if err != nil {
+		return nil, err
+	}
+	lights, err := s.bridge.Lights().idMap()
+	if err != nil {
+		return nil, err
+	}
+	return sceneDiff(states, lights, tol), nil
+}
+
+// sceneDiff returns the lights which deviate from the scene states by more
+// than tol, sorted by ID.
+func sceneDiff(states map[string]SceneLightState, lights map[string]*Light, tol Tolerance) []LightDiff {
+	var diffs []LightDiff
+	for id, st := range states {
+		l, ok := lights[id]
+		if !ok {
+			continue
+		}
+		if fields := diffFields(&st, &l.State, tol); len(fields) > 0 {
+			diffs = append(diffs, LightDiff{Light: id, Scene: st, Current: l.State, Fields: fields})
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool { return lessID(diffs[i].Light, diffs[j].Light) })
+	return diffs
+}
+
+// diffFields returns the JSON names of the fields of cur which differ from
+// the scene state st by more than tol.
+func diffFields(st *SceneLightState, cur *LightState, tol Tolerance) []string {
+	if !cur.Reachable {
+		return []string{"reachable"}
+	}
+	if st.On != cur.On {
+		return []string{"on"}
+	}
+	if !st.On {
+		return nil
+	}
+	var fields []string
+	if st.Brightness != nil && absDiff(float64(*st.Brightness), float64(cur.Brightness)) > float64(tol.Brightness) {
+		fields = append(fields, "bri")
+	}
+	if st.XY != nil && st.XY.Distance(cur.XY) > tol.XY {
+		fields = append(fields, "xy")
+	}
+	if st.ColorTemp != nil && absDiff(float64(*st.ColorTemp), float64(cur.ColorTemp)) > float64(tol.ColorTemp) {
+		fields = append(fields, "ct")
+	}
+	if st.Hue != nil && *st.Hue-cur.Hue > tol.Hue && cur.Hue-*st.Hue > tol.Hue {
+		fields = append(fields, "hue")
+	}
+	if st.Saturation != nil && absDiff(float64(*st.Saturation), float64(cur.Saturation)) > float64(tol.Saturation) {
+		fields = append(fields, "sat")
+	}
+	return fields
+}
+
+// absDiff returns the absolute difference between a and b.
+func absDiff(a, b float64) float64 { return math.Abs(a - b) }
+
+// ActiveScene infers which of the scenes of the group is active, by comparing
+// the current state of its lights with each scene, within the given
+// tolerance. The scenes of the group are those of type GroupScene which
+// belong to it, and light scenes with exactly the lights of the group. If
+// several scenes match, the most recently updated one is returned. If none
+// does, ActiveScene returns nil.
+func (g *Group) ActiveScene(tol Tolerance) (*Scene, error) {
+	scenes, err := g.bridge.Scenes().List()
+	if err != nil {
+		return nil, err
+	}
+	lights, err := g.bridge.Lights().idMap()
+	if err != nil {
+		return nil, err
+	}
+	var active *Scene
+	for _, sc := range scenes {
+		if !g.hasScene(sc) || (active != nil && !sc.updated().After(active.updated())) {
+			continue
+		}
+		states, err := sc.LightStates()
+		if err != nil {
+			return nil, err
+		}
+		if len(sceneDiff(states, lights, tol)) == 0 {
+			active = sc
+		}
+	}
+	return active, nil
+}
+
+// updated returns the time at which the scene was last changed. Scenes which
+// report "none", or a time which can not be parsed, are treated as never
+// changed and get the zero time.
+func (sc *Scene) updated() time.Time {
+	t, err := time.ParseInLocation(timeLayout, sc.LastUpdated, time.UTC)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// hasScene reports whether sc is a scene of the group.
+func (g *Group) hasScene(sc *Scene) bool {
+	if sc.Type == GroupScene {
+		return sc.Group == g.ID
+	}
+	if len(sc.Lights) != len(g.Lights) {
+		return false
+	}
+	for _, id := range sc.Lights {
+		if !contains(g.Lights, id) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,81 @@
+package hue
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestSceneDiff(t *testing.T) {
+	mb := mockBridge(t)
+	defer mb.teardown()
+	mb.responses = map[string]interface{}{
+		"/api/bridge_username/scenes/ab12": json.RawMessage(`{"name": "Relax", "lights": ["1", "2", "3", "4"],
+			"lightstates": {
+				"1": {"on": true, "bri": 144, "ct": 447},
+				"2": {"on": true, "bri": 144, "xy": [0.5, 0.4]},
+				"3": {"on": false},
+				"4": {"on": true, "bri": 144}}}`),
+		"/api/bridge_username/lights": json.RawMessage(`{
+			"1": {"state": {"on": true, "bri": 144, "ct": 447, "reachable": true}},
+			"2": {"state": {"on": true, "bri": 100, "xy": [0.3, 0.3], "reachable": true}},
+			"3": {"state": {"on": true, "bri": 10, "reachable": true}},
+			"4": {"state": {"on": false, "reachable": false}}}`),
+	}
+	sc := &Scene{bridge: mb.b, ID: "ab12"}
+	diffs, err := sc.Diff()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got [][]string
+	for _, d := range diffs {
+		got = append(got, append([]string{d.Light}, d.Fields...))
+	}
+	want := [][]string{{"2", "bri", "xy"}, {"3", "on"}, {"4", "reachable"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestActiveScene(t *testing.T) {
+	mb := mockBridge(t)
+	defer mb.teardown()
+	mb.responses = map[string]interface{}{
+		"/api/bridge_username/scenes": json.RawMessage(`{
+			"ab12": {"name": "Relax", "type": "GroupScene", "group": "1", "lastupdated": "2018-01-01T00:00:00"},
+			"cd34": {"name": "Read", "type": "GroupScene", "group": "1", "lastupdated": "2018-01-02T00:00:00"},
+			"ef56": {"name": "Other", "type": "GroupScene", "group": "2", "lastupdated": "2018-01-03T00:00:00"}}`),
+		"/api/bridge_username/scenes/ab12": json.RawMessage(`{"lightstates": {"1": {"on": true, "bri": 144, "ct": 447}}}`),
+		"/api/bridge_username/scenes/cd34": json.RawMessage(`{"lightstates": {"1": {"on": true, "bri": 254, "ct": 346}}}`),
+		"/api/bridge_username/scenes/ef56": json.RawMessage(`{"lightstates": {"1": {"on": true, "bri": 140, "ct": 447}}}`),
+		"/api/bridge_username/lights": json.RawMessage(`{
+			"1": {"state": {"on": true, "bri": 140, "ct": 447, "reachable": true}}}`),
+	}
+	g := &Group{bridge: mb.b, ID: "1", Lights: []string{"1"}}
+	sc, err := g.ActiveScene(Tolerance{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sc != nil {
+		t.Fatalf("expected no active scene, got %s", sc.Name)
+	}
+	sc, err = g.ActiveScene(Tolerance{Brightness: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sc == nil || sc.Name != "Relax" {
+		t.Fatalf("expected Relax, got %v", sc)
+	}
+	// a scene which was never updated is older than any other
+	mb.responses["/api/bridge_username/scenes"] = json.RawMessage(`{
+		"ab12": {"name": "Relax", "type": "GroupScene", "group": "1", "lastupdated": "2018-01-01T00:00:00"},
+		"zz99": {"name": "Default", "type": "GroupScene", "group": "1", "lastupdated": "none"}}`)
+	mb.responses["/api/bridge_username/scenes/zz99"] = json.RawMessage(`{"lightstates": {"1": {"on": true, "bri": 140, "ct": 447}}}`)
+	sc, err = g.ActiveScene(Tolerance{Brightness: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sc == nil || sc.Name != "Relax" {
+		t.Fatalf("expected Relax, got %v", sc)
+	}
+}
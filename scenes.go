@@ -0,0 +1,215 @@
+package hue
+
+import (
+	"errors"
+	"net/http"
+)
+
+// ErrSceneNotExist is returned when a scene was not found.
+var ErrSceneNotExist = errors.New("scene does not exist")
+
+// Scene types, as reported by the bridge.
+const (
+	// LightScene is a scene which is not tied to a group.
+	LightScene = "LightScene"
+
+	// GroupScene is a scene whose lights are those of a group.
+	GroupScene = "GroupScene"
+)
+
+// Scenes returns the service to interact with the scenes on this bridge.
+func (b *Bridge) Scenes() *ScenesService { return &ScenesService{bridge: b} }
+
+// ScenesService is the service that allows interacting with the scenes API of
+// the bridge.
+type ScenesService struct{ bridge *Bridge }
+
+// List returns a slice of all scenes on the bridge.
+func (s *ScenesService) List() ([]*Scene, error) {
+	all, err := s.idMap()
+	if err != nil {
+		return nil, err
+	}
+	list := make([]*Scene, 0, len(all))
+	for _, sc := range all {
+		list = append(list, sc)
+	}
+	return list, nil
+}
+
+// GetByID returns a scene by id.
+func (s *ScenesService) GetByID(id string) (*Scene, error) {
+	list, err := s.idMap()
+	if err != nil {
+		return nil, err
+	}
+	v, ok := list[id]
+	if !ok {
+		return nil, ErrSceneNotExist
+	}
+	return v, nil
+}
+
+// Get returns a scene by name. Note that scene names are not unique; the
+// first match is returned.
+func (s *ScenesService) Get(name string) (*Scene, error) {
+	list, err := s.idMap()
+	if err != nil {
+		return nil, err
+	}
+	for _, sc := range list {
+		if sc.Name == name {
+			return sc, nil
+		}
+	}
+	return nil, ErrSceneNotExist
+}
+
+// Create creates the given scene on the bridge, storing the current state of
+// its lights. Only the Name, Type, Group, Lights, Recycle, AppData and Picture
+// fields are used; for scenes of type GroupScene, Lights is ignored. On
+// success, the ID of the scene is updated. If the bridge would not accept the
+// name, a *NameError is returned.
+func (s *ScenesService) Create(sc *Scene) error {
+	name, err := s.bridge.validName(sc.Name)
+	if err != nil {
+		return err
+	}
+	sc.Name = name
+	payload := map[string]interface{}{
+		"name":    sc.Name,
+		"recycle": sc.Recycle,
+	}
+	if sc.Type == GroupScene {
+		if err := s.bridge.supports(featureGroupScenes); err != nil {
+			return err
+		}
+		payload["type"] = GroupScene
+		payload["group"] = sc.Group
+	} else {
+		payload["lights"] = sc.Lights
+	}
+	if sc.AppData != (AppData{}) {
+		payload["appdata"] = sc.AppData
+	}
+	if sc.Picture != "" {
+		payload["picture"] = sc.Picture
+	}
+	msg, err := s.bridge.call(http.MethodPost, payload, "scenes")
+	if err != nil {
+		return err
+	}
+	id, err := createdID(msg)
+	if err != nil {
+		return err
+	}
+	sc.bridge = s.bridge
+	sc.ID = id
+	return nil
+}
+
+func (s *ScenesService) idMap() (map[string]*Scene, error) {
+	msg, err := s.bridge.call(http.MethodGet, nil, "scenes")
+	if err != nil {
+		return nil, err
+	}
+	var all map[string]*Scene
+	err = s.bridge.decode(msg, &all)
+	for id, sc := range all {
+		sc.bridge = s.bridge
+		sc.ID = id
+	}
+	return all, err
+}
+
+// Scene holds information about a scene: a stored state of a set of lights.
+// For more information see:
+// http://www.developers.meethue.com/documentation/scenes-api
+type Scene struct {
+	bridge *Bridge
+
+	// ID is the ID that the bridge returns for this scene.
+	ID string `json:"-"`
+
+	// Name is the human readable name of the scene.
+	Name string `json:"name"`
+
+	// Type is the type of the scene, LightScene or GroupScene. It is empty
+	// for scenes created before firmware 1.28, which are light scenes.
+	Type string `json:"type,omitempty"`
+
+	// Group is the ID of the group of a GroupScene.
+	Group string `json:"group,omitempty"`
+
+	// Lights holds the IDs of the lights in the scene.
+	Lights []string `json:"lights"`
+
+	// Owner is the username of the application which created the scene.
+	Owner string `json:"owner,omitempty"`
+
+	// Recycle, when true, allows the bridge to delete the scene when it runs
+	// out of space for new ones.
+	Recycle bool `json:"recycle"`
+
+	// Locked is true when the scene is used by a rule or schedule and thus can
+	// not be deleted.
+	Locked bool `json:"locked"`
+
+	// AppData holds data that applications may store with the scene.
+	AppData AppData `json:"appdata"`
+
+	// Picture is reserved for use by the official app.
+	Picture string `json:"picture,omitempty"`
+
+	// LastUpdated is the UTC time at which the scene was last changed, or
+	// "none".
+	LastUpdated string `json:"lastupdated,omitempty"`
+
+	// Version is the version of the scene format.
+	Version int `json:"version,omitempty"`
+}
+
+// AppData holds data stored by applications with a scene. The official app
+// stores the ID of the room that a scene belongs to in Data (e.g.
+// "a1b2c_r01_d01" for room 1), so setting it in the same format makes scenes
+// created by this package show up in the right room.
+type AppData struct {
+	// Version is the version of the format of Data.
+	Version int `json:"version,omitempty"`
+
+	// Data is free-form data of up to 16 characters.
+	Data string `json:"data,omitempty"`
+}
+
+// Rename changes the name of the scene. If the bridge would not accept the
+// name, a *NameError is returned.
+func (s *Scene) Rename(name string) error {
+	name, err := s.bridge.validName(name)
+	if err != nil {
+		return err
+	}
+	_, err = s.bridge.call(http.MethodPut, map[string]string{
+		"name": name,
+	}, "scenes", s.ID)
+	if err == nil {
+		s.Name = name
+	}
+	return err
+}
+
+// SetAppData changes the application data of the scene.
+func (s *Scene) SetAppData(d AppData) error {
+	_, err := s.bridge.call(http.MethodPut, map[string]AppData{
+		"appdata": d,
+	}, "scenes", s.ID)
+	if err == nil {
+		s.AppData = d
+	}
+	return err
+}
+
+// Delete deletes the scene. If cascade is true, the rules and schedules which
+// refer to it are deleted as well and it is removed from resource links.
+func (s *Scene) Delete(cascade bool) error {
+	return s.bridge.deleteResource(cascade, "scenes", s.ID)
+}
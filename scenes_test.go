@@ -0,0 +1,83 @@
+package hue
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestScenesService(t *testing.T) {
+	mb := mockBridge(t)
+	defer mb.teardown()
+	mb.b.warnings = new(warnings)
+
+	t.Run("List", func(t *testing.T) {
+		mb.nextResponse = json.RawMessage(`{"ab12": {
+			"name": "Relax", "type": "GroupScene", "group": "1", "lights": ["1", "2"],
+			"owner": "user", "recycle": false, "locked": true,
+			"appdata": {"version": 1, "data": "x2jx1_r01_d02"},
+			"picture": "", "lastupdated": "2018-01-01T00:00:00", "version": 2}}`)
+		list, err := mb.b.Scenes().List()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(list) != 1 || list[0].ID != "ab12" || list[0].bridge != mb.b {
+			t.Fatalf("unexpected list %v", list)
+		}
+		sc := list[0]
+		if !sc.Locked || sc.Owner != "user" || sc.AppData != (AppData{Version: 1, Data: "x2jx1_r01_d02"}) {
+			t.Fatalf("unexpected scene %+v", sc)
+		}
+		if len(mb.b.Warnings()) != 0 {
+			t.Fatalf("unexpected warnings %v", mb.b.Warnings())
+		}
+		if _, err := mb.b.Scenes().Get("Energize"); err != ErrSceneNotExist {
+			t.Fatalf("expected ErrSceneNotExist, got %v", err)
+		}
+	})
+
+	t.Run("Create", func(t *testing.T) {
+		mb.nextResponse = []interface{}{
+			map[string]interface{}{"success": map[string]string{"id": "cd34"}},
+		}
+		sc := &Scene{
+			Name:    "Read",
+			Type:    GroupScene,
+			Group:   "1",
+			AppData: AppData{Version: 1, Data: "abcde_r01_d01"},
+		}
+		if err := mb.b.Scenes().Create(sc); err != nil {
+			t.Fatal(err)
+		}
+		if sc.ID != "cd34" || mb.lastMethod != http.MethodPost || mb.lastPath != "/api/bridge_username/scenes" {
+			t.Fatalf("unexpected result %v after %s %s", sc, mb.lastMethod, mb.lastPath)
+		}
+		var got map[string]interface{}
+		if err := json.NewDecoder(mb.lastBody).Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+		want := map[string]interface{}{
+			"name":    "Read",
+			"type":    "GroupScene",
+			"group":   "1",
+			"recycle": false,
+			"appdata": map[string]interface{}{"version": 1.0, "data": "abcde_r01_d01"},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("expected %v, got %v", want, got)
+		}
+	})
+
+	t.Run("SetAppData", func(t *testing.T) {
+		mb.nextResponse = []interface{}{}
+		sc := &Scene{bridge: mb.b, ID: "cd34"}
+		d := AppData{Version: 1, Data: "abcde_r02_d01"}
+		if err := sc.SetAppData(d); err != nil {
+			t.Fatal(err)
+		}
+		if sc.AppData != d || mb.lastMethod != http.MethodPut || mb.lastPath != "/api/bridge_username/scenes/cd34" {
+			t.Fatalf("unexpected result %v after %s %s", sc, mb.lastMethod, mb.lastPath)
+		}
+	})
+}
@@ -0,0 +1,176 @@
+package hue
+
+import (
+	"errors"
+	"net/http"
+	"time"
+)
+
+// ErrScheduleNotExist is returned when a schedule was not found.
+var ErrScheduleNotExist = errors.New("schedule does not exist")
+
+// timeLayout is the layout of time values used by the bridge.
+const timeLayout = "2006-01-02T15:04:05"
+
+// Schedules returns the service to interact with the schedules on this bridge.
+func (b *Bridge) Schedules() *SchedulesService { return &SchedulesService{bridge: b} }
+
+// SchedulesService is the service that allows interacting with the schedules
+// API of the bridge.
+type SchedulesService struct{ bridge *Bridge }
+
+// List returns a slice of all schedules on the bridge.
+func (s *SchedulesService) List() ([]*Schedule, error) {
+	all, err := s.idMap()
+	if err != nil {
+		return nil, err
+	}
+	list := make([]*Schedule, 0, len(all))
+	for _, ss := range all {
+		list = append(list, ss)
+	}
+	return list, nil
+}
+
+// GetByID returns a schedule by id.
+func (s *SchedulesService) GetByID(id string) (*Schedule, error) {
+	list, err := s.idMap()
+	if err != nil {
+		return nil, err
+	}
+	v, ok := list[id]
+	if !ok {
+		return nil, ErrScheduleNotExist
+	}
+	return v, nil
+}
+
+// Create creates the given schedule on the bridge. On success, the ID of the
+// schedule is updated. If the schedule has no description, it is set to
+// OwnerTag and the username of the bridge, marking it as created by this
+// package with these credentials. If the schedule has a name which the
+// bridge would not accept, a *NameError is returned.
+func (s *SchedulesService) Create(sc *Schedule) error {
+	if sc.Name != "" {
+		name, err := s.bridge.validName(sc.Name)
+		if err != nil {
+			return err
+		}
+		sc.Name = name
+	}
+	if sc.Description == "" {
+		sc.Description = s.bridge.ownerTag()
+	}
+	msg, err := s.bridge.call(http.MethodPost, sc, "schedules")
+	if err != nil {
+		return err
+	}
+	id, err := createdID(msg)
+	if err != nil {
+		return err
+	}
+	sc.bridge = s.bridge
+	sc.ID = id
+	return nil
+}
+
+// Rename changes the name of the schedule. If the bridge would not accept the
+// name, a *NameError is returned.
+func (s *Schedule) Rename(name string) error {
+	name, err := s.bridge.validName(name)
+	if err != nil {
+		return err
+	}
+	_, err = s.bridge.call(http.MethodPut, map[string]string{
+		"name": name,
+	}, "schedules", s.ID)
+	if err == nil {
+		s.Name = name
+	}
+	return err
+}
+
+// Delete deletes the schedule. If cascade is true, the rules which refer to it
+// are deleted as well and it is removed from resource links.
+func (s *Schedule) Delete(cascade bool) error {
+	return s.bridge.deleteResource(cascade, "schedules", s.ID)
+}
+
+// RecallSceneAt creates a schedule with the given name which recalls the scene
+// with the given ID on group g at time t. The time is sent to the bridge in
+// the location of t, which should match the timezone of the bridge: use
+// t.In with the location returned by Bridge.Location to convert it. The
+// schedule is deleted by the bridge once it has run.
+func (s *SchedulesService) RecallSceneAt(name string, g *Group, sceneID string, t time.Time) (*Schedule, error) {
+	sc := &Schedule{
+		Name: name,
+		Command: Command{
+			Address: s.bridge.path("groups", g.ID, "action"),
+			Method:  http.MethodPut,
+			Body:    map[string]string{"scene": sceneID},
+		},
+		LocalTime:  t.Format(timeLayout),
+		AutoDelete: true,
+	}
+	if err := s.Create(sc); err != nil {
+		return nil, err
+	}
+	return sc, nil
+}
+
+func (s *SchedulesService) idMap() (map[string]*Schedule, error) {
+	msg, err := s.bridge.call(http.MethodGet, nil, "schedules")
+	if err != nil {
+		return nil, err
+	}
+	var all map[string]*Schedule
+	err = s.bridge.decode(msg, &all)
+	for id, ss := range all {
+		ss.bridge = s.bridge
+		ss.ID = id
+	}
+	return all, err
+}
+
+// Schedule holds information about a schedule. For more information see:
+// http://www.developers.meethue.com/documentation/schedules-api-0
+type Schedule struct {
+	bridge *Bridge
+
+	// ID is the ID that the bridge returns for this schedule.
+	ID string `json:"-"`
+
+	// Name is the name of the schedule.
+	Name string `json:"name,omitempty"`
+
+	// Description is the description of the schedule.
+	Description string `json:"description,omitempty"`
+
+	// Command is the API call that is executed when the schedule fires.
+	Command Command `json:"command"`
+
+	// LocalTime is the time at which the schedule fires, in the timezone of
+	// the bridge. It may also describe recurring times and timers, for example
+	// "W124/T07:30:00" or "PT00:10:00".
+	LocalTime string `json:"localtime"`
+
+	// Status is either "enabled" or "disabled".
+	Status string `json:"status,omitempty"`
+
+	// AutoDelete, when true, causes the bridge to remove the schedule once it
+	// has expired. It has no effect on recurring schedules.
+	AutoDelete bool `json:"autodelete,omitempty"`
+}
+
+// Command holds an API call which is executed by the bridge.
+type Command struct {
+	// Address is the path of the API call, for example
+	// "/api/<username>/groups/0/action".
+	Address string `json:"address"`
+
+	// Method is the HTTP method of the API call.
+	Method string `json:"method"`
+
+	// Body is the body of the API call.
+	Body interface{} `json:"body"`
+}
@@ -0,0 +1,62 @@
+package hue
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSchedulesService(t *testing.T) {
+	mb := mockBridge(t)
+	defer mb.teardown()
+
+	t.Run("List", func(t *testing.T) {
+		mb.nextResponse = map[string]*Schedule{
+			"1": &Schedule{Name: "wake up", LocalTime: "W124/T07:30:00"},
+		}
+		list, err := mb.b.Schedules().List()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(list) != 1 || list[0].ID != "1" || list[0].bridge != mb.b {
+			t.Fatalf("unexpected list %v", list)
+		}
+	})
+
+	t.Run("RecallSceneAt", func(t *testing.T) {
+		mb.nextResponse = []interface{}{
+			map[string]interface{}{"success": map[string]string{"id": "7"}},
+		}
+		at := time.Date(2016, 10, 16, 7, 30, 0, 0, time.UTC)
+		sc, err := mb.b.Schedules().RecallSceneAt("morning", &Group{ID: "3"}, "abc", at)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sc.ID != "7" {
+			t.Fatalf("expected ID 7, got %q", sc.ID)
+		}
+		if mb.lastMethod != http.MethodPost || mb.lastPath != "/api/bridge_username/schedules" {
+			t.Fatalf("unexpected request %s %s", mb.lastMethod, mb.lastPath)
+		}
+		var got map[string]interface{}
+		if err := json.NewDecoder(mb.lastBody).Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+		want := map[string]interface{}{
+			"name":        "morning",
+			"description": OwnerTag + ":bridge_username",
+			"command": map[string]interface{}{
+				"address": "/api/bridge_username/groups/3/action",
+				"method":  "PUT",
+				"body":    map[string]interface{}{"scene": "abc"},
+			},
+			"localtime":  "2016-10-16T07:30:00",
+			"autodelete": true,
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("expected %v, got %v", want, got)
+		}
+	})
+}
@@ -0,0 +1,271 @@
+package hue
+
+import (
+	"errors"
+	"math"
+	"net/http"
+)
+
+// ErrSensorNotExist is returned when a sensor was not found.
+var ErrSensorNotExist = errors.New("sensor does not exist")
+
+// Sensors returns the service to interact with the sensors on this bridge.
+func (b *Bridge) Sensors() *SensorsService { return &SensorsService{bridge: b} }
+
+// SensorsService is the service that allows interacting with the sensors API
+// of the bridge.
+type SensorsService struct{ bridge *Bridge }
+
+// List returns a slice of all sensors known to the bridge.
+func (s *SensorsService) List() ([]*Sensor, error) {
+	all, err := s.idMap()
+	if err != nil {
+		return nil, err
+	}
+	list := make([]*Sensor, 0, len(all))
+	for _, ss := range all {
+		list = append(list, ss)
+	}
+	return list, nil
+}
+
+// GetByID returns a sensor by id.
+func (s *SensorsService) GetByID(id string) (*Sensor, error) {
+	list, err := s.idMap()
+	if err != nil {
+		return nil, err
+	}
+	v, ok := list[id]
+	if !ok {
+		return nil, ErrSensorNotExist
+	}
+	return v, nil
+}
+
+// Get returns a sensor by name.
+func (s *SensorsService) Get(name string) (*Sensor, error) {
+	list, err := s.idMap()
+	if err != nil {
+		return nil, err
+	}
+	for _, ss := range list {
+		if ss.Name == name {
+			return ss, nil
+		}
+	}
+	return nil, ErrSensorNotExist
+}
+
+// Sensor types of sensors which may be created by applications (CLIP sensors).
+const (
+	TypeCLIPSwitch        = "CLIPSwitch"
+	TypeCLIPGenericFlag   = "CLIPGenericFlag"
+	TypeCLIPGenericStatus = "CLIPGenericStatus"
+	TypeCLIPPresence      = "CLIPPresence"
+)
+
+// Create creates a CLIP sensor on the bridge, using the Name, Type, ModelID,
+// ManufacturerName, SWVersion, UID and Recycle fields of the given sensor. ModelID,
+// ManufacturerName and SWVersion default to values identifying this package,
+// while UID defaults to the name. On success, the ID of the sensor is updated.
+func (s *SensorsService) Create(sn *Sensor) error {
+	if sn.ModelID == "" {
+		sn.ModelID = OwnerTag
+	}
+	if sn.ManufacturerName == "" {
+		sn.ManufacturerName = OwnerTag
+	}
+	if sn.SWVersion == "" {
+		sn.SWVersion = "1.0"
+	}
+	if sn.UID == "" {
+		sn.UID = sn.Name
+	}
+	body := map[string]interface{}{
+		"name":             sn.Name,
+		"type":             sn.Type,
+		"modelid":          sn.ModelID,
+		"manufacturername": sn.ManufacturerName,
+		"swversion":        sn.SWVersion,
+		"uniqueid":         sn.UID,
+	}
+	if sn.Recycle {
+		body["recycle"] = true
+	}
+	msg, err := s.bridge.call(http.MethodPost, body, "sensors")
+	if err != nil {
+		return err
+	}
+	id, err := createdID(msg)
+	if err != nil {
+		return err
+	}
+	sn.bridge = s.bridge
+	sn.ID = id
+	return nil
+}
+
+// CreateCLIPSwitch creates a virtual switch with the given name. Pressing its
+// buttons using PressButton triggers the rules which depend on it, which is
+// useful for testing automations without physical switches.
+func (s *SensorsService) CreateCLIPSwitch(name string) (*Sensor, error) {
+	sn := &Sensor{Name: name, Type: TypeCLIPSwitch}
+	if err := s.Create(sn); err != nil {
+		return nil, err
+	}
+	return sn, nil
+}
+
+func (s *SensorsService) idMap() (map[string]*Sensor, error) {
+	msg, err := s.bridge.call(http.MethodGet, nil, "sensors")
+	if err != nil {
+		return nil, err
+	}
+	var all map[string]*Sensor
+	err = s.bridge.decode(msg, &all)
+	for id, ss := range all {
+		ss.bridge = s.bridge
+		ss.ID = id
+	}
+	return all, err
+}
+
+// Sensor holds information about a sensor. For more information see:
+// http://www.developers.meethue.com/documentation/sensors-api
+type Sensor struct {
+	bridge *Bridge
+
+	// ID is the ID that the bridge returns for this sensor.
+	ID string `json:"-"`
+
+	// UID is the unique id of the device, if it is a physical device.
+	UID string `json:"uniqueid,omitempty"`
+
+	// Name is the name of the sensor.
+	Name string `json:"name"`
+
+	// Type is the type of the sensor (e.g. "ZLLPresence", "ZLLTemperature").
+	Type string `json:"type"`
+
+	// ModelID is the hardware model of the sensor.
+	ModelID string `json:"modelid"`
+
+	// ManufacturerName is the manufacturer name.
+	ManufacturerName string `json:"manufacturername"`
+
+	// SWVersion is an identifier for the software version of the sensor.
+	SWVersion string `json:"swversion"`
+
+	// State holds the readings of the sensor. Which fields are relevant
+	// depends on the Type of the sensor.
+	State SensorState `json:"state"`
+
+	// Config holds the configuration of the sensor.
+	Config SensorConfig `json:"config"`
+
+	// Recycle, when true, allows the bridge to delete a CLIP sensor once no
+	// rule or resource link refers to it anymore.
+	Recycle bool `json:"recycle,omitempty"`
+}
+
+// SetOn enables or disables the sensor.
+func (s *Sensor) SetOn(on bool) error {
+	_, err := s.bridge.call(http.MethodPut, map[string]bool{
+		"on": on,
+	}, "sensors", s.ID, "config")
+	if err == nil {
+		s.Config.On = on
+	}
+	return err
+}
+
+// Delete deletes the sensor. If cascade is true, the rules and schedules which
+// refer to it are deleted as well and it is removed from resource links.
+func (s *Sensor) Delete(cascade bool) error {
+	return s.bridge.deleteResource(cascade, "sensors", s.ID)
+}
+
+// Button actions, as used in the button event codes of switches. The code of a
+// button event is 1000 times the number of the button (starting at 1) plus
+// the action. For example, 2002 is a short release of the second button.
+const (
+	ButtonInitialPress = 0
+	ButtonHold         = 1
+	ButtonShortRelease = 2
+	ButtonLongRelease  = 3
+)
+
+// SetButtonEvent sets the button event of a CLIP switch to the given code,
+// triggering the rules which depend on it.
+func (s *Sensor) SetButtonEvent(code int) error {
+	_, err := s.bridge.call(http.MethodPut, map[string]int{
+		"buttonevent": code,
+	}, "sensors", s.ID, "state")
+	if err == nil {
+		s.State.ButtonEvent = code
+	}
+	return err
+}
+
+// PressButton simulates a short press of the given button (starting at 1) on
+// a CLIP switch, by setting its button event to a short release.
+func (s *Sensor) PressButton(button int) error {
+	return s.SetButtonEvent(button*1000 + ButtonShortRelease)
+}
+
+// refresh updates the sensor with its current attributes and state, as known
+// by the bridge.
+func (s *Sensor) refresh() error {
+	r, err := s.bridge.call(http.MethodGet, nil, "sensors", s.ID)
+	if err != nil {
+		return err
+	}
+	return s.bridge.decode(r, s)
+}
+
+// SensorState holds the readings of a sensor.
+type SensorState struct {
+	// LastUpdated is the time at which the state was last updated, in UTC.
+	LastUpdated string `json:"lastupdated,omitempty"`
+
+	// Presence is true when a presence sensor detects motion.
+	Presence bool `json:"presence,omitempty"`
+
+	// Temperature is the temperature in 0.01 degrees Celsius.
+	Temperature int `json:"temperature,omitempty"`
+
+	// LightLevel is the light level in 10000*log10(lux)+1.
+	LightLevel int `json:"lightlevel,omitempty"`
+
+	// Dark is true when the light level is below the configured dark
+	// threshold.
+	Dark bool `json:"dark,omitempty"`
+
+	// Daylight is true when the light level is above the configured daylight
+	// threshold.
+	Daylight bool `json:"daylight,omitempty"`
+
+	// ButtonEvent is the code of the last button event of a switch.
+	ButtonEvent int `json:"buttonevent,omitempty"`
+}
+
+// Celsius returns the temperature in degrees Celsius.
+func (s SensorState) Celsius() float64 { return float64(s.Temperature) / 100 }
+
+// Lux returns the light level in lux.
+func (s SensorState) Lux() float64 {
+	return math.Pow(10, float64(s.LightLevel-1)/10000)
+}
+
+// SensorConfig holds the configuration of a sensor.
+type SensorConfig struct {
+	// On reports whether the sensor is enabled.
+	On bool `json:"on"`
+
+	// Reachable reports whether the sensor can be reached by the bridge.
+	Reachable bool `json:"reachable"`
+
+	// Battery is the battery level in percent. It is nil for sensors which
+	// are not battery powered.
+	Battery *uint8 `json:"battery,omitempty"`
+}
@@ -0,0 +1,119 @@
+package hue
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"testing"
+)
+
+var testSensors = map[string]*Sensor{
+	"1": &Sensor{Name: "Daylight", Type: "Daylight"},
+	"2": &Sensor{Name: "Hall motion", Type: "ZLLPresence", State: SensorState{Presence: true}},
+}
+
+func TestSensorsService(t *testing.T) {
+	mb := mockBridge(t)
+	defer mb.teardown()
+	mb.nextResponse = testSensors
+
+	t.Run("List", func(t *testing.T) {
+		list, err := mb.b.Sensors().List()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(list) != len(testSensors) {
+			t.Fatalf("expected %d entries, got %d", len(testSensors), len(list))
+		}
+		for _, s := range list {
+			if s.ID == "" || s.bridge != mb.b {
+				t.Fatalf("expected to link IDs and bridges")
+			}
+		}
+	})
+
+	t.Run("Get", func(t *testing.T) {
+		s, err := mb.b.Sensors().Get("Hall motion")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s.ID != "2" || !s.State.Presence {
+			t.Fatalf("unexpected sensor %+v", s)
+		}
+		if _, err := mb.b.Sensors().Get("bogus"); err != ErrSensorNotExist {
+			t.Fatalf("expected ErrSensorNotExist, got %v", err)
+		}
+	})
+
+	t.Run("GetByID", func(t *testing.T) {
+		if _, err := mb.b.Sensors().GetByID("bogus"); err != ErrSensorNotExist {
+			t.Fatalf("expected ErrSensorNotExist, got %v", err)
+		}
+	})
+}
+
+func TestCLIPSwitch(t *testing.T) {
+	mb := mockBridge(t)
+	defer mb.teardown()
+	mb.nextResponse = []interface{}{
+		map[string]interface{}{"success": map[string]string{"id": "12"}},
+	}
+	sw, err := mb.b.Sensors().CreateCLIPSwitch("test switch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sw.ID != "12" || mb.lastMethod != http.MethodPost || mb.lastPath != "/api/bridge_username/sensors" {
+		t.Fatalf("unexpected result %v after %s %s", sw, mb.lastMethod, mb.lastPath)
+	}
+	var got map[string]string
+	if err := json.NewDecoder(mb.lastBody).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if got["type"] != TypeCLIPSwitch || got["name"] != "test switch" || got["uniqueid"] != "test switch" {
+		t.Fatalf("unexpected body %v", got)
+	}
+
+	mb.nextResponse = []interface{}{}
+	if err := sw.PressButton(2); err != nil {
+		t.Fatal(err)
+	}
+	if mb.lastMethod != http.MethodPut || mb.lastPath != "/api/bridge_username/sensors/12/state" {
+		t.Fatalf("unexpected request %s %s", mb.lastMethod, mb.lastPath)
+	}
+	var state map[string]int
+	if err := json.NewDecoder(mb.lastBody).Decode(&state); err != nil {
+		t.Fatal(err)
+	}
+	if state["buttonevent"] != 2002 || sw.State.ButtonEvent != 2002 {
+		t.Fatalf("unexpected button event %v", state)
+	}
+}
+
+func TestSensorRecycle(t *testing.T) {
+	mb := mockBridge(t)
+	defer mb.teardown()
+	mb.nextResponse = []interface{}{
+		map[string]interface{}{"success": map[string]string{"id": "13"}},
+	}
+	sn := &Sensor{Name: "flag", Type: TypeCLIPGenericFlag, Recycle: true}
+	if err := mb.b.Sensors().Create(sn); err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]interface{}
+	if err := json.NewDecoder(mb.lastBody).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if got["recycle"] != true {
+		t.Fatalf("expected the sensor to be recyclable, got %v", got)
+	}
+}
+
+func TestSensorState(t *testing.T) {
+	s := SensorState{Temperature: 2153, LightLevel: 20001}
+	if got := s.Celsius(); got != 21.53 {
+		t.Fatalf("expected 21.53, got %v", got)
+	}
+	if got := s.Lux(); math.Abs(got-100) > 1e-9 {
+		t.Fatalf("expected 100 lux, got %v", got)
+	}
+}
@@ -0,0 +1,169 @@
+package hue
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// Snapshot holds the configuration of a bridge at a point in time. It may be
+// stored as JSON, so that it can later be compared with another using Diff.
+type Snapshot struct {
+	// Time is the time at which the snapshot was taken.
+	Time time.Time `json:"time"`
+
+	// Lights holds the lights of the bridge, keyed by ID.
+	Lights map[string]*Light `json:"lights"`
+
+	// Groups holds the groups of the bridge, keyed by ID.
+	Groups map[string]*Group `json:"groups"`
+
+	// Scenes holds the scenes of the bridge, keyed by ID.
+	Scenes map[string]*Scene `json:"scenes"`
+
+	// Rules holds the rules of the bridge, keyed by ID.
+	Rules map[string]*Rule `json:"rules"`
+
+	// Schedules holds the schedules of the bridge, keyed by ID.
+	Schedules map[string]*Schedule `json:"schedules"`
+
+	// Sensors holds the sensors of the bridge, keyed by ID.
+	Sensors map[string]*Sensor `json:"sensors"`
+}
+
+// Snapshot retrieves the configuration of the bridge.
+func (b *Bridge) Snapshot() (*Snapshot, error) {
+	s := &Snapshot{Time: b.now()}
+	var err error
+	if s.Lights, err = b.Lights().idMap(); err != nil {
+		return nil, err
+	}
+	if s.Groups, err = b.Groups().idMap(); err != nil {
+		return nil, err
+	}
+	if s.Scenes, err = b.Scenes().idMap(); err != nil {
+		return nil, err
+	}
+	if s.Rules, err = b.Rules().idMap(); err != nil {
+		return nil, err
+	}
+	if s.Schedules, err = b.Schedules().idMap(); err != nil {
+		return nil, err
+	}
+	if s.Sensors, err = b.Sensors().idMap(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Kinds of changes.
+const (
+	ChangeAdded    = "added"
+	ChangeRemoved  = "removed"
+	ChangeRenamed  = "renamed"
+	ChangeModified = "modified"
+)
+
+// Change describes a change to a resource between two snapshots.
+type Change struct {
+	// Resource is the address of the resource (e.g. "/lights/3").
+	Resource string `json:"resource"`
+
+	// Kind is the kind of change, such as ChangeAdded.
+	Kind string `json:"kind"`
+
+	// Name is the name of the resource. For removed resources, it is the
+	// name they had.
+	Name string `json:"name"`
+
+	// OldName is the previous name of a renamed resource.
+	OldName string `json:"oldname,omitempty"`
+}
+
+// Diff returns the changes to the configuration of a bridge from snapshot a to
+// snapshot b, by kind of resource and ID. Lights and sensors are compared by name
+// only, since their state changes all the time. Groups are modified when
+// their lights, type or class change; scenes, rules and schedules when any
+// of their attributes do. A resource which is both renamed and modified is
+// reported twice.
+func Diff(a, b *Snapshot) []Change {
+	var d differ
+	d.diff("lights", names(a.Lights), names(b.Lights), nil)
+	d.diff("groups", names(a.Groups), names(b.Groups), func(id string) bool {
+		ga, gb := a.Groups[id], b.Groups[id]
+		return !reflect.DeepEqual(ga.Lights, gb.Lights) || ga.Type != gb.Type || ga.Class != gb.Class
+	})
+	d.diff("scenes", names(a.Scenes), names(b.Scenes), func(id string) bool {
+		sa, sb := *a.Scenes[id], *b.Scenes[id]
+		sa.Name, sb.Name = "", ""
+		return !sameJSON(sa, sb)
+	})
+	d.diff("rules", names(a.Rules), names(b.Rules), func(id string) bool {
+		ra, rb := *a.Rules[id], *b.Rules[id]
+		ra.Name, rb.Name = "", ""
+		return !sameJSON(ra, rb)
+	})
+	d.diff("schedules", names(a.Schedules), names(b.Schedules), func(id string) bool {
+		sa, sb := *a.Schedules[id], *b.Schedules[id]
+		sa.Name, sb.Name = "", ""
+		return !sameJSON(sa, sb)
+	})
+	d.diff("sensors", names(a.Sensors), names(b.Sensors), nil)
+	return d
+}
+
+// differ collects changes.
+type differ []Change
+
+// diff records the changes to the resources of the given kind, given their
+// names in both snapshots, keyed by ID. If modified is not nil, it reports
+// whether a resource present in both snapshots was modified.
+func (d *differ) diff(kind string, a, b map[string]string, modified func(id string) bool) {
+	var ids []string
+	for id := range a {
+		ids = append(ids, id)
+	}
+	for id := range b {
+		if _, ok := a[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return lessID(ids[i], ids[j]) })
+	for _, id := range ids {
+		addr := "/" + kind + "/" + id
+		old, inA := a[id]
+		name, inB := b[id]
+		switch {
+		case !inB:
+			*d = append(*d, Change{Resource: addr, Kind: ChangeRemoved, Name: old})
+			continue
+		case !inA:
+			*d = append(*d, Change{Resource: addr, Kind: ChangeAdded, Name: name})
+			continue
+		case old != name:
+			*d = append(*d, Change{Resource: addr, Kind: ChangeRenamed, Name: name, OldName: old})
+		}
+		if modified != nil && modified(id) {
+			*d = append(*d, Change{Resource: addr, Kind: ChangeModified, Name: name})
+		}
+	}
+}
+
+// names returns the names of the resources in m, which must be a map of
+// pointers to structs with a Name field, keyed by ID.
+func names(m interface{}) map[string]string {
+	v := reflect.ValueOf(m)
+	out := make(map[string]string, v.Len())
+	for _, k := range v.MapKeys() {
+		out[k.String()] = v.MapIndex(k).Elem().FieldByName("Name").String()
+	}
+	return out
+}
+
+// sameJSON reports whether a and b have the same JSON encoding.
+func sameJSON(a, b interface{}) bool {
+	ja, erra := json.Marshal(a)
+	jb, errb := json.Marshal(b)
+	return erra == nil && errb == nil && string(ja) == string(jb)
+}
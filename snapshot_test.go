@@ -0,0 +1,43 @@
+package hue
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestSnapshotDiff(t *testing.T) {
+	var a, b Snapshot
+	if err := json.Unmarshal([]byte(`{
+		"lights": {"1": {"name": "Desk"}, "2": {"name": "Hall"}},
+		"groups": {"1": {"name": "Office", "lights": ["1"], "type": "Room"}},
+		"scenes": {"ab12": {"name": "Relax", "lights": ["1"], "lastupdated": "2018-01-01T00:00:00"}},
+		"rules": {},
+		"schedules": {},
+		"sensors": {}}`), &a); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(`{
+		"lights": {"1": {"name": "Desk lamp"}, "10": {"name": "Porch"}},
+		"groups": {"1": {"name": "Office", "lights": ["1", "10"], "type": "Room"}},
+		"scenes": {"ab12": {"name": "Relax", "lights": ["1"], "lastupdated": "2018-01-02T00:00:00"}},
+		"rules": {"3": {"name": "Motion"}},
+		"schedules": {},
+		"sensors": {}}`), &b); err != nil {
+		t.Fatal(err)
+	}
+	want := []Change{
+		{Resource: "/lights/1", Kind: ChangeRenamed, Name: "Desk lamp", OldName: "Desk"},
+		{Resource: "/lights/2", Kind: ChangeRemoved, Name: "Hall"},
+		{Resource: "/lights/10", Kind: ChangeAdded, Name: "Porch"},
+		{Resource: "/groups/1", Kind: ChangeModified, Name: "Office"},
+		{Resource: "/scenes/ab12", Kind: ChangeModified, Name: "Relax"},
+		{Resource: "/rules/3", Kind: ChangeAdded, Name: "Motion"},
+	}
+	if got := Diff(&a, &b); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	if got := Diff(&a, &a); len(got) != 0 {
+		t.Fatalf("expected no changes, got %v", got)
+	}
+}
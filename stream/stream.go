@@ -0,0 +1,170 @@
+// Package stream implements the client side of the Hue Entertainment streaming
+// protocol. It takes care of encoding messages, pacing them at a steady rate,
+// keeping the session alive and reconnecting when the connection is lost.
+//
+// The bridge only accepts streaming messages over DTLS (PSK), which the
+// standard library does not implement. The connection is therefore obtained
+// through a Dialer supplied by the caller, for example using a third-party
+// DTLS package. For more information see:
+// https://developers.meethue.com/documentation/hue-entertainment-api
+package stream // import "gbbr.io/hue/stream"
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"sort"
+	"time"
+
+	"gbbr.io/hue"
+)
+
+// Color is an RGB color, using 16 bits per channel.
+type Color struct{ R, G, B uint16 }
+
+// FromXY returns the color with CIE coordinates xy at the given brightness,
+// between 0 and 1.
+func FromXY(xy hue.XY, brightness float64) Color {
+	c := xy.RGB()
+	scale := func(v uint8) uint16 { return uint16(float64(v) * 257 * brightness) }
+	return Color{R: scale(c.R), G: scale(c.G), B: scale(c.B)}
+}
+
+// Frame maps the IDs of the lights in an entertainment group to the color they
+// should display.
+type Frame map[uint16]Color
+
+// Dialer establishes a DTLS connection to the bridge.
+type Dialer func(ctx context.Context) (io.WriteCloser, error)
+
+const (
+	// defaultRate is the default number of messages sent per second.
+	defaultRate = 25
+
+	// defaultRedialDelay is the default time to wait before reconnecting.
+	defaultRedialDelay = time.Second
+)
+
+// Streamer sends frames to the bridge.
+type Streamer struct {
+	// Dial is used to (re)establish the connection to the bridge.
+	Dial Dialer
+
+	// Rate is the number of messages sent per second. It defaults to 25,
+	// which is what the bridge recommends. The last frame is resent at this
+	// rate even when no new frames arrive, which keeps the session alive.
+	Rate int
+
+	// RedialDelay is the time to wait before reconnecting after the
+	// connection was lost. It defaults to one second.
+	RedialDelay time.Duration
+}
+
+// Run streams frames to the bridge using the given dialer, with the default
+// settings. See Streamer.Run.
+func Run(ctx context.Context, dial Dialer, frames <-chan Frame) error {
+	return (&Streamer{Dial: dial}).Run(ctx, frames)
+}
+
+// Run streams the frames received on the given channel until it is closed, in
+// which case it returns nil, or until the context is done, in which case it
+// returns the context's error. Frames that arrive faster than the configured
+// rate are coalesced, so that only the most recent one is sent.
+func (s *Streamer) Run(ctx context.Context, frames <-chan Frame) error {
+	rate := s.Rate
+	if rate <= 0 {
+		rate = defaultRate
+	}
+	conn, err := s.dial(ctx, 0)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if conn != nil {
+			conn.Close()
+		}
+	}()
+
+	tick := time.NewTicker(time.Second / time.Duration(rate))
+	defer tick.Stop()
+	var (
+		last Frame
+		seq  uint8
+	)
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case f, ok := <-frames:
+			if !ok {
+				return nil
+			}
+			last = f
+		case <-tick.C:
+			if last == nil {
+				continue
+			}
+			seq++
+			if _, err := conn.Write(encode(seq, last)); err != nil {
+				conn.Close()
+				conn = nil
+				if conn, err = s.dial(ctx, s.redialDelay()); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// redialDelay returns the time to wait before reconnecting.
+func (s *Streamer) redialDelay() time.Duration {
+	if s.RedialDelay <= 0 {
+		return defaultRedialDelay
+	}
+	return s.RedialDelay
+}
+
+// dial establishes a connection after waiting for the given delay, retrying
+// after the redial delay until it succeeds or the context is done.
+func (s *Streamer) dial(ctx context.Context, delay time.Duration) (io.WriteCloser, error) {
+	for {
+		if delay > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(delay):
+			}
+		}
+		conn, err := s.Dial(ctx)
+		if err == nil {
+			return conn, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		delay = s.redialDelay()
+	}
+}
+
+// header is the start of every message, followed by the protocol version.
+var header = []byte("HueStream\x01\x00")
+
+// encode returns the message for frame f with sequence number seq, using the
+// RGB color space. Lights are sorted by ID.
+func encode(seq uint8, f Frame) []byte {
+	ids := make([]int, 0, len(f))
+	for id := range f {
+		ids = append(ids, int(id))
+	}
+	sort.Ints(ids)
+	buf := bytes.NewBuffer(make([]byte, 0, len(header)+5+9*len(f)))
+	buf.Write(header)
+	buf.Write([]byte{seq, 0x00, 0x00, 0x00, 0x00}) // seq, reserved, RGB, reserved
+	for _, id := range ids {
+		c := f[uint16(id)]
+		buf.WriteByte(0x00) // device type: light
+		binary.Write(buf, binary.BigEndian, [4]uint16{uint16(id), c.R, c.G, c.B})
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,151 @@
+package stream
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"gbbr.io/hue"
+)
+
+func TestEncode(t *testing.T) {
+	got := encode(7, Frame{
+		2: {R: 0xffff},
+		1: {G: 0x0102, B: 0x0304},
+	})
+	want := append([]byte("HueStream"),
+		0x01, 0x00, 0x07, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x01, 0x00, 0x00, 0x01, 0x02, 0x03, 0x04,
+		0x00, 0x00, 0x02, 0xff, 0xff, 0x00, 0x00, 0x00, 0x00,
+	)
+	if !bytes.Equal(got, want) {
+		t.Fatalf("expected %x, got %x", want, got)
+	}
+}
+
+// fakeConn records written messages and fails once failAfter messages were
+// written, if set.
+type fakeConn struct {
+	mu        sync.Mutex
+	msgs      [][]byte
+	failAfter int
+	closed    bool
+}
+
+func (c *fakeConn) Write(b []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.failAfter > 0 && len(c.msgs) >= c.failAfter {
+		return 0, errors.New("connection lost")
+	}
+	c.msgs = append(c.msgs, append([]byte(nil), b...))
+	return len(b), nil
+}
+
+func (c *fakeConn) Close() error {
+	c.mu.Lock()
+	c.closed = true
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *fakeConn) count() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.msgs)
+}
+
+func TestRun(t *testing.T) {
+	var conns []*fakeConn
+	s := &Streamer{
+		Dial: func(ctx context.Context) (io.WriteCloser, error) {
+			c := &fakeConn{}
+			if len(conns) == 0 {
+				c.failAfter = 2
+			}
+			conns = append(conns, c)
+			return c, nil
+		},
+		Rate:        100,
+		RedialDelay: time.Millisecond,
+	}
+	frames := make(chan Frame)
+	done := make(chan error)
+	go func() { done <- s.Run(context.Background(), frames) }()
+	frames <- Frame{1: {R: 1}}
+	// the frame is resent to keep the session alive, and the connection is
+	// re-established after it fails
+	time.Sleep(100 * time.Millisecond)
+	close(frames)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if len(conns) != 2 {
+		t.Fatalf("expected to reconnect once, got %d connections", len(conns))
+	}
+	if !conns[0].closed || !conns[1].closed {
+		t.Fatal("expected connections to be closed")
+	}
+	if conns[1].count() == 0 {
+		t.Fatal("expected frames on the new connection")
+	}
+}
+
+func TestRunCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	dial := func(context.Context) (io.WriteCloser, error) { return &fakeConn{}, nil }
+	done := make(chan error)
+	go func() { done <- Run(ctx, dial, make(chan Frame)) }()
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Fatalf("expected %v, got %v", context.Canceled, err)
+	}
+}
+
+func TestRunCancelRedial(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var mu sync.Mutex
+	var dials []time.Time
+	s := &Streamer{
+		Dial: func(context.Context) (io.WriteCloser, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			dials = append(dials, time.Now())
+			switch len(dials) {
+			case 1:
+				return &fakeConn{failAfter: 1}, nil
+			case 3:
+				cancel()
+			}
+			return nil, errors.New("bridge unreachable")
+		},
+		Rate:        100,
+		RedialDelay: 20 * time.Millisecond,
+	}
+	frames := make(chan Frame, 1)
+	frames <- Frame{1: {R: 1}}
+	if err := s.Run(ctx, frames); err != context.Canceled {
+		t.Fatalf("expected %v, got %v", context.Canceled, err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(dials) != 3 {
+		t.Fatalf("expected 3 dials, got %d", len(dials))
+	}
+	if d := dials[2].Sub(dials[1]); d < 20*time.Millisecond || d > 500*time.Millisecond {
+		t.Fatalf("expected to redial after the configured delay, got %v", d)
+	}
+}
+
+func TestFromXY(t *testing.T) {
+	if c := FromXY(hue.WhitePoint, 1); c.R < 0xfe00 || c.G < 0xfe00 || c.B < 0xfe00 {
+		t.Fatalf("expected white, got %v", c)
+	}
+	if c := FromXY(hue.WhitePoint, 0.5); c.R > 0x8000 || c.R < 0x7f00 {
+		t.Fatalf("expected half brightness, got %v", c)
+	}
+}
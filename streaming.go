@@ -0,0 +1,91 @@
+package hue
+
+import (
+	"errors"
+	"net/http"
+)
+
+// ErrStreamingActive is returned by commands to lights which are used by an
+// active entertainment stream, when the DetectStreaming option is used. Such
+// lights ignore commands sent using this package until the stream ends.
+var ErrStreamingActive = errors.New("lights are used by an active entertainment stream")
+
+// GroupStream holds the streaming state of an entertainment group.
+type GroupStream struct {
+	// ProxyMode is "auto" or "manual".
+	ProxyMode string `json:"proxymode,omitempty"`
+
+	// ProxyNode is the address of the light which forwards the stream to the
+	// others (e.g. "/lights/3"), or "/bridge".
+	ProxyNode string `json:"proxynode,omitempty"`
+
+	// Active reports whether the group is streaming.
+	Active bool `json:"active"`
+
+	// Owner is the username of the application which is streaming, if any.
+	Owner string `json:"owner,omitempty"`
+}
+
+// Position is the position of a light in an entertainment area, as the x, y
+// and z coordinates, each between -1 and 1. The x axis goes from left to
+// right, the y axis from the back of the room to the front, where the screen
+// is, and the z axis from the floor to the ceiling.
+type Position [3]float64
+
+// X returns the x coordinate of the position.
+func (p Position) X() float64 { return p[0] }
+
+// Y returns the y coordinate of the position.
+func (p Position) Y() float64 { return p[1] }
+
+// Z returns the z coordinate of the position.
+func (p Position) Z() float64 { return p[2] }
+
+// Streaming reports whether the group is an entertainment group which is
+// streaming.
+func (g *Group) Streaming() bool { return g.Stream != nil && g.Stream.Active }
+
+// checkStreaming returns ErrStreamingActive if the state command to the
+// resource at tokens addresses lights which are used by an active
+// entertainment stream. Other calls are not checked.
+func (b *Bridge) checkStreaming(method string, tokens []string) error {
+	if method != http.MethodPut || len(tokens) != 3 {
+		return nil
+	}
+	switch {
+	case tokens[0] == "lights" && tokens[2] == "state":
+	case tokens[0] == "groups" && tokens[2] == "action":
+	default:
+		return nil
+	}
+	groups, err := b.Groups().idMap()
+	if err != nil {
+		return err
+	}
+	captured := make(map[string]bool)
+	for _, g := range groups {
+		if g.Streaming() {
+			for _, id := range g.Lights {
+				captured[id] = true
+			}
+		}
+	}
+	if len(captured) == 0 {
+		return nil
+	}
+	var targets []string
+	switch {
+	case tokens[0] == "lights":
+		targets = []string{tokens[1]}
+	case tokens[1] == "0":
+		return ErrStreamingActive
+	case groups[tokens[1]] != nil:
+		targets = groups[tokens[1]].Lights
+	}
+	for _, id := range targets {
+		if captured[id] {
+			return ErrStreamingActive
+		}
+	}
+	return nil
+}
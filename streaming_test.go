@@ -0,0 +1,48 @@
+package hue
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestDetectStreaming(t *testing.T) {
+	mb := mockBridge(t)
+	defer mb.teardown()
+	mb.responses = map[string]interface{}{
+		"/api/bridge_username/groups": json.RawMessage(`{
+			"1": {"name": "TV", "type": "Entertainment", "lights": ["1", "2"], "stream": {"proxymode": "auto", "proxynode": "/bridge", "active": true, "owner": "other"}},
+			"2": {"name": "Kitchen", "type": "Room", "lights": ["3"]},
+			"3": {"name": "Living", "type": "Room", "lights": ["2", "4"]}}`),
+	}
+	mb.nextResponse = json.RawMessage(`[{"success": {}}]`)
+	mb.b.detectStreaming = true
+	for _, tt := range []struct {
+		fn   func() error
+		want error
+	}{
+		{(&Light{bridge: mb.b, ID: "1"}).On, ErrStreamingActive},
+		{(&Light{bridge: mb.b, ID: "3"}).Off, nil},
+		{(&Group{bridge: mb.b, ID: "3"}).Off, ErrStreamingActive},
+		{mb.b.Groups().All().On, ErrStreamingActive},
+	} {
+		if err := tt.fn(); err != tt.want {
+			t.Fatalf("expected %v, got %v", tt.want, err)
+		}
+	}
+}
+
+func TestEntertainmentLocations(t *testing.T) {
+	var g Group
+	err := json.Unmarshal([]byte(`{
+		"name": "TV", "type": "Entertainment", "lights": ["1", "2"], "sensors": ["7"],
+		"locations": {"1": [-0.5, 1, 0], "2": [0.4, -0.8, 0.6]}}`), &g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := g.Locations["2"]; p.X() != 0.4 || p.Y() != -0.8 || p.Z() != 0.6 {
+		t.Fatalf("unexpected position %v", p)
+	}
+	if len(g.Locations) != 2 || len(g.Sensors) != 1 || g.Sensors[0] != "7" {
+		t.Fatalf("unexpected group %+v", g)
+	}
+}
@@ -0,0 +1,119 @@
+package hue
+
+// Sensor types of switches.
+const (
+	// TypeZLLSwitch is the type of the Hue dimmer switch, and of the buttons
+	// of other Hue switches such as the tap dial.
+	TypeZLLSwitch = "ZLLSwitch"
+
+	// TypeZGPSwitch is the type of Zigbee Green Power switches, such as the
+	// Hue tap and friends-of-hue switches made by other vendors.
+	TypeZGPSwitch = "ZGPSwitch"
+
+	// TypeZLLRelativeRotary is the type of the dial of the Hue tap dial
+	// switch. Its buttons are a separate sensor of type ZLLSwitch.
+	TypeZLLRelativeRotary = "ZLLRelativeRotary"
+)
+
+// Model IDs of ZGPSwitch sensors.
+const (
+	// ModelTap is the model ID of the Hue tap.
+	ModelTap = "ZGPSWITCH"
+
+	// ModelFriendsOfHue is the model ID of friends-of-hue switches.
+	ModelFriendsOfHue = "FOHSWITCH"
+)
+
+// Buttons of friends-of-hue switches which are pressed together. Pressing both
+// buttons on the left (1 and 3) or on the right (2 and 4) of a switch is
+// reported as a button of its own.
+const (
+	ButtonsLeft  = 5
+	ButtonsRight = 6
+)
+
+// ButtonEvent is a decoded button event of a switch.
+type ButtonEvent struct {
+	// Button is the number of the button, starting at 1.
+	Button int
+
+	// Action is one of ButtonInitialPress, ButtonHold, ButtonShortRelease or
+	// ButtonLongRelease.
+	Action int
+}
+
+// tapButtons maps the button event codes of the Hue tap to its buttons. The
+// tap only reports presses.
+var tapButtons = map[int]int{34: 1, 16: 2, 17: 3, 18: 4}
+
+// fohEvents maps the button event codes of friends-of-hue switches to button
+// events. These switches report a press and a release for every button, but
+// do not tell short and long releases apart.
+var fohEvents = map[int]ButtonEvent{
+	16:  {1, ButtonInitialPress},
+	20:  {1, ButtonShortRelease},
+	17:  {2, ButtonInitialPress},
+	21:  {2, ButtonShortRelease},
+	18:  {3, ButtonInitialPress},
+	22:  {3, ButtonShortRelease},
+	19:  {4, ButtonInitialPress},
+	23:  {4, ButtonShortRelease},
+	100: {ButtonsLeft, ButtonInitialPress},
+	101: {ButtonsLeft, ButtonShortRelease},
+	98:  {ButtonsRight, ButtonInitialPress},
+	99:  {ButtonsRight, ButtonShortRelease},
+}
+
+// Button decodes the last button event of the switch. The dimmer switch and
+// CLIP switches use codes of the form 1000*button+action, while ZGPSwitch
+// sensors use a scheme of their own which depends on their model. It returns
+// false if the sensor is not a switch, has not reported an event yet or
+// reported an unknown code.
+func (s *Sensor) Button() (ButtonEvent, bool) {
+	code := s.State.ButtonEvent
+	if code == 0 {
+		return ButtonEvent{}, false
+	}
+	switch s.Type {
+	case TypeZLLSwitch, TypeCLIPSwitch:
+		e := ButtonEvent{Button: code / 1000, Action: code % 1000}
+		return e, e.Button > 0 && e.Action <= ButtonLongRelease
+	case TypeZGPSwitch:
+		if s.ModelID == ModelFriendsOfHue {
+			e, ok := fohEvents[code]
+			return e, ok
+		}
+		button, ok := tapButtons[code]
+		return ButtonEvent{Button: button, Action: ButtonInitialPress}, ok
+	}
+	return ButtonEvent{}, false
+}
+
+// ButtonCode returns the button event code which the switch reports for the
+// given event, for use in rule conditions. It returns false if the switch
+// does not report such an event.
+func (s *Sensor) ButtonCode(e ButtonEvent) (int, bool) {
+	switch s.Type {
+	case TypeZLLSwitch, TypeCLIPSwitch:
+		ok := e.Button > 0 && e.Action >= ButtonInitialPress && e.Action <= ButtonLongRelease
+		return e.Button*1000 + e.Action, ok
+	case TypeZGPSwitch:
+		if s.ModelID == ModelFriendsOfHue {
+			for code, fe := range fohEvents {
+				if fe == e {
+					return code, true
+				}
+			}
+			return 0, false
+		}
+		if e.Action != ButtonInitialPress {
+			return 0, false
+		}
+		for code, button := range tapButtons {
+			if button == e.Button {
+				return code, true
+			}
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,36 @@
+package hue
+
+import "testing"
+
+func TestSensorButton(t *testing.T) {
+	for _, tt := range []struct {
+		typ, model string
+		code       int
+		want       ButtonEvent
+		ok         bool
+	}{
+		{TypeZLLSwitch, "RWL021", 2001, ButtonEvent{2, ButtonHold}, true},
+		{TypeCLIPSwitch, "", 4002, ButtonEvent{4, ButtonShortRelease}, true},
+		{TypeZLLSwitch, "RWL021", 0, ButtonEvent{}, false},
+		{TypeZGPSwitch, ModelTap, 34, ButtonEvent{1, ButtonInitialPress}, true},
+		{TypeZGPSwitch, ModelTap, 18, ButtonEvent{4, ButtonInitialPress}, true},
+		{TypeZGPSwitch, ModelFriendsOfHue, 18, ButtonEvent{3, ButtonInitialPress}, true},
+		{TypeZGPSwitch, ModelFriendsOfHue, 23, ButtonEvent{4, ButtonShortRelease}, true},
+		{TypeZGPSwitch, ModelFriendsOfHue, 99, ButtonEvent{ButtonsRight, ButtonShortRelease}, true},
+		{TypeZGPSwitch, ModelFriendsOfHue, 34, ButtonEvent{}, false},
+		{"ZLLPresence", "SML001", 1002, ButtonEvent{}, false},
+	} {
+		s := &Sensor{Type: tt.typ, ModelID: tt.model, State: SensorState{ButtonEvent: tt.code}}
+		got, ok := s.Button()
+		if ok != tt.ok || (ok && got != tt.want) {
+			t.Fatalf("%s %s %d: expected %v %v, got %v %v", tt.typ, tt.model, tt.code, tt.want, tt.ok, got, ok)
+		}
+		if !ok {
+			continue
+		}
+		code, ok := s.ButtonCode(got)
+		if !ok || code != tt.code {
+			t.Fatalf("%s %s %v: expected code %d, got %d %v", tt.typ, tt.model, got, tt.code, code, ok)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package hue
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// swrCache holds the last responses of listing calls, which are returned
+// while they are refreshed in the background.
+type swrCache struct {
+	mu         sync.Mutex
+	entries    map[string][]byte
+	refreshing map[string]bool
+}
+
+// list retrieves the listing at tokens. With the StaleWhileRevalidate option,
+// the previous response is returned if there is one, reported as stale, and
+// refreshed in the background.
+func (b *Bridge) list(tokens ...string) (msg []byte, stale bool, err error) {
+	if b.swr == nil {
+		msg, err := b.call(http.MethodGet, nil, tokens...)
+		return msg, false, err
+	}
+	return b.swr.get(b, tokens)
+}
+
+// get returns the cached response at tokens, refreshing it in the background,
+// or retrieves it if it is not cached.
+func (c *swrCache) get(b *Bridge, tokens []string) ([]byte, bool, error) {
+	key := strings.Join(tokens, "/")
+	c.mu.Lock()
+	msg, ok := c.entries[key]
+	if ok && !c.refreshing[key] {
+		c.refreshing[key] = true
+		go c.refresh(b, key, tokens)
+	}
+	c.mu.Unlock()
+	if ok {
+		return msg, true, nil
+	}
+	msg, err := b.call(http.MethodGet, nil, tokens...)
+	if err != nil {
+		return nil, false, err
+	}
+	c.mu.Lock()
+	c.entries[key] = msg
+	c.mu.Unlock()
+	return msg, false, nil
+}
+
+// refresh retrieves the response at tokens and caches it under key. Failures
+// keep the previous response.
+func (c *swrCache) refresh(b *Bridge, key string, tokens []string) {
+	msg, err := b.call(http.MethodGet, nil, tokens...)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err == nil {
+		c.entries[key] = msg
+	}
+	delete(c.refreshing, key)
+}
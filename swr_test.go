@@ -0,0 +1,59 @@
+package hue
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestStaleWhileRevalidate(t *testing.T) {
+	var n int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"1": {"name": "v%d"}}`, atomic.AddInt32(&n, 1))
+	}))
+	defer srv.Close()
+	var o options
+	StaleWhileRevalidate()(&o)
+	b := o.apply(&Bridge{bridgeID: bridgeID{IP: srv.URL + "/"}, username: "u"})
+
+	l, err := b.Lights().GetByID("1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.Name != "v1" || l.Stale() {
+		t.Fatalf("expected fresh v1, got %q (stale: %v)", l.Name, l.Stale())
+	}
+	l, err = b.Lights().GetByID("1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.Name != "v1" || !l.Stale() {
+		t.Fatalf("expected stale v1, got %q (stale: %v)", l.Name, l.Stale())
+	}
+	// the background refresh makes the next call return v2
+	deadline := time.Now().Add(time.Second)
+	for {
+		l, err = b.Lights().GetByID("1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if l.Name == "v2" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected refreshed response")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	// other methods always retrieve current data
+	all, err := b.Lights().idMap()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if all["1"].Stale() {
+		t.Fatal("expected current data")
+	}
+}
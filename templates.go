@@ -0,0 +1,282 @@
+package hue
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Template is a parameterized automation, such as a motion-activated light,
+// which is installed on the bridge as a set of rules. The templates provided
+// by this package are obtained using functions such as MotionLightTemplate.
+type Template struct {
+	// Name is the name of the automation. It is used as the name of the
+	// resource link which groups its resources, and as a prefix of their
+	// names.
+	Name string
+
+	// rules are the rules of the automation.
+	rules []*Rule
+}
+
+// templateClassID is the class ID of the resource links created for
+// templates. Class IDs are defined by applications.
+const templateClassID = 6021
+
+// Installation is a template installed on the bridge.
+type Installation struct {
+	bridge *Bridge
+
+	// ID is the ID of the resource link which groups the resources of the
+	// installation.
+	ID string
+
+	// Resources holds the addresses of the resources of the installation
+	// (e.g. "/rules/3").
+	Resources []string
+}
+
+// Install creates the resources of template t on the bridge, along with a
+// resource link which groups them, so that they can be removed as a unit
+// using Installation.Remove. If installation fails, the resources that were
+// created are removed. Bridges which do not support resource links return an
+// *UnsupportedError.
+func (b *Bridge) Install(t *Template) (*Installation, error) {
+	if err := b.supports(featureResourceLinks); err != nil {
+		return nil, err
+	}
+	in := &Installation{bridge: b}
+	if err := in.create(t); err != nil {
+		in.Remove()
+		return nil, err
+	}
+	if err := in.link(t.Name, OwnerTag, templateClassID, in.Resources); err != nil {
+		in.Remove()
+		return nil, err
+	}
+	return in, nil
+}
+
+// link creates the resource link of the installation, with the given
+// attributes.
+func (in *Installation) link(name, description string, classID int, links []string) error {
+	msg, err := in.bridge.call(http.MethodPost, map[string]interface{}{
+		"name":        name,
+		"description": description,
+		"type":        "Link",
+		"classid":     classID,
+		"recycle":     false,
+		"links":       links,
+	}, "resourcelinks")
+	if err != nil {
+		return err
+	}
+	in.ID, err = createdID(msg)
+	return err
+}
+
+// create creates the resources of template t, recording them.
+func (in *Installation) create(t *Template) error {
+	for _, r := range t.rules {
+		rule := *r
+		if err := in.bridge.Rules().Create(&rule); err != nil {
+			return err
+		}
+		in.Resources = append(in.Resources, "/rules/"+rule.ID)
+	}
+	return nil
+}
+
+// Installation returns the installation with the given resource link ID, for
+// example to remove it after a restart.
+func (b *Bridge) Installation(id string) (*Installation, error) {
+	msg, err := b.call(http.MethodGet, nil, "resourcelinks", id)
+	if err != nil {
+		return nil, err
+	}
+	var link struct {
+		Links []string `json:"links"`
+	}
+	if err := b.decode(msg, &link); err != nil {
+		return nil, err
+	}
+	return &Installation{bridge: b, ID: id, Resources: link.Links}, nil
+}
+
+// Remove deletes the resources of the installation, in the reverse order of
+// their creation, and its resource link. Resources which no longer exist are
+// skipped.
+func (in *Installation) Remove() error {
+	for i := len(in.Resources) - 1; i >= 0; i-- {
+		if err := in.remove(in.Resources[i]); err != nil {
+			return err
+		}
+	}
+	if in.ID == "" {
+		return nil
+	}
+	return in.remove("/resourcelinks/" + in.ID)
+}
+
+// remove deletes the resource at the given address, unless it no longer
+// exists.
+func (in *Installation) remove(addr string) error {
+	_, err := in.bridge.call(http.MethodDelete, nil, strings.Split(strings.TrimPrefix(addr, "/"), "/")...)
+	if e, ok := err.(APIError); ok && e.Code == errResourceNotAvailable {
+		return nil
+	}
+	return err
+}
+
+// ddx returns the value of a "ddx" condition which is met d after a change.
+func ddx(d time.Duration) string {
+	s := int(d / time.Second)
+	return fmt.Sprintf("PT%02d:%02d:%02d", s/3600, s/60%60, s%60)
+}
+
+// MotionLightTemplate returns a template which sets state s on group g when
+// the given presence sensor detects motion, and turns the group off once no
+// motion has been detected for the given timeout.
+func MotionLightTemplate(name string, presence *Sensor, g *Group, s *State, timeout time.Duration) *Template {
+	return onOffTemplate(name, "/sensors/"+presence.ID+"/state/presence", g, s, timeout)
+}
+
+// dimmerStep is the brightness increment of the dim buttons of the dimmer
+// switch template.
+const dimmerStep = 30
+
+// DimmerSwitchTemplate returns a template which maps the buttons of a Hue
+// dimmer switch to group g in the standard way: the first button turns the
+// group on, the second and third dim it up and down, also while held, and the
+// fourth turns it off.
+func DimmerSwitchTemplate(name string, sw *Sensor, g *Group) *Template {
+	rule := func(suffix string, code int, body interface{}) *Rule {
+		return &Rule{
+			Name: name + " " + suffix,
+			Conditions: []Condition{
+				{Address: "/sensors/" + sw.ID + "/state/buttonevent", Operator: OpEq, Value: fmt.Sprint(code)},
+				{Address: "/sensors/" + sw.ID + "/state/lastupdated", Operator: OpDx},
+			},
+			Actions: []Command{groupCommand(g, body)},
+		}
+	}
+	return &Template{
+		Name: name,
+		rules: []*Rule{
+			rule("on", 1000+ButtonShortRelease, &State{On: true}),
+			rule("up", 2000+ButtonShortRelease, map[string]interface{}{"on": true, "bri_inc": dimmerStep}),
+			rule("up hold", 2000+ButtonHold, map[string]interface{}{"on": true, "bri_inc": dimmerStep}),
+			rule("down", 3000+ButtonShortRelease, map[string]int{"bri_inc": -dimmerStep}),
+			rule("down hold", 3000+ButtonHold, map[string]int{"bri_inc": -dimmerStep}),
+			rule("off", 4000+ButtonShortRelease, offState{}),
+		},
+	}
+}
+
+// ButtonTemplate returns a template which runs the given commands when switch
+// sw reports the corresponding button events. The button event codes are
+// those of the model of the switch (see Sensor.ButtonCode), so the same
+// mapping can be used for dimmer switches, taps and friends-of-hue switches.
+// If the switch does not report one of the events, an error is returned.
+func ButtonTemplate(name string, sw *Sensor, actions map[ButtonEvent][]Command) (*Template, error) {
+	events := make([]ButtonEvent, 0, len(actions))
+	for e := range actions {
+		events = append(events, e)
+	}
+	sort.Slice(events, func(i, j int) bool {
+		if events[i].Button != events[j].Button {
+			return events[i].Button < events[j].Button
+		}
+		return events[i].Action < events[j].Action
+	})
+	t := &Template{Name: name}
+	for _, e := range events {
+		code, ok := sw.ButtonCode(e)
+		if !ok {
+			return nil, fmt.Errorf("switch %s does not report button %d action %d", sw.ID, e.Button, e.Action)
+		}
+		t.rules = append(t.rules, &Rule{
+			Name: fmt.Sprintf("%s %d.%d", name, e.Button, e.Action),
+			Conditions: []Condition{
+				{Address: "/sensors/" + sw.ID + "/state/buttonevent", Operator: OpEq, Value: fmt.Sprint(code)},
+				{Address: "/sensors/" + sw.ID + "/state/lastupdated", Operator: OpDx},
+			},
+			Actions: actions[e],
+		})
+	}
+	return t, nil
+}
+
+// TapDialTemplate returns a template which maps a Hue tap dial switch to
+// group g: each of its buttons recalls the scene with the corresponding ID in
+// scenes, or turns the group off if the ID is empty, and turning the dial
+// dims the group up or down. Buttons without an entry in scenes are left
+// unmapped. The switch is reported by the bridge as two sensors: buttons, of
+// type ZLLSwitch, and dial, of type ZLLRelativeRotary.
+func TapDialTemplate(name string, buttons, dial *Sensor, g *Group, scenes ...string) (*Template, error) {
+	actions := make(map[ButtonEvent][]Command, len(scenes))
+	for i, id := range scenes {
+		var body interface{} = offState{}
+		if id != "" {
+			body = map[string]string{"scene": id}
+		}
+		actions[ButtonEvent{Button: i + 1, Action: ButtonShortRelease}] = []Command{groupCommand(g, body)}
+	}
+	t, err := ButtonTemplate(name, buttons, actions)
+	if err != nil {
+		return nil, err
+	}
+	rotate := func(suffix, op string, body interface{}) *Rule {
+		return &Rule{
+			Name: name + " " + suffix,
+			Conditions: []Condition{
+				{Address: "/sensors/" + dial.ID + "/state/expectedrotation", Operator: op, Value: "0"},
+				{Address: "/sensors/" + dial.ID + "/state/lastupdated", Operator: OpDx},
+			},
+			Actions: []Command{groupCommand(g, body)},
+		}
+	}
+	t.rules = append(t.rules,
+		rotate("up", OpGt, map[string]interface{}{"on": true, "bri_inc": dimmerStep}),
+		rotate("down", OpLt, map[string]int{"bri_inc": -dimmerStep}),
+	)
+	return t, nil
+}
+
+// DoorLightTemplate returns a template which sets state s on group g when the
+// given door (open/close) sensor reports that the door opened, and turns the
+// group off once the door has been closed for the given timeout.
+func DoorLightTemplate(name string, door *Sensor, g *Group, s *State, timeout time.Duration) *Template {
+	return onOffTemplate(name, "/sensors/"+door.ID+"/state/open", g, s, timeout)
+}
+
+// onOffTemplate returns a template which sets state s on group g when the
+// boolean sensor attribute at addr becomes true, and turns the group off once
+// it has been false for the given timeout.
+func onOffTemplate(name, addr string, g *Group, s *State, timeout time.Duration) *Template {
+	return &Template{
+		Name: name,
+		rules: []*Rule{{
+			Name: name + " on",
+			Conditions: []Condition{
+				{Address: addr, Operator: OpEq, Value: "true"},
+				{Address: addr, Operator: OpDx},
+			},
+			Actions: []Command{groupCommand(g, s)},
+		}, {
+			Name: name + " off",
+			Conditions: []Condition{
+				{Address: addr, Operator: OpEq, Value: "false"},
+				{Address: addr, Operator: OpDdx, Value: ddx(timeout)},
+			},
+			Actions: []Command{groupCommand(g, offState{})},
+		}},
+	}
+}
+
+// groupCommand returns a command which sends body to the action of group g.
+func groupCommand(g *Group, body interface{}) Command {
+	return Command{Address: "/groups/" + g.ID + "/action", Method: http.MethodPut, Body: body}
+}
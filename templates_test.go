@@ -0,0 +1,107 @@
+package hue
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDDX(t *testing.T) {
+	if got := ddx(90 * time.Minute); got != "PT01:30:00" {
+		t.Fatalf("unexpected value %q", got)
+	}
+}
+
+func TestInstallTemplate(t *testing.T) {
+	var (
+		rules   = map[string][]byte{}
+		link    map[string]interface{}
+		deleted []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/api/user/rules":
+			id := fmt.Sprint(len(rules) + 1)
+			rules[id] = body
+			fmt.Fprintf(w, `[{"success": {"id": %q}}]`, id)
+		case r.Method == http.MethodPost && r.URL.Path == "/api/user/resourcelinks":
+			json.Unmarshal(body, &link)
+			w.Write([]byte(`[{"success": {"id": "9"}}]`))
+		case r.Method == http.MethodDelete:
+			deleted = append(deleted, r.URL.Path)
+			w.Write([]byte(`[]`))
+		default:
+			w.Write([]byte(`{}`))
+		}
+	}))
+	defer srv.Close()
+	b := &Bridge{bridgeID: bridgeID{IP: srv.URL + "/"}, username: "user"}
+	presence := &Sensor{ID: "5"}
+	g := &Group{ID: "1"}
+	in, err := b.Install(MotionLightTemplate("Hall", presence, g, &State{On: true}, 5*time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if in.ID != "9" || !reflect.DeepEqual(in.Resources, []string{"/rules/1", "/rules/2"}) {
+		t.Fatalf("unexpected installation %+v", in)
+	}
+	if link["name"] != "Hall" || len(link["links"].([]interface{})) != 2 {
+		t.Fatalf("unexpected resource link %v", link)
+	}
+	var off Rule
+	if err := json.Unmarshal(rules["2"], &off); err != nil {
+		t.Fatal(err)
+	}
+	if off.Conditions[1] != (Condition{Address: "/sensors/5/state/presence", Operator: OpDdx, Value: "PT00:05:00"}) {
+		t.Fatalf("unexpected conditions %v", off.Conditions)
+	}
+	if err := in.Remove(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"/api/user/rules/2", "/api/user/rules/1", "/api/user/resourcelinks/9"}
+	if !reflect.DeepEqual(deleted, want) {
+		t.Fatalf("expected %v, got %v", want, deleted)
+	}
+}
+
+func TestTapDialTemplate(t *testing.T) {
+	buttons := &Sensor{ID: "10", Type: TypeZLLSwitch, ModelID: "RDM002"}
+	dial := &Sensor{ID: "11", Type: TypeZLLRelativeRotary, ModelID: "RDM002"}
+	g := &Group{ID: "3"}
+	tmpl, err := TapDialTemplate("Living", buttons, dial, g, "ab", "", "cd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, r := range tmpl.rules {
+		got = append(got, fmt.Sprintf("%s: %s %s %s", r.Name, r.Conditions[0].Address, r.Conditions[0].Operator, r.Conditions[0].Value))
+	}
+	want := []string{
+		"Living 1.2: /sensors/10/state/buttonevent eq 1002",
+		"Living 2.2: /sensors/10/state/buttonevent eq 2002",
+		"Living 3.2: /sensors/10/state/buttonevent eq 3002",
+		"Living up: /sensors/11/state/expectedrotation gt 0",
+		"Living down: /sensors/11/state/expectedrotation lt 0",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+	if body, ok := tmpl.rules[0].Actions[0].Body.(map[string]string); !ok || body["scene"] != "ab" {
+		t.Fatalf("unexpected action %v", tmpl.rules[0].Actions[0])
+	}
+	if _, ok := tmpl.rules[1].Actions[0].Body.(offState); !ok {
+		t.Fatalf("unexpected action %v", tmpl.rules[1].Actions[0])
+	}
+
+	// the tap only reports presses
+	tap := &Sensor{ID: "12", Type: TypeZGPSwitch, ModelID: ModelTap}
+	if _, err := ButtonTemplate("Tap", tap, map[ButtonEvent][]Command{{1, ButtonShortRelease}: nil}); err == nil {
+		t.Fatal("expected error")
+	}
+}
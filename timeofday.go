@@ -0,0 +1,89 @@
+package hue
+
+import (
+	"sort"
+	"time"
+)
+
+// TimeSlot is a part of the day during which a room is turned on with a given
+// scene. A slot lasts until the next one starts.
+type TimeSlot struct {
+	// Start is the time of day, in local time, at which the slot starts,
+	// given as the time since midnight.
+	Start time.Duration
+
+	// Scene is the name of the scene of the room to recall. If empty, the
+	// lights are turned on in the state they were last in.
+	Scene string
+}
+
+// DefaultTimeSlots returns the slots used by the app for time-based light: the
+// morning starts at 7:00, the day at 10:00, the evening at 18:00 and the night
+// at 23:00. Each slot uses the scene of the given name.
+func DefaultTimeSlots(morning, day, evening, night string) []TimeSlot {
+	return []TimeSlot{
+		{Start: 7 * time.Hour, Scene: morning},
+		{Start: 10 * time.Hour, Scene: day},
+		{Start: 18 * time.Hour, Scene: evening},
+		{Start: 23 * time.Hour, Scene: night},
+	}
+}
+
+// SetTimeSlots sets the slots which Room.TurnOnAppropriately uses for the
+// room whose group has the given ID, replacing any previous ones. Without
+// slots, those of the room are removed.
+func (b *Bridge) SetTimeSlots(groupID string, slots ...TimeSlot) {
+	p := b.groupPrefs
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(slots) == 0 {
+		delete(p.slots, groupID)
+		return
+	}
+	list := append([]TimeSlot(nil), slots...)
+	sort.Slice(list, func(i, j int) bool { return list[i].Start < list[j].Start })
+	p.slots[groupID] = list
+}
+
+// slotAt returns the time slot of the group in effect at time t, if any. Before
+// the first slot of the day, the last one of the previous day is in effect.
+func (g *Group) slotAt(t time.Time) (TimeSlot, bool) {
+	p := g.bridge.groupPrefs
+	if p == nil {
+		return TimeSlot{}, false
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	list := p.slots[g.ID]
+	if len(list) == 0 {
+		return TimeSlot{}, false
+	}
+	h, m, sec := t.Clock()
+	d := time.Duration(h)*time.Hour + time.Duration(m)*time.Minute + time.Duration(sec)*time.Second
+	slot := list[len(list)-1]
+	for _, sl := range list {
+		if sl.Start > d {
+			break
+		}
+		slot = sl
+	}
+	return slot, true
+}
+
+// TurnOnAppropriately turns the lights of the room on with the scene of the
+// time slot in effect at time now, as set using Bridge.SetTimeSlots. It is
+// meant to be called by motion and switch handlers, so it is an automated
+// change: during the quiet hours of the room at time now, it does nothing and
+// returns ErrQuietHours. If the room has no time slots, or the slot has no
+// scene, the lights are turned on in the state they were last in. If the
+// scene of the slot does not exist, ErrSceneNotExist is returned.
+func (r *Room) TurnOnAppropriately(now time.Time) error {
+	if r.Group.isQuiet(now) {
+		return ErrQuietHours
+	}
+	slot, ok := r.Group.slotAt(now)
+	if !ok || slot.Scene == "" {
+		return r.On()
+	}
+	return r.Scene(slot.Scene)
+}
@@ -0,0 +1,61 @@
+package hue
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTurnOnAppropriately(t *testing.T) {
+	mb := mockBridge(t)
+	defer mb.teardown()
+	mb.responses = map[string]interface{}{
+		"/api/bridge_username/groups": map[string]*Group{
+			"2": &Group{Name: "Kitchen", Type: TypeRoom, Lights: []string{"1"}},
+		},
+		"/api/bridge_username/scenes": map[string]*Scene{
+			"ab12": &Scene{Name: "Energize", Type: GroupScene, Group: "2"},
+			"cd34": &Scene{Name: "Nightlight", Type: GroupScene, Group: "2"},
+		},
+		"/api/bridge_username/sensors": map[string]*Sensor{},
+	}
+	r, err := mb.b.Room("Kitchen")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mb.b.SetTimeSlots("2", DefaultTimeSlots("Energize", "", "Relax", "Nightlight")...)
+	at := func(h, m int) time.Time { return time.Date(2017, 1, 1, h, m, 0, 0, time.UTC) }
+	for _, tt := range []struct {
+		t     time.Time
+		scene string
+	}{
+		{at(8, 0), "Energize"},
+		{at(7, 0), "Energize"},
+		{at(2, 30), "Nightlight"},
+		{at(23, 0), "Nightlight"},
+		{at(12, 0), ""},
+		{at(19, 0), "Relax"},
+	} {
+		if slot, ok := r.Group.slotAt(tt.t); !ok || slot.Scene != tt.scene {
+			t.Fatalf("%v: expected %q, got %q", tt.t, tt.scene, slot.Scene)
+		}
+	}
+	mb.nextResponse = []interface{}{}
+	if err := r.TurnOnAppropriately(at(8, 0)); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.TurnOnAppropriately(at(19, 0)); err != ErrSceneNotExist {
+		t.Fatalf("expected ErrSceneNotExist, got %v", err)
+	}
+	mb.b.SetQuietHours("2", QuietHours{From: 19 * time.Hour, To: 7 * time.Hour})
+	mb.lastMethod = ""
+	if err := r.TurnOnAppropriately(at(2, 30)); err != ErrQuietHours {
+		t.Fatalf("expected ErrQuietHours, got %v", err)
+	}
+	if mb.lastMethod != "" {
+		t.Fatalf("unexpected request %s %s", mb.lastMethod, mb.lastPath)
+	}
+	mb.b.SetTimeSlots("2")
+	if _, ok := r.Group.slotAt(at(8, 0)); ok {
+		t.Fatal("expected slots to be removed")
+	}
+}
@@ -0,0 +1,91 @@
+package hue
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// Location returns the timezone of the bridge. A bridge whose timezone is not
+// set reports "none", in which case it uses UTC. The timezone database of the
+// host is used; on systems without one, such as some containers, import the
+// time/tzdata package.
+func (c *Config) Location() (*time.Location, error) {
+	switch c.TimeZone {
+	case "", "none":
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(c.TimeZone)
+	if err != nil {
+		return nil, fmt.Errorf("bridge timezone: %v", err)
+	}
+	return loc, nil
+}
+
+// Location retrieves the timezone of the bridge, as described by
+// Config.Location.
+func (b *Bridge) Location() (*time.Location, error) {
+	c, err := b.Config()
+	if err != nil {
+		return nil, err
+	}
+	return c.Location()
+}
+
+// FormatBridgeTime returns t as a local time of the bridge, whose timezone is
+// loc, for use in schedules. Times in any timezone are converted, so that a
+// schedule fires at the same instant as t regardless of the timezone of the
+// host.
+func FormatBridgeTime(t time.Time, loc *time.Location) string {
+	return t.In(loc).Format(timeLayout)
+}
+
+// ParseBridgeTime parses the local time s of the bridge, whose timezone is
+// loc, such as the time of a schedule. Local times which occur twice or not at
+// all because of a daylight saving time transition are resolved as by
+// time.Date, which picks one of the offsets in effect around the transition.
+func ParseBridgeTime(s string, loc *time.Location) (time.Time, error) {
+	return time.ParseInLocation(timeLayout, s, loc)
+}
+
+// recurringTime matches the local time of a schedule which recurs weekly,
+// capturing the weekdays and the time of day.
+var recurringTime = regexp.MustCompile(`^W(\d{1,3})/T(\d\d):(\d\d):(\d\d)$`)
+
+// ConvertWeekly converts the local time of a schedule which recurs weekly,
+// such as "W124/T07:30:00", from timezone from to timezone to, for example from
+// the timezone of the host to that of the bridge. Since the offset between two
+// timezones changes with daylight saving time, the conversion is made for the
+// week of the given date; a schedule converted in summer fires an hour off in
+// winter if only one of the timezones observes daylight saving time. When the
+// time of day moves to another day, the weekdays are shifted accordingly.
+func ConvertWeekly(localTime string, from, to *time.Location, date time.Time) (string, error) {
+	m := recurringTime.FindStringSubmatch(localTime)
+	if m == nil {
+		return "", fmt.Errorf("%q is not a weekly recurring time", localTime)
+	}
+	days, _ := strconv.Atoi(m[1])
+	h, _ := strconv.Atoi(m[2])
+	min, _ := strconv.Atoi(m[3])
+	sec, _ := strconv.Atoi(m[4])
+	if days <= 0 || days > 127 || h > 23 || min > 59 || sec > 59 {
+		return "", fmt.Errorf("%q is not a weekly recurring time", localTime)
+	}
+	y, mo, d := date.In(from).Date()
+	src := time.Date(y, mo, d, h, min, sec, 0, from)
+	dst := src.In(to)
+	// the difference in days between both dates is -1, 0 or 1
+	ys, ms, ds := src.Date()
+	yd, md, dd := dst.Date()
+	shift := int(time.Date(yd, md, dd, 0, 0, 0, 0, time.UTC).Sub(time.Date(ys, ms, ds, 0, 0, 0, 0, time.UTC)) / (24 * time.Hour))
+	// bit 6 is Monday and bit 0 is Sunday, so that moving a day later
+	// shifts each bit one to the right
+	switch shift {
+	case 1:
+		days = days>>1 | (days&1)<<6
+	case -1:
+		days = (days<<1)&127 | days>>6
+	}
+	return fmt.Sprintf("W%d/T%s", days, dst.Format("15:04:05")), nil
+}
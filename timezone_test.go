@@ -0,0 +1,81 @@
+package hue
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBridgeTime(t *testing.T) {
+	ams, err := (&Config{TimeZone: "Europe/Amsterdam"}).Location()
+	if err != nil {
+		t.Skipf("no timezone database: %v", err)
+	}
+	if loc, err := (&Config{TimeZone: "none"}).Location(); err != nil || loc != time.UTC {
+		t.Fatalf("expected UTC, got %v (%v)", loc, err)
+	}
+	if _, err := (&Config{TimeZone: "Mars/Olympus"}).Location(); err == nil {
+		t.Fatal("expected an error")
+	}
+	for _, tt := range []struct {
+		utc  time.Time
+		want string
+	}{
+		{time.Date(2017, 1, 10, 6, 30, 0, 0, time.UTC), "2017-01-10T07:30:00"},
+		{time.Date(2017, 7, 10, 6, 30, 0, 0, time.UTC), "2017-07-10T08:30:00"},
+		// the day clocks are set forward
+		{time.Date(2017, 3, 26, 0, 30, 0, 0, time.UTC), "2017-03-26T01:30:00"},
+		{time.Date(2017, 3, 26, 1, 30, 0, 0, time.UTC), "2017-03-26T03:30:00"},
+	} {
+		got := FormatBridgeTime(tt.utc, ams)
+		if got != tt.want {
+			t.Fatalf("expected %s, got %s", tt.want, got)
+		}
+		back, err := ParseBridgeTime(got, ams)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !back.Equal(tt.utc) {
+			t.Fatalf("expected %v, got %v", tt.utc, back)
+		}
+	}
+}
+
+func TestConvertWeekly(t *testing.T) {
+	ams, err := time.LoadLocation("Europe/Amsterdam")
+	if err != nil {
+		t.Skipf("no timezone database: %v", err)
+	}
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("no timezone database: %v", err)
+	}
+	winter := time.Date(2017, 1, 10, 12, 0, 0, 0, time.UTC)
+	summer := time.Date(2017, 7, 10, 12, 0, 0, 0, time.UTC)
+	for _, tt := range []struct {
+		in       string
+		from, to *time.Location
+		date     time.Time
+		want     string
+	}{
+		{"W124/T07:30:00", ams, time.UTC, winter, "W124/T06:30:00"},
+		{"W124/T07:30:00", ams, time.UTC, summer, "W124/T05:30:00"},
+		// Monday and Sunday move to Sunday and Saturday
+		{"W65/T00:30:00", ams, time.UTC, winter, "W3/T23:30:00"},
+		// Monday and Sunday move to Tuesday and Monday
+		{"W65/T20:00:00", ny, time.UTC, winter, "W96/T01:00:00"},
+		{"W127/T20:00:00", ny, ams, summer, "W127/T02:00:00"},
+	} {
+		got, err := ConvertWeekly(tt.in, tt.from, tt.to, tt.date)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Fatalf("%s: expected %s, got %s", tt.in, tt.want, got)
+		}
+	}
+	for _, bad := range []string{"2017-01-01T07:30:00", "W0/T07:30:00", "W200/T07:30:00", "W1/T25:00:00"} {
+		if _, err := ConvertWeekly(bad, ams, time.UTC, winter); err == nil {
+			t.Fatalf("%s: expected an error", bad)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package hue
+
+import (
+	"sync"
+	"time"
+)
+
+// transitionTime converts d to a transition time in multiples of 100ms, as
+// used by the bridge. A negative duration results in nil, meaning that no
+// default is set.
+func transitionTime(d time.Duration) *uint16 {
+	if d < 0 {
+		return nil
+	}
+	t := (d + 50*time.Millisecond) / (100 * time.Millisecond)
+	if t > 1<<16-1 {
+		t = 1<<16 - 1
+	}
+	v := uint16(t)
+	return &v
+}
+
+// SetDefaultTransition sets the transition time used by all state changes
+// sent to the lights and groups of this bridge, unless they set one, or a
+// default is set on the light or group itself. A duration of zero makes
+// changes instant, while a negative duration removes the default, in which
+// case the bridge uses 400ms.
+func (b *Bridge) SetDefaultTransition(d time.Duration) { b.transition = transitionTime(d) }
+
+// SetDefaultTransition sets the transition time used by state changes sent to
+// this light, unless they set one. It takes precedence over the default of the
+// bridge. The default is kept by the bridge for the ID of the light, so it
+// also applies to the light when it is retrieved again. A negative duration
+// removes the default.
+func (l *Light) SetDefaultTransition(d time.Duration) {
+	l.bridge.transitions.set("lights/"+l.ID, d)
+}
+
+// SetDefaultTransition sets the transition time used by state changes sent to
+// this group, unless they set one. It takes precedence over the default of the
+// bridge. The default is kept by the bridge for the ID of the group, so it
+// also applies to the group when it is retrieved again. A negative duration
+// removes the default.
+func (g *Group) SetDefaultTransition(d time.Duration) {
+	g.bridge.transitions.set("groups/"+g.ID, d)
+}
+
+// transitions holds the default transition times of lights and groups, keyed
+// by their resource path, e.g. "lights/1".
+type transitions struct {
+	mu    sync.Mutex
+	times map[string]uint16
+}
+
+// newTransitions returns transitions without any defaults.
+func newTransitions() *transitions {
+	return &transitions{times: make(map[string]uint16)}
+}
+
+// set sets the default transition time of the resource at path p to d, or
+// removes it if d is negative.
+func (t *transitions) set(p string, d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	v := transitionTime(d)
+	if v == nil {
+		delete(t.times, p)
+		return
+	}
+	t.times[p] = *v
+}
+
+// get returns the default transition time of the resource at path p, if any.
+func (t *transitions) get(p string) *uint16 {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	v, ok := t.times[p]
+	if !ok {
+		return nil
+	}
+	return &v
+}
+
+// stateWithTransition is a State sent along with a transition time. Unlike
+// State.TransitionTime, the transition time is sent even if it is zero.
+type stateWithTransition struct {
+	*State
+	TransitionTime uint16 `json:"transitiontime"`
+}
+
+// offState is the payload which turns lights off.
+type offState struct {
+	On             bool    `json:"on"`
+	TransitionTime *uint16 `json:"transitiontime,omitempty"`
+}
+
+// statePayload returns the payload which sets state s, using the default
+// transition time def, unless it is nil or s sets one.
+func statePayload(s *State, def *uint16) interface{} {
+	if s.TransitionTime != 0 || def == nil {
+		return s
+	}
+	return stateWithTransition{State: s, TransitionTime: *def}
+}
+
+// defaultTransition returns the default transition time of l, if any.
+func (l *Light) defaultTransition() *uint16 {
+	if v := l.bridge.transitions.get("lights/" + l.ID); v != nil {
+		return v
+	}
+	return l.bridge.transition
+}
+
+// defaultTransition returns the default transition time of g, if any.
+func (g *Group) defaultTransition() *uint16 {
+	if v := g.bridge.transitions.get("groups/" + g.ID); v != nil {
+		return v
+	}
+	return g.bridge.transition
+}
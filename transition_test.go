@@ -0,0 +1,52 @@
+package hue
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDefaultTransition(t *testing.T) {
+	var puts []map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut {
+			var body map[string]interface{}
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				t.Error(err)
+			}
+			puts = append(puts, body)
+			w.Write([]byte(`[{"success":{}}]`))
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+	b := &Bridge{bridgeID: bridgeID{IP: srv.URL + "/"}, username: "user", transitions: newTransitions()}
+	l := &Light{bridge: b, ID: "1"}
+	g := &Group{bridge: b, ID: "1"}
+
+	l.On()
+	b.SetDefaultTransition(0)
+	l.On()
+	l.Off()
+	g.Set(&State{Brightness: 10, TransitionTime: 5})
+	l.SetDefaultTransition(time.Second)
+	// the default belongs to the light, not to this value of it
+	(&Light{bridge: b, ID: "1"}).On()
+	g.Off()
+
+	want := []map[string]interface{}{
+		{"on": true},
+		{"on": true, "transitiontime": 0.0},
+		{"on": false, "transitiontime": 0.0},
+		{"bri": 10.0, "transitiontime": 5.0},
+		{"on": true, "transitiontime": 10.0},
+		{"on": false, "transitiontime": 0.0},
+	}
+	if !reflect.DeepEqual(puts, want) {
+		t.Fatalf("expected %v, got %v", want, puts)
+	}
+}
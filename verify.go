@@ -0,0 +1,144 @@
+package hue
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// verifyDelay is the time after which the state of lights is first read back
+// by the verified commands. It doubles after every attempt.
+var verifyDelay = time.Second
+
+// VerifyError is returned by verified commands when lights did not reach the
+// requested state, even after retrying.
+type VerifyError struct {
+	// Lights holds the lights which did not comply, with the state they were
+	// last seen in.
+	Lights []*Light
+}
+
+func (e *VerifyError) Error() string {
+	ids := make([]string, len(e.Lights))
+	for i, l := range e.Lights {
+		ids[i] = l.ID
+	}
+	return fmt.Sprintf("lights %v did not reach the requested state", ids)
+}
+
+// SetVerified is like Set, but reads back the state of the light after a short
+// delay and sends the command again if the light did not comply, up to
+// retries times, doubling the delay every time. Only the on state and
+// brightness are checked. If the light still did not comply, a *VerifyError
+// is returned. Errors which are not Retryable, such as invalid values, are
+// returned without retrying. SetVerified is meant for critical automations,
+// since commands are occasionally lost on the way to a light.
+func (l *Light) SetVerified(s *State, retries int) error {
+	if err := l.Set(s); err != nil {
+		return err
+	}
+	return l.bridge.verify([]string{l.ID}, s, retries)
+}
+
+// OffVerified is like Off, but verifies that the light turned off in the same
+// way as SetVerified.
+func (l *Light) OffVerified(retries int) error {
+	if err := l.Off(); err != nil {
+		return err
+	}
+	return l.bridge.verify([]string{l.ID}, nil, retries)
+}
+
+// SetVerified is like Set, but verifies that the lights of the group reached
+// the state in the same way as Light.SetVerified. The command is sent again
+// only to the lights which did not comply.
+func (g *Group) SetVerified(s *State, retries int) error {
+	if err := g.Set(s); err != nil {
+		return err
+	}
+	return g.bridge.verify(g.lightIDs(), s, retries)
+}
+
+// OffVerified is like Off, but verifies that the lights of the group turned
+// off in the same way as SetVerified. For example, it may be used for turning
+// all lights off at night, using group 0.
+func (g *Group) OffVerified(retries int) error {
+	if err := g.Off(); err != nil {
+		return err
+	}
+	return g.bridge.verify(g.lightIDs(), nil, retries)
+}
+
+// lightIDs returns the IDs of the lights of the group, or nil for group 0,
+// which holds all lights.
+func (g *Group) lightIDs() []string {
+	if g.ID == "0" {
+		return nil
+	}
+	return g.Lights
+}
+
+// verify checks that the lights with the given IDs, or all lights if ids is
+// nil, reached state s, or are off if s is nil. Lights which did not are sent
+// the command again, up to retries times. Errors which are not Retryable end
+// the retries and are returned as they are.
+func (b *Bridge) verify(ids []string, s *State, retries int) error {
+	delay := verifyDelay
+	for try := 0; ; try++ {
+		b.sleep(delay)
+		all, err := b.Lights().idMap()
+		if err != nil {
+			if !Retryable(err) || try == retries {
+				return err
+			}
+			delay *= 2
+			continue
+		}
+		var failed []*Light
+		for id, l := range all {
+			if (ids == nil || contains(ids, id)) && !l.complies(s) {
+				failed = append(failed, l)
+			}
+		}
+		if len(failed) == 0 {
+			return nil
+		}
+		if try == retries {
+			sort.Slice(failed, func(i, j int) bool { return lessID(failed[i].ID, failed[j].ID) })
+			return &VerifyError{Lights: failed}
+		}
+		for _, l := range failed {
+			if s == nil {
+				err = l.Off()
+			} else {
+				err = l.Set(s)
+			}
+			// temporary errors are reported by the next check
+			if err != nil && !Retryable(err) {
+				return err
+			}
+		}
+		delay *= 2
+	}
+}
+
+// complies reports whether light l reached state s, or is off if s is nil.
+// With WithColorCompensation, the brightness may be either the requested one,
+// as set by group commands, or the one adjusted for the model of the light.
+func (l *Light) complies(s *State) bool {
+	if s == nil {
+		return complies(&l.State, nil)
+	}
+	return complies(&l.State, s) || complies(&l.State, l.compensate(s))
+}
+
+// complies reports whether ls matches state s, or is off if s is nil.
+func complies(ls *LightState, s *State) bool {
+	if s == nil {
+		return !ls.On
+	}
+	if s.On && !ls.On {
+		return false
+	}
+	return s.Brightness == 0 || s.Brightness == ls.Brightness
+}
@@ -0,0 +1,77 @@
+package hue
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOffVerified(t *testing.T) {
+	defer func(d time.Duration) { verifyDelay = d }(verifyDelay)
+	verifyDelay = time.Millisecond
+	// light 1 complies after one retry, light 2 never does
+	offs := map[string]int{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPut && r.URL.Path == "/api/user/groups/1/action":
+			offs["1"]++
+			offs["2"]++
+			w.Write([]byte(`[]`))
+		case r.Method == http.MethodPut:
+			offs[strings.Split(r.URL.Path, "/")[4]]++
+			w.Write([]byte(`[]`))
+		case r.URL.Path == "/api/user/groups/1":
+			w.Write([]byte(`{"name": "g", "lights": ["1", "2"]}`))
+		case r.URL.Path == "/api/user/lights":
+			on1 := offs["1"] < 2
+			w.Write([]byte(`{"1": {"state": {"on": ` + strconv.FormatBool(on1) + `}}, "2": {"state": {"on": true}}, "3": {"state": {"on": true}}}`))
+		default:
+			w.Write([]byte(`{}`))
+		}
+	}))
+	defer srv.Close()
+	b := &Bridge{bridgeID: bridgeID{IP: srv.URL + "/"}, username: "user"}
+	g := &Group{bridge: b, ID: "1", Lights: []string{"1", "2"}}
+	err := g.OffVerified(2)
+	e, ok := err.(*VerifyError)
+	if !ok || len(e.Lights) != 1 || e.Lights[0].ID != "2" {
+		t.Fatalf("expected light 2 to fail, got %v", err)
+	}
+	if offs["1"] != 2 || offs["2"] != 3 {
+		t.Fatalf("unexpected commands %v", offs)
+	}
+}
+
+func TestSetVerifiedPermanentError(t *testing.T) {
+	defer func(d time.Duration) { verifyDelay = d }(verifyDelay)
+	verifyDelay = time.Millisecond
+	var puts int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPut:
+			puts++
+			if puts > 1 {
+				w.Write([]byte(`[{"error": {"type": 7, "address": "/lights/1/state/bri", "description": "invalid value"}}]`))
+				return
+			}
+			w.Write([]byte(`[]`))
+		case r.URL.Path == "/api/user/lights":
+			w.Write([]byte(`{"1": {"state": {"on": false}}}`))
+		default:
+			w.Write([]byte(`{"state": {"on": false}}`))
+		}
+	}))
+	defer srv.Close()
+	b := &Bridge{bridgeID: bridgeID{IP: srv.URL + "/"}, username: "user"}
+	l := &Light{bridge: b, ID: "1"}
+	err := l.SetVerified(&State{On: true, Brightness: 100}, 5)
+	if e, ok := err.(APIError); !ok || e.Code != 7 {
+		t.Fatalf("expected the APIError of the resend, got %v", err)
+	}
+	if puts != 2 {
+		t.Fatalf("expected to stop after the first resend, got %d commands", puts)
+	}
+}
@@ -0,0 +1,173 @@
+package hue
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// webhookRetryDelay is the delay before retrying a failed delivery. It doubles
+// with every attempt.
+var webhookRetryDelay = time.Second
+
+// SignatureHeader is the HTTP header which holds the signature of webhook
+// deliveries, in the form "sha256=<hex>". The signature is the HMAC-SHA256 of
+// the request body, keyed with the secret of the webhook.
+const SignatureHeader = "X-Hue-Signature"
+
+// Webhook forwards changes to an HTTP endpoint, so that other services, such
+// as serverless functions, can react to them without using this package.
+type Webhook struct {
+	// URL is the URL that changes are POSTed to, as a JSON object holding the
+	// time and the list of changes.
+	URL string
+
+	// Secret, if not empty, is used to sign deliveries. See SignatureHeader.
+	Secret []byte
+
+	// Filter, if not nil, reports whether a change should be delivered.
+	Filter func(Change) bool
+
+	// Retries is the number of times that a failed delivery is retried.
+	Retries int
+
+	// Client is the HTTP client used for deliveries. If nil,
+	// http.DefaultClient is used.
+	Client *http.Client
+}
+
+// WebhookError is returned when the endpoint of a webhook does not accept a
+// delivery.
+type WebhookError struct {
+	// URL is the URL of the webhook.
+	URL string
+
+	// Status is the HTTP status code of the last attempt. It is zero if the
+	// endpoint could not be reached.
+	Status int
+
+	// Err is the error of the last attempt, if the endpoint could not be
+	// reached.
+	Err error
+}
+
+func (e *WebhookError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("webhook %s: %v", e.URL, e.Err)
+	}
+	return fmt.Sprintf("webhook %s: status %d", e.URL, e.Status)
+}
+
+// webhookPayload is the body of a delivery.
+type webhookPayload struct {
+	Time    time.Time `json:"time"`
+	Changes []Change  `json:"changes"`
+}
+
+// Deliver POSTs the changes which pass the filter of the webhook to its URL.
+// Nothing is sent if none do. Responses other than 2xx, and failures to reach
+// the endpoint, are retried with increasing delays. If all attempts fail, a
+// *WebhookError is returned.
+func (w *Webhook) Deliver(t time.Time, changes []Change) error {
+	var list []Change
+	for _, c := range changes {
+		if w.Filter == nil || w.Filter(c) {
+			list = append(list, c)
+		}
+	}
+	if len(list) == 0 {
+		return nil
+	}
+	body, err := json.Marshal(webhookPayload{Time: t, Changes: list})
+	if err != nil {
+		return err
+	}
+	client := w.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	delay := webhookRetryDelay
+	var werr *WebhookError
+	for attempt := 0; attempt <= w.Retries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(delay)
+			delay *= 2
+		}
+		if werr = w.post(client, body); werr == nil {
+			return nil
+		}
+	}
+	return werr
+}
+
+// post makes one delivery attempt.
+func (w *Webhook) post(client *http.Client, body []byte) *WebhookError {
+	req, err := http.NewRequest(http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return &WebhookError{URL: w.URL, Err: err}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(w.Secret) > 0 {
+		req.Header.Set(SignatureHeader, "sha256="+sign(w.Secret, body))
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return &WebhookError{URL: w.URL, Err: err}
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &WebhookError{URL: w.URL, Status: resp.StatusCode}
+	}
+	return nil
+}
+
+// sign returns the hex encoded HMAC-SHA256 of body, keyed with secret.
+func sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ForwardChanges takes a snapshot of the bridge every interval and delivers
+// the changes since the previous one to the given webhooks, until the
+// context is done, which it returns the error of. Since the bridge does not
+// push changes, they are detected by comparing snapshots using Diff. Failures
+// to take a snapshot or to deliver changes are passed to onError, if it is not
+// nil, and do not stop forwarding.
+func (b *Bridge) ForwardChanges(ctx context.Context, interval time.Duration, onError func(error), hooks ...*Webhook) error {
+	report := func(err error) {
+		if onError != nil {
+			onError(err)
+		}
+	}
+	prev, err := b.Snapshot()
+	if err != nil {
+		return err
+	}
+	clock := clockOf(b.clock)
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-clock.After(interval):
+		}
+		cur, err := b.Snapshot()
+		if err != nil {
+			report(err)
+			continue
+		}
+		changes := Diff(prev, cur)
+		prev = cur
+		for _, w := range hooks {
+			if err := w.Deliver(cur.Time, changes); err != nil {
+				report(err)
+			}
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package hue
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWebhookDeliver(t *testing.T) {
+	defer func(d time.Duration) { webhookRetryDelay = d }(webhookRetryDelay)
+	webhookRetryDelay = time.Millisecond
+	var attempts int32
+	var got webhookPayload
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		if sig := r.Header.Get(SignatureHeader); sig != "sha256="+sign([]byte("secret"), body) {
+			t.Errorf("bad signature %q", sig)
+		}
+		if err := json.Unmarshal(body, &got); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer srv.Close()
+	w := &Webhook{
+		URL:     srv.URL,
+		Secret:  []byte("secret"),
+		Filter:  func(c Change) bool { return c.Kind != ChangeModified },
+		Retries: 1,
+	}
+	changes := []Change{
+		{Resource: "/lights/1", Kind: ChangeRenamed, Name: "Desk", OldName: "Hue color lamp 1"},
+		{Resource: "/scenes/ab", Kind: ChangeModified, Name: "Relax"},
+	}
+	if err := w.Deliver(time.Unix(0, 0).UTC(), changes); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got.Changes, changes[:1]) {
+		t.Fatalf("expected %v, got %v", changes[:1], got.Changes)
+	}
+
+	w.Retries = 0
+	atomic.StoreInt32(&attempts, 0)
+	if err := w.Deliver(time.Unix(0, 0), changes); err == nil {
+		t.Fatal("expected error")
+	} else if e, ok := err.(*WebhookError); !ok || e.Status != http.StatusBadGateway {
+		t.Fatalf("expected *WebhookError, got %v", err)
+	}
+}
+
+func TestForwardChanges(t *testing.T) {
+	var gets int32
+	bridge := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/u/lights" {
+			w.Write([]byte(`{}`))
+			return
+		}
+		if atomic.AddInt32(&gets, 1) == 1 {
+			w.Write([]byte(`{"1": {"name": "a"}}`))
+			return
+		}
+		w.Write([]byte(`{"1": {"name": "b"}}`))
+	}))
+	defer bridge.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var got webhookPayload
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+		cancel()
+	}))
+	defer hook.Close()
+	b := &Bridge{
+		bridgeID: bridgeID{IP: bridge.URL + "/"},
+		username: "u",
+		clock:    &fakeClock{t: time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	err := b.ForwardChanges(ctx, time.Minute, func(err error) { t.Error(err) }, &Webhook{URL: hook.URL})
+	if err != context.Canceled {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	want := []Change{{Resource: "/lights/1", Kind: ChangeRenamed, Name: "b", OldName: "a"}}
+	if !reflect.DeepEqual(got.Changes, want) {
+		t.Fatalf("expected %v, got %v", want, got.Changes)
+	}
+}
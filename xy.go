@@ -0,0 +1,163 @@
+package hue
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"image/color"
+	"math"
+)
+
+// ErrInvalidXY is returned by XY.Validate for coordinates outside the range
+// accepted by the bridge.
+var ErrInvalidXY = errors.New("xy coordinates must be between 0 and 1")
+
+// XY holds the x and y coordinates of a color in CIE color space. Both must
+// be between 0 and 1.
+type XY [2]float64
+
+// X returns the x coordinate.
+func (p XY) X() float64 { return p[0] }
+
+// Y returns the y coordinate.
+func (p XY) Y() float64 { return p[1] }
+
+// XYPtr returns a pointer to the coordinates x and y, for setting the XY field
+// of a State in a single expression, e.g. &State{XY: XYPtr(0.3, 0.4)}.
+func XYPtr(x, y float64) *XY { return &XY{x, y} }
+
+// SetXY sets the color of the state to the coordinates x and y and returns s,
+// so that calls may be chained, e.g. (&State{On: true}).SetXY(0.3, 0.4).
+func (s *State) SetXY(x, y float64) *State {
+	s.XY = XYPtr(x, y)
+	return s
+}
+
+// UnmarshalJSON decodes coordinates given either as an array, as used by the
+// bridge, or as an object with x and y fields.
+func (p *XY) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '{' {
+		var v struct{ X, Y float64 }
+		if err := json.Unmarshal(data, &v); err != nil {
+			return err
+		}
+		*p = XY{v.X, v.Y}
+		return nil
+	}
+	return json.Unmarshal(data, (*[2]float64)(p))
+}
+
+// Validate returns ErrInvalidXY if either coordinate is outside [0, 1].
+func (p XY) Validate() error {
+	if p[0] < 0 || p[0] > 1 || p[1] < 0 || p[1] > 1 || math.IsNaN(p[0]) || math.IsNaN(p[1]) {
+		return ErrInvalidXY
+	}
+	return nil
+}
+
+// Distance returns the Euclidean distance between p and q.
+func (p XY) Distance(q XY) float64 { return math.Hypot(p[0]-q[0], p[1]-q[1]) }
+
+// RGB returns the color p at full brightness, converted to sRGB.
+func (p XY) RGB() color.RGBA {
+	r, g, b := xyToRGB(p[0], p[1])
+	return color.RGBA{R: uint8(math.Round(r * 255)), G: uint8(math.Round(g * 255)), B: uint8(math.Round(b * 255)), A: 255}
+}
+
+// XYFromRGB returns the CIE coordinates of the sRGB color c, ignoring its
+// brightness. Black is mapped to the white point D65.
+func XYFromRGB(c color.Color) XY {
+	r16, g16, b16, _ := c.RGBA()
+	r, g, b := linear(float64(r16)/0xffff), linear(float64(g16)/0xffff), linear(float64(b16)/0xffff)
+	X := r*0.4124 + g*0.3576 + b*0.1805
+	Y := r*0.2126 + g*0.7152 + b*0.0722
+	Z := r*0.0193 + g*0.1192 + b*0.9505
+	sum := X + Y + Z
+	if sum == 0 {
+		return WhitePoint
+	}
+	return XY{X / sum, Y / sum}
+}
+
+// linear applies the inverse of the sRGB transfer function to component c.
+func linear(c float64) float64 {
+	if c <= 0.04045 {
+		return c / 12.92
+	}
+	return math.Pow((c+0.055)/1.055, 2.4)
+}
+
+// WhitePoint is the D65 white point.
+var WhitePoint = XY{0.3127, 0.3290}
+
+// Gamut is the triangle of colors in CIE color space that a light can show.
+type Gamut struct{ Red, Green, Blue XY }
+
+// The gamuts of Hue lights. The gamut of a light depends on its model. For
+// more information see:
+// https://developers.meethue.com/documentation/supported-lights
+var (
+	// GamutA is the gamut of LivingColors and early lightstrips.
+	GamutA = Gamut{Red: XY{0.704, 0.296}, Green: XY{0.2151, 0.7106}, Blue: XY{0.138, 0.08}}
+
+	// GamutB is the gamut of the first generations of Hue bulbs.
+	GamutB = Gamut{Red: XY{0.675, 0.322}, Green: XY{0.409, 0.518}, Blue: XY{0.167, 0.04}}
+
+	// GamutC is the gamut of newer Hue bulbs and lightstrips.
+	GamutC = Gamut{Red: XY{0.6915, 0.3083}, Green: XY{0.17, 0.7}, Blue: XY{0.1532, 0.0475}}
+)
+
+// Contains reports whether p is within the gamut.
+func (g Gamut) Contains(p XY) bool {
+	d1 := cross(g.Red, g.Green, p)
+	d2 := cross(g.Green, g.Blue, p)
+	d3 := cross(g.Blue, g.Red, p)
+	neg := d1 < 0 || d2 < 0 || d3 < 0
+	pos := d1 > 0 || d2 > 0 || d3 > 0
+	return !(neg && pos)
+}
+
+// cross returns the z component of the cross product of b-a and p-a, whose
+// sign tells on which side of the line through a and b the point p lies.
+func cross(a, b, p XY) float64 {
+	return (b[0]-a[0])*(p[1]-a[1]) - (b[1]-a[1])*(p[0]-a[0])
+}
+
+// Clamp returns p if it is within the gamut, or otherwise the point where the
+// line from p towards ref, which must be within the gamut, enters it. This
+// keeps the hue of p, unlike clamping to the closest point of the gamut. The
+// white point is a common choice for ref, though it lies just outside of
+// GamutB.
+func (g Gamut) Clamp(p, ref XY) XY {
+	if g.Contains(p) {
+		return p
+	}
+	best := ref
+	bestT := math.Inf(1)
+	for _, edge := range [][2]XY{{g.Red, g.Green}, {g.Green, g.Blue}, {g.Blue, g.Red}} {
+		if t, ok := intersect(p, ref, edge[0], edge[1]); ok && t < bestT {
+			bestT = t
+			best = XY{p[0] + t*(ref[0]-p[0]), p[1] + t*(ref[1]-p[1])}
+		}
+	}
+	return best
+}
+
+// intersect returns the position t, between 0 and 1, of the intersection of
+// the segment from p to q with the segment from a to b, such that the
+// intersection is p+t(q-p).
+func intersect(p, q, a, b XY) (float64, bool) {
+	r := XY{q[0] - p[0], q[1] - p[1]}
+	s := XY{b[0] - a[0], b[1] - a[1]}
+	den := r[0]*s[1] - r[1]*s[0]
+	if den == 0 {
+		return 0, false
+	}
+	t := ((a[0]-p[0])*s[1] - (a[1]-p[1])*s[0]) / den
+	u := ((a[0]-p[0])*r[1] - (a[1]-p[1])*r[0]) / den
+	if t < 0 || t > 1 || u < 0 || u > 1 {
+		return 0, false
+	}
+	return t, true
+}
@@ -0,0 +1,72 @@
+package hue
+
+import (
+	"encoding/json"
+	"image/color"
+	"math"
+	"testing"
+)
+
+func TestXYValidate(t *testing.T) {
+	if err := (XY{0.3, 0.3}).Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := (XY{1.2, 0.3}).Validate(); err != ErrInvalidXY {
+		t.Fatalf("expected ErrInvalidXY, got %v", err)
+	}
+}
+
+func TestXYFromRGB(t *testing.T) {
+	if p := XYFromRGB(color.White); p.Distance(WhitePoint) > 0.001 {
+		t.Fatalf("expected white point, got %v", p)
+	}
+	red := XYFromRGB(color.RGBA{R: 255, A: 255})
+	if red.Distance(XY{0.64, 0.33}) > 0.001 {
+		t.Fatalf("expected sRGB red, got %v", red)
+	}
+	if c := red.RGB(); c.R != 255 || c.G > 1 || c.B > 1 {
+		t.Fatalf("expected red, got %v", c)
+	}
+}
+
+func TestGamutClamp(t *testing.T) {
+	if !GamutC.Contains(WhitePoint) || GamutB.Contains(XY{0.7, 0.3}) {
+		t.Fatal("unexpected containment")
+	}
+	in := XY{0.4, 0.4}
+	if got := GamutC.Clamp(in, WhitePoint); got != in {
+		t.Fatalf("expected %v unchanged, got %v", in, got)
+	}
+	p := XY{0.1, 0.8}
+	got := GamutC.Clamp(p, WhitePoint)
+	if got == WhitePoint || !GamutC.Contains(XY{got[0] + (WhitePoint[0]-got[0])*1e-9, got[1] + (WhitePoint[1]-got[1])*1e-9}) {
+		t.Fatalf("expected %v on the gamut", got)
+	}
+	// the clamped point lies on the line from p to the white point
+	if c := cross(p, WhitePoint, got); math.Abs(c) > 1e-9 {
+		t.Fatalf("expected %v on the line towards the white point", got)
+	}
+}
+
+func TestXYJSON(t *testing.T) {
+	for _, in := range []string{`{"xy": [0.3, 0.4]}`, `{"xy": {"x": 0.3, "y": 0.4}}`, `{"xy": {"X": 0.3, "Y": 0.4}}`} {
+		var s State
+		if err := json.Unmarshal([]byte(in), &s); err != nil {
+			t.Fatal(err)
+		}
+		if s.XY == nil || *s.XY != (XY{0.3, 0.4}) {
+			t.Fatalf("%s: unexpected coordinates %v", in, s.XY)
+		}
+	}
+	var s State
+	if err := json.Unmarshal([]byte(`{"xy": "red"}`), &s); err == nil {
+		t.Fatal("expected an error")
+	}
+	data, err := json.Marshal((&State{On: true}).SetXY(0.3, 0.4))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"on":true,"xy":[0.3,0.4]}` {
+		t.Fatalf("unexpected encoding %s", data)
+	}
+}